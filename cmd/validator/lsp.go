@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	configfilevalidator "github.com/Boeing/config-file-validator/v2"
+	"github.com/Boeing/config-file-validator/v2/pkg/finder"
+	"github.com/Boeing/config-file-validator/v2/pkg/lsp"
+	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+)
+
+// lspCommand is the first argument that starts the language server instead
+// of a validation run. A search path named "lsp" must be written as "./lsp".
+const lspCommand = "lsp"
+
+// runLSP runs a language server on in and out that validates the documents
+// an editor opens, with the same configuration a normal run would use:
+// flags, environment variables and .cfv.toml. Flags that only affect which
+// files a run finds or how it reports them are ignored.
+func runLSP(args []string, in io.Reader, out io.Writer) int {
+	cfg, err := getFlags(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	if flagSet.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "lsp does not take search paths")
+		return 2
+	}
+	if *cfg.watch || *cfg.gitRev != "" || *cfg.filesFrom != "" {
+		fmt.Fprintln(os.Stderr, "--watch, --git-rev and --files-from cannot be used with lsp")
+		return 2
+	}
+
+	resolved, err := resolveConfig(&cfg)
+	if err != nil {
+		log.Printf("An error occurred: %v", err)
+		return 2
+	}
+	// A local SchemaStore clone is meant for offline use; never let a
+	// keystroke wait on a schema download.
+	if resolved.store != nil && *cfg.schemaStorePath != "" {
+		resolved.store.SetOffline(true)
+	}
+
+	c := buildCLIWithFinder(resolved, staticFileFinder{})
+	fileFinder := finder.FileSystemFinderInit(resolved.finderOpts...)
+	server := lsp.NewServer(func(path string, content []byte) (reporter.Report, bool) {
+		ft, ok, err := fileFinder.FileTypeFor(path, content)
+		if err != nil || !ok {
+			return reporter.Report{}, false
+		}
+		return c.ValidateContent(content, ft, path), true
+	}, lsp.WithVersion(configfilevalidator.GetVersion().Version))
+
+	if err := server.Serve(in, out); err != nil {
+		if !errors.Is(err, lsp.ErrExitWithoutShutdown) {
+			log.Printf("An error occurred during lsp execution: %v", err)
+		}
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/internal/testhelper"
+	"github.com/Boeing/config-file-validator/v2/pkg/tools"
+)
+
+func lspSession(t *testing.T, msgs ...any) io.Reader {
+	t.Helper()
+	var b bytes.Buffer
+	for _, msg := range msgs {
+		body, err := json.Marshal(msg)
+		require.NoError(t, err)
+		fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	return &b
+}
+
+type lspDiagnostics struct {
+	Params struct {
+		URI         string `json:"uri"`
+		Diagnostics []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"diagnostics"`
+	} `json:"params"`
+	Method string `json:"method"`
+}
+
+func lspMessages(t *testing.T, out []byte) []lspDiagnostics {
+	t.Helper()
+	r := bufio.NewReader(bytes.NewReader(out))
+	var msgs []lspDiagnostics
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if errors.Is(err, io.EOF) {
+			return msgs
+		}
+		require.NoError(t, err)
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		require.NoError(t, err)
+		body := make([]byte, length)
+		_, err = io.ReadFull(r, body)
+		require.NoError(t, err)
+		var msg lspDiagnostics
+		require.NoError(t, json.Unmarshal(body, &msg))
+		msgs = append(msgs, msg)
+	}
+}
+
+func Test_runLSP(t *testing.T) {
+	dir := t.TempDir()
+	store := filepath.Join(dir, "store")
+	require.NoError(t, os.MkdirAll(filepath.Join(store, "src", "api", "json"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(store, "src", "schemas", "json"), 0755))
+	catalog := `{"schemas": [
+		{"fileMatch": ["app.json"], "url": "https://www.schemastore.org/app.json"},
+		{"fileMatch": ["remote.json"], "url": "http://127.0.0.1:1/remote.json"}
+	]}`
+	testhelper.WriteFile(t, filepath.Join(store, "src", "api", "json"), "catalog.json", catalog)
+	testhelper.WriteFile(t, filepath.Join(store, "src", "schemas", "json"), "app.json",
+		`{"type": "object", "required": ["name"]}`)
+	testhelper.WriteFile(t, dir, ".cfv.toml", fmt.Sprintf("schemastore-path = %q\n\n[validators.json]\nforbid-duplicate-keys = true\n", store))
+	t.Chdir(dir)
+
+	open := func(name, text string) map[string]any {
+		return map[string]any{
+			"jsonrpc": "2.0",
+			"method":  "textDocument/didOpen",
+			"params": map[string]any{"textDocument": map[string]any{
+				"uri": tools.FileURL(filepath.Join(dir, name)), "languageId": "json", "version": 1, "text": text,
+			}},
+		}
+	}
+	in := lspSession(t,
+		map[string]any{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": map[string]any{}},
+		open("app.json", `{}`),
+		open("dup.json", `{"a": 1, "a": 2}`),
+		open("remote.json", `{}`),
+		open("notes.md", `# notes`),
+		map[string]any{"jsonrpc": "2.0", "id": 2, "method": "shutdown"},
+		map[string]any{"jsonrpc": "2.0", "method": "exit"},
+	)
+	var out bytes.Buffer
+	require.Equal(t, 0, runLSP(nil, in, &out))
+
+	msgs := lspMessages(t, out.Bytes())
+	require.Len(t, msgs, 5)
+	require.Equal(t, "textDocument/publishDiagnostics", msgs[1].Method)
+	require.Len(t, msgs[1].Params.Diagnostics, 1)
+	require.Equal(t, "schema/required", msgs[1].Params.Diagnostics[0].Code)
+	require.Equal(t, `duplicate key "a"`, msgs[2].Params.Diagnostics[0].Message)
+	require.Equal(t, "json/duplicate-key", msgs[2].Params.Diagnostics[0].Code)
+	// The clone has no copy of remote.json's schema, and it is not fetched.
+	require.Empty(t, msgs[3].Params.Diagnostics)
+}
+
+func Test_runLSPErrors(t *testing.T) {
+	require.Equal(t, 2, runLSP([]string{"project"}, bytes.NewReader(nil), io.Discard))
+	require.Equal(t, 2, runLSP([]string{"--watch"}, bytes.NewReader(nil), io.Discard))
+	require.Equal(t, 2, runLSP([]string{"--files-from=-"}, bytes.NewReader(nil), io.Discard))
+	require.Equal(t, 1, runLSP([]string{"--no-config"}, bytes.NewReader(nil), io.Discard))
+}
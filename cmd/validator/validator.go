@@ -6,21 +6,51 @@ Currently Apple PList XML, CSV, HCL, HOCON, INI, JSON, Properties, Sarif, TOML,
 configuration file types are supported.
 
 Usage: validator [OPTIONS] [<search_path>...]
+       validator lsp [OPTIONS]
 
 positional arguments:
     search_path: The search path on the filesystem for configuration files. Defaults to the current working directory if no search_path provided. Multiple search paths can be declared separated by a space.
 
+subcommands:
+    lsp: Run a Language Server Protocol server on stdin and stdout that publishes diagnostics for the documents an editor opens. Takes the same options, .cfv.toml and environment variables as a normal run.
+
 optional flags:
+  -0
+    	Files in -files-from are separated by NUL characters instead of newlines
+  -archive-member-mb int
+    	Largest archive member, in MiB, that -archives reads. Archives with a larger member are reported as unreadable (default 64)
+  -archives
+    	Validate the members of .zip, .jar, .tar, .tar.gz and .tgz archives in the search paths
+  -baseline string
+    	Baseline file of known failures to suppress. Stale entries are reported as warnings
+  -cache
+    	Cache validation results on disk and reuse them for files that have not changed
+  -cache-dir string
+    	Directory for the validation result cache. Implies -cache
+  -changed-since string
+    	Only validate files added, modified or renamed since the given git ref
   -depth int
     	Depth of recursion for the provided search paths. Set depth to 0 to disable recursive path traversal
+  -disable-rules string
+    	A comma separated list of rule IDs, or categories such as schema/*, whose findings are dropped
   -exclude-dirs string
     	Subdirectories to exclude when searching for configuration files
   -exclude-file-types string
     	A comma separated list of file types to ignore
+  -fail-on string
+    	Lowest finding severity that fails the run: error, warning or never (default "error")
   -file-types string
     	A comma separated list of file types to validate
+  -files-from string
+    	Validate only the files listed, one per line, in this file, or on stdin when -
+  -git-dir string
+    	Git repository for -git-rev, which may be bare. Defaults to $GIT_DIR or the current repository
+  -git-rev string
+    	Validate the files in a git revision without checking it out
   -globbing bool
     	Set globbing to true to enable pattern matching for search paths
+  -jobs int
+    	Number of files to validate concurrently. Defaults to the number of CPUs
   -reporter string
 		A string representing report format and optional output file path separated by colon if present.
 		Usage: --reporter <format>:<optional_file_path>
@@ -31,6 +61,16 @@ optional flags:
 		External SARIF file to merge into SARIF output. Repeatable and requires --reporter=sarif.
   -merge-sarif-dir string
 		Directory tree containing SARIF files to merge into SARIF output. Requires --reporter=sarif.
+  -sniff
+    	Detect the type of extensionless and .conf, .cfg, .config and .cnf files from their content
+  -staged
+    	Only validate files added, modified or renamed in the git index
+  -stdin-format string
+    	Format of stdin when the search path is -: file, tar or ndjson (default "file")
+  -type-hints
+    	Type files by a cfv: type=<type> directive, a modeline or a just shebang in their first lines
+  -update-baseline
+    	Record every current failure in the -baseline file
   -version
     	Version prints the release version of validator
   -watch
@@ -40,7 +80,13 @@ optional flags:
 package main
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -60,11 +106,14 @@ import (
 	"github.com/fsnotify/fsnotify"
 
 	configfilevalidator "github.com/Boeing/config-file-validator/v2"
+	"github.com/Boeing/config-file-validator/v2/pkg/baseline"
 	"github.com/Boeing/config-file-validator/v2/pkg/cli"
 	"github.com/Boeing/config-file-validator/v2/pkg/configfile"
 	"github.com/Boeing/config-file-validator/v2/pkg/filetype"
 	"github.com/Boeing/config-file-validator/v2/pkg/finder"
+	"github.com/Boeing/config-file-validator/v2/pkg/gitfs"
 	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+	"github.com/Boeing/config-file-validator/v2/pkg/resultcache"
 	"github.com/Boeing/config-file-validator/v2/pkg/schemastore"
 	"github.com/Boeing/config-file-validator/v2/pkg/tools"
 	"github.com/Boeing/config-file-validator/v2/pkg/validator"
@@ -96,6 +145,24 @@ type validatorConfig struct {
 	mergeSarif       sarifMergeFlags
 	mergeSarifDir    *string
 	ignoreFiles      ignoreFileFlags
+	jobs             *int
+	cache            *bool
+	cacheDir         *string
+	changedSince     *string
+	staged           *bool
+	baseline         *string
+	updateBaseline   *bool
+	failOn           *string
+	disableRules     *string
+	archives         *bool
+	archiveMemberMB  *int
+	gitRev           *string
+	gitDir           *string
+	stdinFormat      *string
+	filesFrom        *string
+	nulSeparated     *bool
+	sniff            *bool
+	typeHints        *bool
 }
 
 type reporterFlags []string
@@ -161,6 +228,7 @@ func (iff *ignoreFileFlags) Set(value string) error {
 // Custom Usage function to cover. Uses the current flagSet when available.
 func validatorUsage() {
 	fmt.Println("Usage: validator [OPTIONS] [<search_path>...]")
+	fmt.Println("       validator lsp [OPTIONS]")
 	fmt.Println()
 	fmt.Println("positional arguments:")
 	fmt.Printf(
@@ -171,8 +239,9 @@ func validatorUsage() {
 	fmt.Println("  YAML:  # yaml-language-server: $schema=schema.json")
 	fmt.Println("  TOML:  \"$schema\" = \"schema.json\"")
 	fmt.Println("  TOON:  \"$schema\": schema.json")
-	fmt.Println("  XML:   xsi:noNamespaceSchemaLocation=\"schema.xsd\"")
+	fmt.Println("  XML:   xsi:noNamespaceSchemaLocation=\"schema.xsd\" or xsi:schemaLocation=\"<namespace> <schema.xsd> ...\"")
 	fmt.Println("  XML:   <!DOCTYPE> with inline DTD (validated during syntax check)")
+	fmt.Println("  XML:   <!DOCTYPE> naming an external DTD (with dtd = true under [validators.xml])")
 	fmt.Println()
 	fmt.Println("optional flags:")
 	if flagSet != nil {
@@ -230,7 +299,7 @@ func getFlags(args []string) (validatorConfig, error) {
 		requireSchemaPtr    = flagSet.Bool("require-schema", false,
 			"Fail validation if a file supports schema validation but does not declare a schema.\n"+
 				"Supported types: JSON ($schema property), YAML (yaml-language-server comment),\n"+
-				"TOML ($schema key), TOON (\"$schema\" key), XML (xsi:noNamespaceSchemaLocation).\n"+
+				"TOML ($schema key), TOON (\"$schema\" key), XML (xsi:noNamespaceSchemaLocation or xsi:schemaLocation).\n"+
 				"Other file types (INI, CSV, ENV, HCL, HOCON, Properties, PList, EditorConfig) are not affected.\n"+
 				"Cannot be used with --no-schema.")
 		noSchemaPtr = flagSet.Bool("no-schema", false,
@@ -255,6 +324,55 @@ func getFlags(args []string) (validatorConfig, error) {
 			"Watch search paths for file changes and re-run validation.")
 		mergeSarifDirPtr = flagSet.String("merge-sarif-dir", "",
 			"Directory tree containing SARIF files to merge into SARIF output. Requires --reporter=sarif.")
+		jobsPtr = flagSet.Int("jobs", 0,
+			"Number of files to validate concurrently. Defaults to the number of CPUs.")
+		cachePtr = flagSet.Bool("cache", false,
+			"Cache validation results on disk and reuse them for files that have not changed.")
+		cacheDirPtr = flagSet.String("cache-dir", "",
+			"Directory for the validation result cache. Implies --cache.\n"+
+				"Defaults to $XDG_CACHE_HOME/cfv/results or ~/.cache/cfv/results.")
+		changedSincePtr = flagSet.String("changed-since", "",
+			"Only validate files added, modified or renamed since the given git ref\n"+
+				"(compared from its merge base with HEAD). Uncommitted and untracked files are not included.")
+		stagedPtr = flagSet.Bool("staged", false,
+			"Only validate files added, modified or renamed in the git index.\n"+
+				"Cannot be used with --changed-since.")
+		baselinePtr = flagSet.String("baseline", "",
+			"Baseline file of known failures. Failures recorded in it are suppressed and\n"+
+				"entries that no longer fail are reported as stale warnings.")
+		updateBaselinePtr = flagSet.Bool("update-baseline", false,
+			"Record every current failure in the --baseline file, replacing the entries\n"+
+				"for the files validated. Requires --baseline.")
+		failOnPtr = flagSet.String("fail-on", "error",
+			"Lowest finding severity that makes the exit status non-zero: error, warning or never.")
+		disableRulesPtr = flagSet.String("disable-rules", "",
+			"A comma separated list of rule IDs whose findings are dropped, such as json/duplicate-key.\n"+
+				"Use <category>/* to disable every rule in a category, such as schema/*.")
+		archivesPtr = flagSet.Bool("archives", false,
+			"Validate the members of .zip, .jar, .tar, .tar.gz and .tgz archives in the search paths.\n"+
+				"Members are reported as <archive>!/<member path>.")
+		archiveMemberMBPtr = flagSet.Int("archive-member-mb", 64,
+			"Largest archive member, in MiB, that --archives reads.\n"+
+				"Archives with a larger member are reported as unreadable.")
+		gitRevPtr = flagSet.String("git-rev", "",
+			"Validate the files in a git revision, read from the object database without a checkout.\n"+
+				"Search paths and reported paths are relative to the repository root.")
+		gitDirPtr = flagSet.String("git-dir", "",
+			"Git repository for --git-rev, which may be bare.\n"+
+				"Defaults to $GIT_DIR, or the repository containing the current directory.")
+		filesFromPtr = flagSet.String("files-from", "",
+			"Validate only the files listed, one per line, in this file, or on stdin when -.\n"+
+				"Listed files must be under a search path and pass every filter. Missing files are skipped.")
+		nulSeparatedPtr = flagSet.Bool("0", false, "Files in --files-from are separated by NUL characters instead of newlines.")
+		stdinFormatPtr  = flagSet.String("stdin-format", stdinFormatFile,
+			"Format of stdin when the search path is -: file, a single document of the --file-types type,\n"+
+				"tar, a tar stream of named files, or ndjson, one {\"path\": ..., \"content\": ...} record per line.")
+		sniffPtr = flagSet.Bool("sniff", false,
+			"Detect the type of extensionless files, dotfiles and .conf, .cfg, .config and .cnf files\n"+
+				"from their content when their name does not give one.")
+		typeHintsPtr = flagSet.Bool("type-hints", false,
+			"Type files by a cfv: type=<type> directive, a Vim or Emacs modeline, or a just shebang\n"+
+				"in their first lines. Hints are read from files typed by name and files --sniff would consider.")
 	)
 	flagSet.Var(
 		&reporterConfigFlags,
@@ -276,7 +394,7 @@ func getFlags(args []string) (validatorConfig, error) {
 		"Map a glob pattern to a schema file for validation.\n"+
 			"Format: <pattern>:<schema_path>\n"+
 			"Use JSON Schema (.json) for JSON, YAML, TOML, and TOON files.\n"+
-			"Use XSD (.xsd) for XML files. Paths are relative to the current directory.\n"+
+			"Use XSD (.xsd), RELAX NG (.rng) or a DTD (.dtd) for XML files. Paths are relative to the current directory.\n"+
 			"Multiple mappings can be specified.\n"+
 			"Examples:\n"+
 			"  --schema-map=\"**/package.json:schemas/package.schema.json\"\n"+
@@ -325,6 +443,24 @@ func getFlags(args []string) (validatorConfig, error) {
 		return validatorConfig{}, err
 	}
 
+	if isFlagSet("jobs") && *jobsPtr < 0 {
+		return validatorConfig{}, errors.New("wrong parameter value for jobs, value cannot be negative")
+	}
+
+	if *archiveMemberMBPtr < 1 {
+		return validatorConfig{}, errors.New("wrong parameter value for archive-member-mb, value must be at least 1")
+	}
+
+	switch *stdinFormatPtr {
+	case stdinFormatFile, stdinFormatTar, stdinFormatNDJSON:
+	default:
+		return validatorConfig{}, fmt.Errorf("unknown stdin format %q: must be file, tar or ndjson", *stdinFormatPtr)
+	}
+
+	if *changedSincePtr != "" && *stagedPtr {
+		return validatorConfig{}, errors.New("--changed-since and --staged cannot be used together")
+	}
+
 	config := validatorConfig{
 		searchPaths,
 		excludeDirsPtr,
@@ -349,6 +485,24 @@ func getFlags(args []string) (validatorConfig, error) {
 		mergeSarifConfigFlags,
 		mergeSarifDirPtr,
 		ignoreFileConfigFlags,
+		jobsPtr,
+		cachePtr,
+		cacheDirPtr,
+		changedSincePtr,
+		stagedPtr,
+		baselinePtr,
+		updateBaselinePtr,
+		failOnPtr,
+		disableRulesPtr,
+		archivesPtr,
+		archiveMemberMBPtr,
+		gitRevPtr,
+		gitDirPtr,
+		stdinFormatPtr,
+		filesFromPtr,
+		nulSeparatedPtr,
+		sniffPtr,
+		typeHintsPtr,
 	}
 
 	return config, nil
@@ -586,6 +740,23 @@ func applyDefaultFlagsFromEnv() error {
 		"schemastore-path":   "CFV_SCHEMASTORE_PATH",
 		"gitignore":          "CFV_GITIGNORE",
 		"watch":              "CFV_WATCH",
+		"jobs":               "CFV_JOBS",
+		"cache":              "CFV_CACHE",
+		"cache-dir":          "CFV_CACHE_DIR",
+		"changed-since":      "CFV_CHANGED_SINCE",
+		"staged":             "CFV_STAGED",
+		"baseline":           "CFV_BASELINE",
+		"update-baseline":    "CFV_UPDATE_BASELINE",
+		"fail-on":            "CFV_FAIL_ON",
+		"disable-rules":      "CFV_DISABLE_RULES",
+		"archives":           "CFV_ARCHIVES",
+		"archive-member-mb":  "CFV_ARCHIVE_MEMBER_MB",
+		"git-rev":            "CFV_GIT_REV",
+		"git-dir":            "CFV_GIT_DIR",
+		"stdin-format":       "CFV_STDIN_FORMAT",
+		"files-from":         "CFV_FILES_FROM",
+		"sniff":              "CFV_SNIFF",
+		"type-hints":         "CFV_TYPE_HINTS",
 	}
 
 	for flagName, envVar := range flagsEnvMap {
@@ -670,16 +841,28 @@ type resolvedConfig struct {
 	requireSchema bool
 	noSchema      bool
 	schemaMap     map[string]string
+	schemaMapFunc func(string) (string, bool)
+	configFiles   func(string) []string
 	store         *schemastore.Store
 	finderOpts    []finder.FSFinderOptions
 	searchPaths   []string
 	watch         bool
+	jobs          int
+	resultCache   *resultcache.Cache
+	baseline      *baseline.Baseline
+	updateBase    bool
+	failOn        cli.FailOn
+	disabledRules []string
 	stdinData     []byte
 	stdinFileType filetype.FileType
 	isStdin       bool
 }
 
 func mainInit() int {
+	if len(os.Args) > 1 && os.Args[1] == lspCommand {
+		return runLSP(os.Args[2:], os.Stdin, os.Stdout)
+	}
+
 	validatorConfig, err := getFlags(os.Args[1:])
 	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
@@ -730,6 +913,26 @@ func resolveConfig(cfg *validatorConfig) (*resolvedConfig, error) {
 		return nil, fmt.Errorf("loading config file: %w", err)
 	}
 
+	resultCache, err := openResultCache(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	base, updateBase, err := loadBaseline(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	failOn, err := parseFailOn(cfg.failOn)
+	if err != nil {
+		return nil, err
+	}
+
+	disabledRules, err := parseDisableRules(cfg.disableRules)
+	if err != nil {
+		return nil, err
+	}
+
 	quiet := *cfg.quiet
 	requireSchema := *cfg.requireSchema
 	noSchema := *cfg.noSchema
@@ -764,6 +967,10 @@ func resolveConfig(cfg *validatorConfig) (*resolvedConfig, error) {
 
 	groupOutput := strings.Split(*cfg.groupOutput, ",")
 	watch := cfg.watch != nil && *cfg.watch
+	var jobs int
+	if cfg.jobs != nil {
+		jobs = *cfg.jobs
+	}
 
 	resolved := &resolvedConfig{
 		reporters:     reporters,
@@ -775,6 +982,35 @@ func resolveConfig(cfg *validatorConfig) (*resolvedConfig, error) {
 		store:         store,
 		searchPaths:   cfg.searchPaths,
 		watch:         watch,
+		jobs:          jobs,
+		resultCache:   resultCache,
+		baseline:      base,
+		updateBase:    updateBase,
+		failOn:        failOn,
+		disabledRules: disabledRules,
+	}
+
+	gitMode := (cfg.changedSince != nil && *cfg.changedSince != "") || (cfg.staged != nil && *cfg.staged)
+	if gitMode && watch {
+		return nil, errors.New("--changed-since and --staged cannot be used with --watch")
+	}
+	gitRev := ""
+	if cfg.gitRev != nil {
+		gitRev = *cfg.gitRev
+	}
+	if err := validateGitRev(cfg, gitRev, gitMode, watch); err != nil {
+		return nil, err
+	}
+
+	excludeFileTypes := getExcludeFileTypes(*cfg.excludeFileTypes)
+	configuredTypes := applyValidatorOptions(validatorOpts)
+
+	filesFrom := ""
+	if cfg.filesFrom != nil {
+		filesFrom = *cfg.filesFrom
+	}
+	if err := validateFilesFrom(cfg, filesFrom, watch); err != nil {
+		return nil, err
 	}
 
 	// Handle stdin mode
@@ -782,6 +1018,28 @@ func resolveConfig(cfg *validatorConfig) (*resolvedConfig, error) {
 		if watch {
 			return nil, errors.New("--watch cannot be used with stdin")
 		}
+		if gitMode {
+			return nil, errors.New("--changed-since and --staged cannot be used with stdin")
+		}
+		// A stream of named files is searched like a directory.
+		if format := stdinFormatValue(cfg); format != stdinFormatFile {
+			if *cfg.gitignore || len(cfg.ignoreFiles) > 0 {
+				return nil, errors.New("--gitignore and --ignore-file cannot be used with --stdin-format=" + format)
+			}
+			entries, err := readStdinEntries(os.Stdin, format)
+			if err != nil {
+				return nil, err
+			}
+			fsOpts, err := buildFinderOpts(*cfg, excludeFileTypes, configuredTypes)
+			if err != nil {
+				return nil, err
+			}
+			resolved.finderOpts = append(fsOpts, finder.WithEntries(entries))
+			return resolved, nil
+		}
+		if base != nil {
+			return nil, errors.New("--baseline cannot be used with stdin")
+		}
 		ft, data, err := readStdin(*cfg.fileTypes)
 		if err != nil {
 			return nil, err
@@ -792,17 +1050,126 @@ func resolveConfig(cfg *validatorConfig) (*resolvedConfig, error) {
 		return resolved, nil
 	}
 
-	excludeFileTypes := getExcludeFileTypes(*cfg.excludeFileTypes)
-	configuredTypes := applyValidatorOptions(validatorOpts)
 	fsOpts, err := buildFinderOpts(*cfg, excludeFileTypes, configuredTypes)
 	if err != nil {
 		return nil, err
 	}
+	if gitRev != "" {
+		treeFS, err := gitfs.Open(gitDir(cfg), gitRev)
+		if err != nil {
+			return nil, err
+		}
+		fsOpts = append(fsOpts, finder.WithFS(treeFS))
+	}
+	if gitRev == "" && !*cfg.noConfig {
+		nested := newNestedConfigs(configFilePath(cfg), validatorOpts, cfg.searchPaths)
+		fsOpts = append(fsOpts, finder.WithDirConfigs(nested.dirConfig))
+		resolved.schemaMapFunc = nested.schemaFor
+		resolved.configFiles = nested.configFiles
+	}
+	if filesFrom != "" {
+		files, err := readFileList(filesFrom, cfg.nulSeparated != nil && *cfg.nulSeparated)
+		if err != nil {
+			return nil, err
+		}
+		fsOpts = append(fsOpts, finder.WithFiles(files))
+	}
 	resolved.finderOpts = fsOpts
 
 	return resolved, nil
 }
 
+// validateFilesFrom rejects options that --files-from cannot be combined
+// with.
+func validateFilesFrom(cfg *validatorConfig, filesFrom string, watch bool) error {
+	if filesFrom == "" {
+		if cfg.nulSeparated != nil && *cfg.nulSeparated {
+			return errors.New("-0 requires --files-from")
+		}
+		return nil
+	}
+	switch {
+	case watch:
+		return errors.New("--files-from cannot be used with --watch")
+	case len(cfg.searchPaths) == 1 && cfg.searchPaths[0] == "-":
+		return errors.New("--files-from cannot be used with the stdin search path; use --files-from=- to read the list from stdin")
+	case *cfg.globbing:
+		return errors.New("--files-from cannot be used with --globbing")
+	case cfg.gitRev != nil && *cfg.gitRev != "":
+		return errors.New("--files-from cannot be used with --git-rev")
+	}
+	return nil
+}
+
+// readFileList reads the paths listed in the file at name, or on stdin
+// when name is "-". Paths are separated by newlines, or by NUL characters
+// when nul is set, and blank entries are ignored.
+func readFileList(name string, nul bool) ([]string, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		//nolint:gosec // The file list is a user-provided path.
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading --files-from: %w", err)
+	}
+	return splitFileList(data, nul), nil
+}
+
+func splitFileList(data []byte, nul bool) []string {
+	sep := "\n"
+	if nul {
+		sep = "\x00"
+	}
+	files := make([]string, 0)
+	for _, file := range strings.Split(string(data), sep) {
+		if !nul {
+			file = strings.TrimSuffix(file, "\r")
+		}
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// validateGitRev rejects options that need files on disk when --git-rev
+// reads them from a git revision instead.
+func validateGitRev(cfg *validatorConfig, gitRev string, gitMode, watch bool) error {
+	if gitRev == "" {
+		if cfg.gitDir != nil && *cfg.gitDir != "" {
+			return errors.New("--git-dir requires --git-rev")
+		}
+		return nil
+	}
+	switch {
+	case watch:
+		return errors.New("--git-rev cannot be used with --watch")
+	case gitMode:
+		return errors.New("--git-rev cannot be used with --changed-since or --staged")
+	case len(cfg.searchPaths) == 1 && cfg.searchPaths[0] == "-":
+		return errors.New("--git-rev cannot be used with stdin")
+	case *cfg.globbing:
+		return errors.New("--git-rev cannot be used with --globbing")
+	case *cfg.gitignore || len(cfg.ignoreFiles) > 0:
+		return errors.New("--git-rev cannot be used with --gitignore or --ignore-file")
+	}
+	return nil
+}
+
+// gitDir returns the repository --git-rev reads from: --git-dir, then
+// $GIT_DIR as git itself would use, then "" for the repository containing
+// the current directory.
+func gitDir(cfg *validatorConfig) string {
+	if cfg.gitDir != nil && *cfg.gitDir != "" {
+		return *cfg.gitDir
+	}
+	return os.Getenv("GIT_DIR")
+}
+
 func buildCLI(rc *resolvedConfig) *cli.CLI {
 	return buildCLIWithFinder(rc, nil)
 }
@@ -815,7 +1182,15 @@ func buildCLIWithFinder(rc *resolvedConfig, fileFinder finder.FileFinder) *cli.C
 		cli.WithRequireSchema(rc.requireSchema),
 		cli.WithNoSchema(rc.noSchema),
 		cli.WithSchemaMap(rc.schemaMap),
+		cli.WithSchemaMapFunc(rc.schemaMapFunc),
+		cli.WithConfigFilesFunc(rc.configFiles),
 		cli.WithSchemaStore(rc.store),
+		cli.WithJobs(rc.jobs),
+		cli.WithResultCache(rc.resultCache),
+		cli.WithBaseline(rc.baseline),
+		cli.WithUpdateBaseline(rc.updateBase),
+		cli.WithFailOn(rc.failOn),
+		cli.WithDisabledRules(rc.disabledRules),
 	}
 
 	if rc.isStdin {
@@ -859,6 +1234,122 @@ func openSchemaStore(cfg *validatorConfig) (*schemastore.Store, error) {
 	return nil, nil
 }
 
+// openResultCache opens the on-disk result cache when --cache or --cache-dir
+// is set. Entries are salted with the tool version and the content of the
+// top-level .cfv.toml in effect, so upgrading the validator or editing the
+// config file invalidates every cached result. Nested .cfv.toml files are
+// part of the key of the files they apply to.
+func openResultCache(cfg *validatorConfig) (*resultcache.Cache, error) {
+	var dir string
+	if cfg.cacheDir != nil {
+		dir = *cfg.cacheDir
+	}
+	if (cfg.cache == nil || !*cfg.cache) && dir == "" {
+		return nil, nil
+	}
+	if dir == "" {
+		var err error
+		dir, err = resultcache.DefaultDir()
+		if err != nil {
+			return nil, fmt.Errorf("locating result cache directory: %w", err)
+		}
+	}
+
+	salt := toolFingerprint()
+	if cfgPath := configFilePath(cfg); cfgPath != "" {
+		data, err := os.ReadFile(cfgPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+		salt += "\x00" + string(data)
+	}
+
+	return resultcache.Open(dir, salt)
+}
+
+// loadBaseline loads the --baseline file, if one is set, and reports whether
+// it should be rewritten with the failures of this run.
+func loadBaseline(cfg *validatorConfig) (*baseline.Baseline, bool, error) {
+	var path string
+	if cfg.baseline != nil {
+		path = *cfg.baseline
+	}
+	update := cfg.updateBaseline != nil && *cfg.updateBaseline
+	if path == "" {
+		if update {
+			return nil, false, errors.New("--update-baseline requires --baseline")
+		}
+		return nil, false, nil
+	}
+	b, err := baseline.Load(path)
+	if err != nil {
+		return nil, false, err
+	}
+	return b, update, nil
+}
+
+// parseFailOn returns the --fail-on threshold. A nil or empty value is the
+// default, error.
+func parseFailOn(value *string) (cli.FailOn, error) {
+	if value == nil || *value == "" {
+		return cli.FailOnError, nil
+	}
+	switch failOn := cli.FailOn(*value); failOn {
+	case cli.FailOnError, cli.FailOnWarning, cli.FailOnNever:
+		return failOn, nil
+	default:
+		return "", errors.New("wrong parameter value for fail-on, only supports error, warning, or never")
+	}
+}
+
+// parseDisableRules returns the rule ID patterns listed in --disable-rules.
+// Every pattern must name a rule in the catalogue, or a category with at
+// least one rule.
+func parseDisableRules(value *string) ([]string, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	var patterns []string
+	for _, p := range strings.Split(*value, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		known := slices.ContainsFunc(validator.Rules, func(r validator.Rule) bool {
+			return validator.MatchRule(p, r.ID)
+		})
+		if !known {
+			return nil, fmt.Errorf("wrong parameter value for disable-rules, unknown rule %q", p)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// toolFingerprint identifies the running build. Release builds are
+// identified by their version; development builds, which all report
+// "unknown", by a hash of the executable.
+func toolFingerprint() string {
+	version := configfilevalidator.GetVersion().Version
+	if version != "unknown" {
+		return version
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return version
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return version
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return version
+	}
+	return fmt.Sprintf("%s-%x", version, h.Sum(nil))
+}
+
 func readStdin(fileTypesFlag string) (filetype.FileType, []byte, error) {
 	if fileTypesFlag == "" {
 		return filetype.FileType{}, nil, errors.New("reading from stdin requires --file-types to specify exactly one file type")
@@ -878,6 +1369,79 @@ func readStdin(fileTypesFlag string) (filetype.FileType, []byte, error) {
 	}
 	return filetype.FileType{}, nil, fmt.Errorf("unknown file type %q", fileTypeName)
 }
+
+// Formats of stdin accepted by --stdin-format.
+const (
+	stdinFormatFile   = "file"
+	stdinFormatTar    = "tar"
+	stdinFormatNDJSON = "ndjson"
+)
+
+func stdinFormatValue(cfg *validatorConfig) string {
+	if cfg.stdinFormat == nil {
+		return stdinFormatFile
+	}
+	return *cfg.stdinFormat
+}
+
+// stdinRecord is a line of --stdin-format=ndjson input.
+type stdinRecord struct {
+	Path    *string `json:"path"`
+	Content *string `json:"content"`
+}
+
+// readStdinEntries reads the named files of a multi-file stdin stream. A
+// tar stream may be gzip-compressed.
+func readStdinEntries(r io.Reader, format string) ([]finder.Entry, error) {
+	var entries []finder.Entry
+	if format == stdinFormatNDJSON {
+		dec := json.NewDecoder(r)
+		for record := 1; ; record++ {
+			var rec stdinRecord
+			err := dec.Decode(&rec)
+			if errors.Is(err, io.EOF) {
+				return entries, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading stdin record %d: %w", record, err)
+			}
+			if rec.Path == nil || *rec.Path == "" || rec.Content == nil {
+				return nil, fmt.Errorf("reading stdin record %d: path and content are required", record)
+			}
+			entries = append(entries, finder.Entry{Path: *rec.Path, Content: []byte(*rec.Content)})
+		}
+	}
+
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("reading stdin: %w", err)
+		}
+		r = gz
+	} else {
+		r = br
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading stdin: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("reading stdin: %w", err)
+		}
+		entries = append(entries, finder.Entry{Path: hdr.Name, Content: content})
+	}
+}
+
 func buildFinderOpts(cfg validatorConfig, excludeFileTypes []string, fileTypes []filetype.FileType) ([]finder.FSFinderOptions, error) {
 	excludeDirs := strings.Split(*cfg.excludeDirs, ",")
 	fsOpts := []finder.FSFinderOptions{
@@ -930,6 +1494,24 @@ func buildFinderOpts(cfg validatorConfig, excludeFileTypes []string, fileTypes [
 	if len(cfg.ignoreFiles) > 0 {
 		fsOpts = append(fsOpts, finder.WithIgnoreFiles([]string(cfg.ignoreFiles)))
 	}
+	if cfg.changedSince != nil && *cfg.changedSince != "" {
+		fsOpts = append(fsOpts, finder.WithChangedSince(*cfg.changedSince))
+	}
+	if cfg.staged != nil && *cfg.staged {
+		fsOpts = append(fsOpts, finder.WithStaged(true))
+	}
+	if cfg.archives != nil && *cfg.archives {
+		fsOpts = append(fsOpts, finder.WithArchives(true))
+	}
+	if cfg.archiveMemberMB != nil {
+		fsOpts = append(fsOpts, finder.WithMaxArchiveMemberSize(int64(*cfg.archiveMemberMB)<<20))
+	}
+	if cfg.sniff != nil && *cfg.sniff {
+		fsOpts = append(fsOpts, finder.WithSniff(true))
+	}
+	if cfg.typeHints != nil && *cfg.typeHints {
+		fsOpts = append(fsOpts, finder.WithTypeHints(true))
+	}
 
 	return fsOpts, nil
 }
@@ -1015,17 +1597,20 @@ func parseSchemaMapFlags(flags schemaMapFlags) (map[string]string, error) {
 	return result, nil
 }
 
-func applyConfigFile(cfg *validatorConfig) (*configfile.ValidatorOptions, error) {
+// configFilePath returns the .cfv.toml in effect: the --config path, or the
+// discovered file unless --no-config is set. It returns "" if there is none.
+func configFilePath(cfg *validatorConfig) string {
 	if *cfg.noConfig {
-		return nil, nil
+		return ""
 	}
-
-	var cfgPath string
 	if *cfg.configPath != "" {
-		cfgPath = *cfg.configPath
-	} else {
-		cfgPath = configfile.Discover(".")
+		return *cfg.configPath
 	}
+	return configfile.Discover(".")
+}
+
+func applyConfigFile(cfg *validatorConfig) (*configfile.ValidatorOptions, error) {
+	cfgPath := configFilePath(cfg)
 	if cfgPath == "" {
 		return nil, nil
 	}
@@ -1087,6 +1672,37 @@ func applyConfigFile(cfg *validatorConfig) (*configfile.ValidatorOptions, error)
 	if !isFlagSet("gitignore") && fileCfg.Gitignore != nil {
 		cfg.gitignore = fileCfg.Gitignore
 	}
+	if !isFlagSet("archives") && fileCfg.Archives != nil {
+		cfg.archives = fileCfg.Archives
+	}
+	if !isFlagSet("archive-member-mb") && fileCfg.ArchiveMemberMB != nil {
+		cfg.archiveMemberMB = fileCfg.ArchiveMemberMB
+	}
+	if !isFlagSet("sniff") && fileCfg.Sniff != nil {
+		cfg.sniff = fileCfg.Sniff
+	}
+	if !isFlagSet("type-hints") && fileCfg.TypeHints != nil {
+		cfg.typeHints = fileCfg.TypeHints
+	}
+	if !isFlagSet("jobs") && fileCfg.Jobs != nil {
+		cfg.jobs = fileCfg.Jobs
+	}
+	if !isFlagSet("cache") && fileCfg.Cache != nil {
+		cfg.cache = fileCfg.Cache
+	}
+	if !isFlagSet("cache-dir") && fileCfg.CacheDir != nil {
+		cfg.cacheDir = fileCfg.CacheDir
+	}
+	if !isFlagSet("fail-on") && fileCfg.FailOn != nil {
+		cfg.failOn = fileCfg.FailOn
+	}
+	if !isFlagSet("disable-rules") && len(fileCfg.DisableRules) > 0 {
+		v := strings.Join(fileCfg.DisableRules, ",")
+		cfg.disableRules = &v
+	}
+	if !isFlagSet("baseline") && fileCfg.Baseline != nil {
+		cfg.baseline = fileCfg.Baseline
+	}
 	if !isFlagSet("ignore-file") && len(fileCfg.IgnoreFiles) > 0 {
 		cfg.ignoreFiles = ignoreFileFlags(fileCfg.IgnoreFiles)
 	}
@@ -1104,6 +1720,100 @@ func applyConfigFile(cfg *validatorConfig) (*configfile.ValidatorOptions, error)
 	return &fileCfg.Validators, nil
 }
 
+// nestedConfigs applies the .cfv.toml files below the top-level one to
+// their directory trees.
+type nestedConfigs struct {
+	tree       *configfile.Tree
+	validators configfile.ValidatorOptions
+}
+
+// newNestedConfigs looks for nested files below each search root up to
+// the directory of the first configuration file at or above the root,
+// which is top-level for it even when --config replaces it. top is the
+// top-level file in effect and validators are its validator options.
+func newNestedConfigs(top string, validators *configfile.ValidatorOptions, roots []string) *nestedConfigs {
+	n := &nestedConfigs{tree: configfile.NewTree(top, roots...)}
+	if validators != nil {
+		n.validators = *validators
+	}
+	return n
+}
+
+// dirConfig returns the finder configuration the nested files put in
+// effect in dir.
+func (n *nestedConfigs) dirConfig(dir string) (*finder.DirConfig, error) {
+	scope, err := n.tree.ScopeFor(dir)
+	if err != nil || scope == nil {
+		return nil, err
+	}
+	eff := scope.Effective()
+	dirConfig := &finder.DirConfig{
+		ExcludeDirs:      eff.ExcludeDirs,
+		ExcludeFileTypes: getExcludeFileTypes(strings.Join(eff.ExcludeFileTypes, ",")),
+	}
+	for _, m := range eff.TypeMap {
+		typeName := strings.ToLower(m.Value)
+		i := slices.IndexFunc(filetype.FileTypes, func(ft filetype.FileType) bool { return ft.Name == typeName })
+		if i < 0 {
+			return nil, fmt.Errorf("config file %s: unknown file type %q in type-map", filepath.Join(m.Dir, configfile.FileName), typeName)
+		}
+		dirConfig.TypeOverrides = append(dirConfig.TypeOverrides, finder.TypeOverride{Pattern: m.Pattern, FileType: filetype.FileTypes[i], Dir: m.Dir})
+	}
+	if eff.Validators != (configfile.ValidatorOptions{}) {
+		validators := configfile.MergeValidatorOptions(n.validators, eff.Validators)
+		dirConfig.FileTypes = applyValidatorOptions(&validators)
+	}
+	return dirConfig, nil
+}
+
+// schemaFor maps a file to a schema with the schema-map entries of the
+// nested files that apply to it. As at top level, glob patterns match the
+// path, here relative to the nested file, and other patterns the file
+// name.
+func (n *nestedConfigs) schemaFor(filePath string) (string, bool) {
+	// Archive members are configured by the directory of the archive.
+	archivePath, _, _ := strings.Cut(filePath, finder.ArchiveSeparator)
+	scope, err := n.tree.ScopeFor(filepath.Dir(archivePath))
+	if err != nil || scope == nil {
+		return "", false
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", false
+	}
+	for _, m := range scope.Effective().SchemaMap {
+		if !tools.IsGlobPattern(m.Pattern) {
+			if m.Pattern == filepath.Base(filePath) {
+				return m.Value, true
+			}
+			continue
+		}
+		rel, err := filepath.Rel(m.Dir, absPath)
+		if err != nil {
+			continue
+		}
+		if matched, err := doublestar.PathMatch(m.Pattern, filepath.ToSlash(rel)); err == nil && matched {
+			return m.Value, true
+		}
+	}
+	return "", false
+}
+
+// configFiles returns the nested files that apply to a file, closest
+// first.
+func (n *nestedConfigs) configFiles(filePath string) []string {
+	archivePath, _, _ := strings.Cut(filePath, finder.ArchiveSeparator)
+	scope, err := n.tree.ScopeFor(filepath.Dir(archivePath))
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for ; scope != nil; scope = scope.Parent {
+		files = append(files, filepath.Join(scope.Dir, configfile.FileName))
+	}
+	return files
+}
+
 func applyValidatorOptions(opts *configfile.ValidatorOptions) []filetype.FileType {
 	types := make([]filetype.FileType, len(filetype.FileTypes))
 	copy(types, filetype.FileTypes)
@@ -1126,6 +1836,14 @@ func applyValidatorOptions(opts *configfile.ValidatorOptions) []filetype.FileTyp
 			if opts.INI != nil {
 				types[i].Validator = applyINIOptions(opts.INI)
 			}
+		case "properties":
+			if opts.Properties != nil && opts.Properties.NestedKeys != nil {
+				types[i].Validator = validator.PropValidator{NestedKeys: *opts.Properties.NestedKeys}
+			}
+		case "xml":
+			if opts.XML != nil {
+				types[i].Validator = applyXMLOptions(opts.XML)
+			}
 		default:
 		}
 	}
@@ -1150,6 +1868,23 @@ func applyCSVOptions(opts *configfile.CSVOptions) validator.CsvValidator {
 	return v
 }
 
+func applyXMLOptions(opts *configfile.XMLOptions) validator.XMLValidator {
+	v := validator.XMLValidator{}
+	if opts.Catalog != nil {
+		v.Catalog = *opts.Catalog
+	}
+	if opts.DTD != nil {
+		v.DTD = *opts.DTD
+	}
+	if opts.ExternalEntities != nil {
+		v.ExternalEntities = *opts.ExternalEntities
+	}
+	if opts.RemoteSchemas != nil {
+		v.RemoteSchemas = *opts.RemoteSchemas
+	}
+	return v
+}
+
 func applyJSONOptions(opts *configfile.JSONOptions) validator.JSONValidator {
 	v := validator.JSONValidator{}
 	if opts.ForbidDuplicateKeys != nil {
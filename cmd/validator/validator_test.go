@@ -1,12 +1,18 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"flag"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/finder"
 )
 
 func Test_getFlags(t *testing.T) {
@@ -37,9 +43,19 @@ func Test_getFlags(t *testing.T) {
 		{"sarif merge dir", []string{"--reporter=sarif", "--merge-sarif-dir=reports", "."}, false},
 		{"ignore-file", []string{"--ignore-file=.dockerignore", "."}, false},
 		{"multiple ignore-files", []string{"--ignore-file=.dockerignore", "--ignore-file=.prettierignore", "."}, false},
+		{"jobs", []string{"--jobs=4", "."}, false},
+		{"cache", []string{"--cache", "."}, false},
+		{"cache dir", []string{"--cache-dir=/tmp/cfv-cache", "."}, false},
+		{"baseline", []string{"--baseline=baseline.json", "."}, false},
+		{"update baseline", []string{"--baseline=baseline.json", "--update-baseline", "."}, false},
+		{"changed since", []string{"--changed-since=origin/main", "."}, false},
+		{"staged", []string{"--staged", "."}, false},
+		{"disable rules", []string{"--disable-rules=json/duplicate-key,schema/*", "."}, false},
 
 		// Invalid flag combinations
 		{"negative depth", []string{"-depth=-1", "."}, true},
+		{"negative jobs", []string{"--jobs=-1", "."}, true},
+		{"changed since with staged", []string{"--changed-since=HEAD", "--staged", "."}, true},
 		{"wrong reporter", []string{"--reporter=wrong", "."}, true},
 		{"merge sarif requires sarif reporter", []string{"--reporter=json", "--merge-sarif=external.sarif", "."}, true},
 		{"empty merge sarif file", []string{"--reporter=sarif", "--merge-sarif=", "."}, true},
@@ -73,6 +89,22 @@ func Test_getFlags(t *testing.T) {
 	}
 }
 
+func Test_parseDisableRules(t *testing.T) {
+	value := " json/duplicate-key, schema/* ,"
+	patterns, err := parseDisableRules(&value)
+	require.NoError(t, err)
+	require.Equal(t, []string{"json/duplicate-key", "schema/*"}, patterns)
+
+	patterns, err = parseDisableRules(nil)
+	require.NoError(t, err)
+	require.Nil(t, patterns)
+
+	for _, bad := range []string{"json/nope", "nope/*", "schema"} {
+		_, err := parseDisableRules(&bad)
+		require.ErrorContains(t, err, "wrong parameter value for disable-rules", bad)
+	}
+}
+
 func Test_resolveConfigAllowsNilMergeSarifDir(t *testing.T) {
 	flagSet = flag.NewFlagSet("validator", flag.ContinueOnError)
 
@@ -249,3 +281,81 @@ func Test_emptyBoolEnvVarNoParseError(t *testing.T) {
 		})
 	}
 }
+
+func Test_archivesFinderOption(t *testing.T) {
+	dir := t.TempDir()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	w, err := zw.Create("application.properties")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("a=1\n"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.jar"), b.Bytes(), 0600))
+	configPath := filepath.Join(t.TempDir(), ".cfv.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte("archives = true\n"), 0600))
+
+	for _, args := range [][]string{{"--no-config", "--archives", dir}, {"--config=" + configPath, dir}} {
+		cfg, err := getFlags(args)
+		require.NoError(t, err)
+		resolved, err := resolveConfig(&cfg)
+		require.NoError(t, err)
+		files, err := finder.FileSystemFinderInit(resolved.finderOpts...).Find()
+		require.NoError(t, err)
+		require.Len(t, files, 1, args)
+		require.Equal(t, filepath.Join(dir, "app.jar")+finder.ArchiveSeparator+"application.properties", files[0].Path)
+	}
+}
+
+func Test_archiveMemberMBFinderOption(t *testing.T) {
+	_, err := getFlags([]string{"--archive-member-mb=0", "."})
+	require.ErrorContains(t, err, "archive-member-mb, value must be at least 1")
+
+	configPath := filepath.Join(t.TempDir(), ".cfv.toml")
+	require.NoError(t, os.WriteFile(configPath, []byte("archive-member-mb = 2\n"), 0600))
+	for args, want := range map[string]int64{"--no-config": 64 << 20, "--config=" + configPath: 2 << 20} {
+		cfg, err := getFlags([]string{args, "."})
+		require.NoError(t, err)
+		resolved, err := resolveConfig(&cfg)
+		require.NoError(t, err)
+		require.Equal(t, want, finder.FileSystemFinderInit(resolved.finderOpts...).MaxArchiveMemberSize, args)
+	}
+}
+
+func Test_readStdinEntries(t *testing.T) {
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "config/", Typeflag: tar.TypeDir, Mode: 0755}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "config/app.json", Typeflag: tar.TypeReg, Mode: 0644, Size: 2}))
+	_, err := tw.Write([]byte("{}"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	entries, err := readStdinEntries(bytes.NewReader(b.Bytes()), stdinFormatTar)
+	require.NoError(t, err)
+	require.Equal(t, []finder.Entry{{Path: "config/app.json", Content: []byte("{}")}}, entries)
+
+	_, err = readStdinEntries(bytes.NewReader([]byte("not a tar stream")), stdinFormatTar)
+	require.ErrorContains(t, err, "reading stdin")
+
+	entries, err = readStdinEntries(bytes.NewReader([]byte(`{"path": "a.yaml", "content": "a: 1"}`+"\n\n"+`{"path": "b.ini", "content": ""}`)), stdinFormatNDJSON)
+	require.NoError(t, err)
+	require.Equal(t, []finder.Entry{{Path: "a.yaml", Content: []byte("a: 1")}, {Path: "b.ini", Content: []byte("")}}, entries)
+
+	_, err = readStdinEntries(bytes.NewReader([]byte(`{"path": "a.yaml", "content": 1}`)), stdinFormatNDJSON)
+	require.ErrorContains(t, err, "reading stdin record 1")
+
+	for _, format := range []string{stdinFormatTar, stdinFormatNDJSON} {
+		entries, err = readStdinEntries(bytes.NewReader(nil), format)
+		require.NoError(t, err, format)
+		require.Empty(t, entries, format)
+	}
+}
+
+func Test_splitFileList(t *testing.T) {
+	require.Equal(t, []string{"a.json", "dir/b c.yaml"}, splitFileList([]byte("a.json\r\n\ndir/b c.yaml\n"), false))
+	require.Equal(t, []string{"a.json", "new\nline.yaml"}, splitFileList([]byte("a.json\x00new\nline.yaml\x00"), true))
+	require.Empty(t, splitFileList(nil, false))
+}
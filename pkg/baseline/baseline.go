@@ -0,0 +1,210 @@
+// Package baseline records the validation failures that exist when a check
+// is first adopted, so that later runs report only new failures.
+//
+// A baseline is a JSON file listing, per file, a fingerprint of each known
+// error. Fingerprints ignore line and column numbers, including those in
+// parser messages, so that unrelated edits which shift an error do not make
+// it look new. Paths are stored relative to the baseline file's directory,
+// so a baseline committed at the root of a repository works from any
+// checkout location.
+package baseline
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+)
+
+const formatVersion = 1
+
+// Entry is one known failure. Rule is the failure's rule ID, recorded for
+// readers of the file; matching uses only Path and Fingerprint.
+type Entry struct {
+	Path        string `json:"path"`
+	Fingerprint string `json:"fingerprint"`
+	Rule        string `json:"rule,omitempty"`
+	Message     string `json:"message"`
+}
+
+// Baseline is a set of known failures loaded from, or destined for, a
+// baseline file.
+type Baseline struct {
+	path    string
+	dir     string
+	entries []Entry
+}
+
+type fileFormat struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Load reads the baseline file at path. A missing file is an empty
+// baseline, so a workflow can reference the file before it is first written.
+func Load(path string) (*Baseline, error) {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("resolving baseline path: %w", err)
+	}
+	b := &Baseline{path: path, dir: dir}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline: %w", err)
+	}
+
+	var f fileFormat
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("baseline %s: %w", path, err)
+	}
+	if f.Version != formatVersion {
+		return nil, fmt.Errorf("baseline %s: unsupported version %d", path, f.Version)
+	}
+	b.entries = f.Entries
+	return b, nil
+}
+
+// Entries returns the known failures.
+func (b *Baseline) Entries() []Entry {
+	return b.entries
+}
+
+// Update replaces the entries for every file in reports with that file's
+// current failures. Entries for files outside reports are kept, so a run
+// over part of a repository does not drop the rest of the baseline, unless
+// the file no longer exists.
+func (b *Baseline) Update(reports []reporter.Report) {
+	validated := make(map[string]struct{}, len(reports))
+	var entries []Entry
+	for _, r := range reports {
+		rel := b.relPath(r.FilePath)
+		validated[rel] = struct{}{}
+		for i, msg := range r.ValidationErrors {
+			entries = append(entries, Entry{Path: rel, Fingerprint: Fingerprint(msg), Rule: r.Rule(i), Message: msg})
+		}
+	}
+	for _, e := range b.entries {
+		if _, ok := validated[e.Path]; ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(b.dir, filepath.FromSlash(e.Path))); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		return cmp.Or(cmp.Compare(a.Path, b.Path), cmp.Compare(a.Fingerprint, b.Fingerprint))
+	})
+	b.entries = entries
+}
+
+// Save writes the baseline back to the file it was loaded from.
+func (b *Baseline) Save() error {
+	entries := b.entries
+	if entries == nil {
+		entries = []Entry{}
+	}
+	data, err := json.MarshalIndent(fileFormat{Version: formatVersion, Entries: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding baseline: %w", err)
+	}
+	if err := os.WriteFile(b.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing baseline: %w", err)
+	}
+	return nil
+}
+
+// Apply removes the errors recorded in the baseline from reports and
+// returns the updated reports. A file whose errors are all in the baseline
+// passes, with a note recording how many errors were suppressed. Baseline
+// entries for a validated file that no longer match any of its errors are
+// reported as warnings on that file, so the baseline can be pruned.
+func (b *Baseline) Apply(reports []reporter.Report) []reporter.Report {
+	known := make(map[string]map[string][]Entry)
+	for _, e := range b.entries {
+		if known[e.Path] == nil {
+			known[e.Path] = make(map[string][]Entry)
+		}
+		known[e.Path][e.Fingerprint] = append(known[e.Path][e.Fingerprint], e)
+	}
+
+	out := make([]reporter.Report, len(reports))
+	for i, r := range reports {
+		remaining := known[b.relPath(r.FilePath)]
+		out[i] = suppress(r, remaining)
+		for _, fp := range slices.Sorted(maps.Keys(remaining)) {
+			for _, e := range remaining[fp] {
+				out[i].Warnings = append(out[i].Warnings,
+					fmt.Sprintf("stale baseline entry, the error no longer occurs: %s", e.Message))
+			}
+		}
+	}
+	return out
+}
+
+// suppress removes the errors of r found in remaining, consuming one
+// baseline entry per suppressed error.
+func suppress(r reporter.Report, remaining map[string][]Entry) reporter.Report {
+	if len(r.ValidationErrors) == 0 || len(remaining) == 0 {
+		return r
+	}
+	r, suppressed := r.DropErrors(func(_ int, msg string) bool {
+		fp := Fingerprint(msg)
+		if len(remaining[fp]) == 0 {
+			return false
+		}
+		remaining[fp] = remaining[fp][1:]
+		if len(remaining[fp]) == 0 {
+			delete(remaining, fp)
+		}
+		return true
+	})
+	if suppressed > 0 {
+		r.Notes = append(slices.Clone(r.Notes), fmt.Sprintf("%d error(s) suppressed by baseline", suppressed))
+	}
+	return r
+}
+
+// positionPattern matches the "line N, column M: " part of a formatted
+// error such as "schema: line 3, column 5: port: Invalid type".
+var positionPattern = regexp.MustCompile(`^(\w+: )line \d+(?:, column \d+)?: `)
+
+// embeddedPositionPattern matches the numbers of positions parsers put in
+// their messages, such as "yaml: line 5: ..." or "... at line 2, column 3".
+var embeddedPositionPattern = regexp.MustCompile(`(?i)\b(line|column|col) \d+`)
+
+// Fingerprint returns a stable identifier for a formatted validation error.
+// Source positions, both the leading one and those embedded in the
+// message, are ignored so that an error keeps its fingerprint when
+// unrelated edits move it to a different line.
+func Fingerprint(msg string) string {
+	normalized := positionPattern.ReplaceAllString(msg, "$1")
+	normalized = embeddedPositionPattern.ReplaceAllString(normalized, "$1 N")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (b *Baseline) relPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(b.dir, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
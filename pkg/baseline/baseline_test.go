@@ -0,0 +1,205 @@
+package baseline
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+)
+
+func failed(path string, lines []int, msgs ...string) reporter.Report {
+	cols := make([]int, len(lines))
+	for i := range cols {
+		cols[i] = 1
+	}
+	return reporter.Report{
+		FileName:         filepath.Base(path),
+		FilePath:         path,
+		IsValid:          false,
+		ValidationError:  errors.New(msgs[0]),
+		ValidationErrors: msgs,
+		ErrorType:        "schema",
+		StartLine:        lines[0],
+		StartColumn:      1,
+		ErrorLines:       lines,
+		ErrorColumns:     cols,
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	t.Parallel()
+	b, err := Load(filepath.Join(t.TempDir(), "baseline.json"))
+	require.NoError(t, err)
+	require.Empty(t, b.Entries())
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	require.NoError(t, os.WriteFile(corrupt, []byte("{"), 0600))
+	_, err := Load(corrupt)
+	require.ErrorContains(t, err, "corrupt.json")
+
+	future := filepath.Join(dir, "future.json")
+	require.NoError(t, os.WriteFile(future, []byte(`{"version": 99, "entries": []}`), 0600))
+	_, err = Load(future)
+	require.ErrorContains(t, err, "unsupported version 99")
+}
+
+func TestUpdateSaveRoundTrip(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "baseline.json")
+
+	b, err := Load(path)
+	require.NoError(t, err)
+	typeErr := failed(filepath.Join(dir, "sub", "b.json"), []int{2}, "schema: line 2, column 1: port: Invalid type")
+	typeErr.ErrorRules = []string{"schema/type"}
+	b.Update([]reporter.Report{
+		typeErr,
+		failed(filepath.Join(dir, "a.json"), []int{1}, "syntax: line 1, column 9: invalid character"),
+		{FilePath: filepath.Join(dir, "ok.json"), IsValid: true},
+	})
+	require.NoError(t, b.Save())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	entries := loaded.Entries()
+	require.Len(t, entries, 2)
+	require.Equal(t, "a.json", entries[0].Path)
+	require.Equal(t, "sub/b.json", entries[1].Path)
+	require.Equal(t, "schema: line 2, column 1: port: Invalid type", entries[1].Message)
+	require.Equal(t, Fingerprint(entries[1].Message), entries[1].Fingerprint)
+	require.Equal(t, "schema/type", entries[1].Rule)
+	require.Empty(t, entries[0].Rule)
+}
+
+func TestUpdateKeepsEntriesForFilesNotValidated(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.json")
+	require.NoError(t, os.WriteFile(kept, []byte("{"), 0600))
+	deleted := filepath.Join(dir, "deleted.json")
+	updated := filepath.Join(dir, "updated.json")
+
+	b, err := Load(filepath.Join(dir, "baseline.json"))
+	require.NoError(t, err)
+	b.Update([]reporter.Report{
+		failed(kept, []int{1}, "syntax: line 1, column 2: unexpected end of JSON input"),
+		failed(deleted, []int{1}, "syntax: line 1, column 2: unexpected end of JSON input"),
+		failed(updated, []int{1}, "schema: line 1, column 1: old"),
+	})
+
+	b.Update([]reporter.Report{failed(updated, []int{1}, "schema: line 1, column 1: new")})
+
+	var got []string
+	for _, e := range b.Entries() {
+		got = append(got, e.Path+" "+e.Message)
+	}
+	require.Equal(t, []string{
+		"kept.json syntax: line 1, column 2: unexpected end of JSON input",
+		"updated.json schema: line 1, column 1: new",
+	}, got)
+}
+
+func TestFingerprintIgnoresPosition(t *testing.T) {
+	t.Parallel()
+	require.Equal(t,
+		Fingerprint("schema: line 3, column 5: port: Invalid type"),
+		Fingerprint("schema: line 40, column 1: port: Invalid type"))
+	require.Equal(t,
+		Fingerprint("syntax: line 3: bad"),
+		Fingerprint("syntax: line 9: bad"))
+	require.NotEqual(t,
+		Fingerprint("schema: line 3, column 5: port: Invalid type"),
+		Fingerprint("syntax: line 3, column 5: port: Invalid type"))
+	require.NotEqual(t,
+		Fingerprint("schema: port: Invalid type"),
+		Fingerprint("schema: host: Invalid type"))
+
+	// Positions embedded in parser messages are ignored too.
+	require.Equal(t,
+		Fingerprint("syntax: line 5, column 3: yaml: line 5: did not find expected key"),
+		Fingerprint("syntax: line 7, column 3: yaml: line 7: did not find expected key"))
+	require.Equal(t,
+		Fingerprint("syntax: toml: line 4 (last key \"a\"): expected value"),
+		Fingerprint("syntax: toml: line 12 (last key \"a\"): expected value"))
+	require.Equal(t,
+		Fingerprint("syntax: element b: not declared at line 2, column 3"),
+		Fingerprint("syntax: element b: not declared at line 20, column 30"))
+	require.Equal(t,
+		Fingerprint("syntax: XML syntax error on line 3: unexpected EOF"),
+		Fingerprint("syntax: XML syntax error on line 8: unexpected EOF"))
+	require.NotEqual(t,
+		Fingerprint("syntax: expected 2 columns"),
+		Fingerprint("syntax: expected 3 columns"))
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "baseline.json")
+	known := filepath.Join(dir, "known.json")
+	partial := filepath.Join(dir, "partial.json")
+	fixed := filepath.Join(dir, "fixed.json")
+	other := filepath.Join(dir, "other.json")
+
+	b, err := Load(path)
+	require.NoError(t, err)
+	b.Update([]reporter.Report{
+		failed(known, []int{1, 2}, "schema: line 1, column 1: a: required", "schema: line 2, column 1: b: required"),
+		failed(partial, []int{1}, "schema: line 1, column 1: a: required"),
+		failed(fixed, []int{4}, "syntax: line 4, column 1: bad"),
+	})
+
+	reports := b.Apply([]reporter.Report{
+		// Same errors, moved down by an edit.
+		failed(known, []int{5, 6}, "schema: line 5, column 1: a: required", "schema: line 6, column 1: b: required"),
+		// One known error and one new one.
+		failed(partial, []int{1, 3}, "schema: line 1, column 1: a: required", "schema: line 3, column 1: c: required"),
+		{FilePath: fixed, IsValid: true},
+		failed(other, []int{1}, "schema: line 1, column 1: a: required"),
+	})
+
+	require.True(t, reports[0].IsValid)
+	require.NoError(t, reports[0].ValidationError)
+	require.Empty(t, reports[0].ValidationErrors)
+	require.Empty(t, reports[0].ErrorType)
+	require.Equal(t, []string{"2 error(s) suppressed by baseline"}, reports[0].Notes)
+
+	require.False(t, reports[1].IsValid)
+	require.Equal(t, []string{"schema: line 3, column 1: c: required"}, reports[1].ValidationErrors)
+	require.Equal(t, []int{3}, reports[1].ErrorLines)
+	require.Equal(t, 3, reports[1].StartLine)
+	require.Equal(t, []string{"1 error(s) suppressed by baseline"}, reports[1].Notes)
+
+	require.True(t, reports[2].IsValid)
+	require.Equal(t, []string{
+		"stale baseline entry, the error no longer occurs: syntax: line 4, column 1: bad",
+	}, reports[2].Warnings)
+
+	require.False(t, reports[3].IsValid, "errors in files outside the baseline are reported")
+	require.Empty(t, reports[3].Notes)
+}
+
+func TestApplyCountsDuplicateErrors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "dup.json")
+
+	b, err := Load(filepath.Join(dir, "baseline.json"))
+	require.NoError(t, err)
+	b.Update([]reporter.Report{failed(file, []int{1}, "schema: line 1, column 1: (root): Invalid type")})
+
+	reports := b.Apply([]reporter.Report{
+		failed(file, []int{1, 2}, "schema: line 1, column 1: (root): Invalid type", "schema: line 2, column 1: (root): Invalid type"),
+	})
+	require.False(t, reports[0].IsValid, "a second occurrence of a known error is new")
+	require.Equal(t, []string{"schema: line 2, column 1: (root): Invalid type"}, reports[0].ValidationErrors)
+}
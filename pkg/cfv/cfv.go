@@ -0,0 +1,200 @@
+// Package cfv is the embeddable API of the config file validator.
+//
+// Validate checks a single document held in memory and ValidateFS checks
+// every supported file in an fs.FS. Both detect file types the way the
+// validator command does and resolve schemas with the same precedence: a
+// schema the document declares, then Options.SchemaMap, then
+// Options.SchemaStore. Findings are returned as structured values rather
+// than printed.
+//
+//	res, err := cfv.Validate(ctx, "config/app.json", content, cfv.Options{
+//		SchemaMap: map[string]string{"**/app.json": "schemas/app.schema.json"},
+//	})
+package cfv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/cli"
+	"github.com/Boeing/config-file-validator/v2/pkg/filetype"
+	"github.com/Boeing/config-file-validator/v2/pkg/finder"
+	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+	"github.com/Boeing/config-file-validator/v2/pkg/schemastore"
+)
+
+// ErrUnsupportedFileType is returned by Validate when path is not a file
+// type the validator handles.
+var ErrUnsupportedFileType = errors.New("unsupported file type")
+
+// Options configures validation. The zero value checks the syntax of every
+// supported file type and any schema a document declares.
+type Options struct {
+	// SchemaMap maps glob patterns, or plain file names, to schema files,
+	// as --schema-map does. Schema paths are read from the OS file system.
+	SchemaMap map[string]string
+	// SchemaStore is consulted for files with no declared or mapped schema.
+	SchemaStore *schemastore.Store
+	// RequireSchema makes files that support schema validation fail when
+	// no schema is found for them.
+	RequireSchema bool
+	// NoSchema turns schema validation off entirely.
+	NoSchema bool
+	// DisabledRules drops findings whose rule ID matches one of the
+	// patterns, as --disable-rules does.
+	DisabledRules []string
+	// FileTypes limits detection to these file types. It defaults to
+	// filetype.FileTypes.
+	FileTypes []filetype.FileType
+	// TypeOverrides assign file types to paths matching glob patterns
+	// ahead of detection, as --type-map does.
+	TypeOverrides []finder.TypeOverride
+	// Archives makes ValidateFS validate the members of zip and tar
+	// archives, as --archives does.
+	Archives bool
+	// TypeHints types files by the type they declare in their first
+	// lines, as --type-hints does.
+	TypeHints bool
+	// Sniff detects the type of files whose name does not give one from
+	// their content, as --sniff does.
+	Sniff bool
+}
+
+// Finding is a single problem found in a file.
+type Finding struct {
+	// Rule is the stable rule ID of the finding, such as "json/syntax".
+	Rule string
+	// Severity is the severity of the finding.
+	Severity reporter.Severity
+	// Kind is "syntax" or "schema".
+	Kind string
+	// Message describes the finding, without its kind or position.
+	Message string
+	// Line and Column are 1-based, and 0 when unknown.
+	Line   int
+	Column int
+}
+
+// Result is the outcome of validating one file.
+type Result struct {
+	// Path is the path the file was validated as.
+	Path string
+	// FileType is the name of the detected file type, such as "json".
+	FileType string
+	// Valid is false when any finding has error severity.
+	Valid    bool
+	Findings []Finding
+	// Warnings and Notes are advisory messages that are not tied to a
+	// position, such as a schema mapping that does not apply to the file.
+	Warnings []string
+	Notes    []string
+}
+
+// validation holds what Validate and ValidateFS share across files.
+type validation struct {
+	cli    *cli.CLI
+	finder *finder.FileSystemFinder
+}
+
+func newValidation(opts Options, finderOpts ...finder.FSFinderOptions) validation {
+	finderOpts = append(finderOpts,
+		finder.WithTypeOverrides(opts.TypeOverrides),
+		finder.WithArchives(opts.Archives),
+		finder.WithTypeHints(opts.TypeHints),
+		finder.WithSniff(opts.Sniff),
+	)
+	if opts.FileTypes != nil {
+		finderOpts = append(finderOpts, finder.WithFileTypes(opts.FileTypes))
+	}
+	return validation{
+		cli: cli.Init(
+			cli.WithReporters(),
+			cli.WithSchemaMap(opts.SchemaMap),
+			cli.WithSchemaStore(opts.SchemaStore),
+			cli.WithRequireSchema(opts.RequireSchema),
+			cli.WithNoSchema(opts.NoSchema),
+			cli.WithDisabledRules(opts.DisabledRules),
+		),
+		finder: finder.FileSystemFinderInit(finderOpts...),
+	}
+}
+
+// validate returns the result for content as the file at path, and false
+// when path is not a supported file.
+func (v validation) validate(path string, content []byte) (Result, bool, error) {
+	ft, ok, err := v.finder.FileTypeFor(path, content)
+	if err != nil || !ok {
+		return Result{}, false, err
+	}
+	return newResult(path, ft.Name, v.cli.ValidateContent(content, ft, path)), true, nil
+}
+
+// Validate validates content as the file at path. The path and the type
+// hints in content select the file type, and the path the schema; the file
+// itself is never read. It returns an error wrapping ErrUnsupportedFileType
+// when path is not a supported file.
+func Validate(ctx context.Context, path string, content []byte, opts Options) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	res, ok, err := newValidation(opts).validate(path, content)
+	if err != nil {
+		return Result{}, err
+	}
+	if !ok {
+		return Result{}, fmt.Errorf("%s: %w", path, ErrUnsupportedFileType)
+	}
+	return res, nil
+}
+
+// ValidateFS validates every supported file in fsys, in lexical order, and
+// skips the rest. Result paths are the slash-separated paths within fsys.
+// It stops at the first error reading fsys or when ctx is done.
+func ValidateFS(ctx context.Context, fsys fs.FS, opts Options) ([]Result, error) {
+	v := newValidation(opts, finder.WithFS(fsys))
+	files, err := v.finder.Find()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(files))
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		content, err := f.ReadFile()
+		if errors.Is(err, finder.ErrUnreadableArchive) {
+			results = append(results, newResult(f.Path, "", cli.UnreadableArchiveReport(f, err)))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, newResult(f.Path, f.FileType.Name, v.cli.ValidateFile(content, f)))
+	}
+	return results, nil
+}
+
+func newResult(path, fileType string, report reporter.Report) Result {
+	res := Result{
+		Path:     path,
+		FileType: fileType,
+		Valid:    report.IsValid,
+		Warnings: report.Warnings,
+		Notes:    report.Notes,
+	}
+	for i, msg := range report.ValidationErrors {
+		kind, text := reporter.SplitFinding(msg)
+		line, col := report.Position(i)
+		res.Findings = append(res.Findings, Finding{
+			Rule:     report.Rule(i),
+			Severity: report.Severity(i),
+			Kind:     kind,
+			Message:  text,
+			Line:     line,
+			Column:   col,
+		})
+	}
+	return res
+}
@@ -0,0 +1,165 @@
+package cfv
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/internal/testhelper"
+	"github.com/Boeing/config-file-validator/v2/pkg/filetype"
+	"github.com/Boeing/config-file-validator/v2/pkg/finder"
+	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+)
+
+func Test_Validate(t *testing.T) {
+	t.Parallel()
+	res, err := Validate(context.Background(), "config/app.json", []byte("{\n  \"a\": 1,\n}"), Options{})
+	require.NoError(t, err)
+	require.Equal(t, "config/app.json", res.Path)
+	require.Equal(t, "json", res.FileType)
+	require.False(t, res.Valid)
+	require.Len(t, res.Findings, 1)
+	f := res.Findings[0]
+	require.Equal(t, "json/syntax", f.Rule)
+	require.Equal(t, reporter.SeverityError, f.Severity)
+	require.Equal(t, "syntax", f.Kind)
+	require.Equal(t, 3, f.Line)
+	require.NotContains(t, f.Message, "line 3")
+
+	res, err = Validate(context.Background(), "app.yaml", []byte("a: 1\n"), Options{})
+	require.NoError(t, err)
+	require.True(t, res.Valid)
+	require.Empty(t, res.Findings)
+}
+
+func Test_ValidateSchemaMap(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	testhelper.WriteFile(t, dir, "app.schema.json", `{"type": "object", "required": ["name"]}`)
+	opts := Options{SchemaMap: map[string]string{"**/app.json": filepath.Join(dir, "app.schema.json")}}
+
+	res, err := Validate(context.Background(), "deploy/app.json", []byte(`{}`), opts)
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+	require.Len(t, res.Findings, 1)
+	require.Equal(t, "schema", res.Findings[0].Kind)
+	require.Equal(t, "schema/required", res.Findings[0].Rule)
+
+	opts.DisabledRules = []string{"schema/*"}
+	res, err = Validate(context.Background(), "deploy/app.json", []byte(`{}`), opts)
+	require.NoError(t, err)
+	require.True(t, res.Valid)
+
+	res, err = Validate(context.Background(), "deploy/app.json", []byte(`{}`), Options{NoSchema: true, SchemaMap: opts.SchemaMap})
+	require.NoError(t, err)
+	require.True(t, res.Valid)
+}
+
+func Test_ValidateRequireSchema(t *testing.T) {
+	t.Parallel()
+	res, err := Validate(context.Background(), "app.json", []byte(`{}`), Options{RequireSchema: true})
+	require.NoError(t, err)
+	require.False(t, res.Valid)
+	require.Len(t, res.Findings, 1)
+	require.Equal(t, "schema/missing", res.Findings[0].Rule)
+
+	// Files that cannot carry a schema are not affected.
+	res, err = Validate(context.Background(), "app.env", []byte("A=1\n"), Options{RequireSchema: true})
+	require.NoError(t, err)
+	require.True(t, res.Valid)
+}
+
+func Test_ValidateFileTypes(t *testing.T) {
+	t.Parallel()
+	_, err := Validate(context.Background(), "notes.md", []byte("# notes"), Options{})
+	require.ErrorIs(t, err, ErrUnsupportedFileType)
+
+	_, err = Validate(context.Background(), "app.yaml", []byte("a: 1"), Options{FileTypes: []filetype.FileType{filetype.JSONFileType}})
+	require.ErrorIs(t, err, ErrUnsupportedFileType)
+
+	res, err := Validate(context.Background(), "settings.conf", []byte(`{"a": 1}`), Options{
+		TypeOverrides: []finder.TypeOverride{{Pattern: "**/*.conf", FileType: filetype.JSONFileType}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "json", res.FileType)
+	require.True(t, res.Valid)
+
+	res, err = Validate(context.Background(), "chart/values", []byte("# cfv: type=yaml\na: [1\n"), Options{TypeHints: true})
+	require.NoError(t, err)
+	require.Equal(t, "yaml", res.FileType)
+	require.False(t, res.Valid)
+
+	_, err = Validate(context.Background(), "chart/values", []byte("# cfv: type=yaml\na: 1\n"), Options{})
+	require.ErrorIs(t, err, ErrUnsupportedFileType, "type hints are off by default")
+
+	res, err = Validate(context.Background(), "etc/app.cnf", []byte("<app/>"), Options{Sniff: true})
+	require.NoError(t, err)
+	require.Equal(t, "xml", res.FileType)
+}
+
+func Test_ValidateCanceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := Validate(ctx, "app.json", []byte(`{}`), Options{})
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = ValidateFS(ctx, fstest.MapFS{"app.json": {Data: []byte(`{}`)}}, Options{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_ValidateFS(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"b.yaml":          {Data: []byte("a: [1\n")},
+		"a.json":          {Data: []byte(`{"a": 1}`)},
+		"nested/c.toml":   {Data: []byte("a = 1\n")},
+		"nested/notes.md": {Data: []byte("# notes")},
+	}
+	results, err := ValidateFS(context.Background(), fsys, Options{})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, "a.json", results[0].Path)
+	require.True(t, results[0].Valid)
+	require.Equal(t, "b.yaml", results[1].Path)
+	require.False(t, results[1].Valid)
+	require.Equal(t, "yaml/syntax", results[1].Findings[0].Rule)
+	require.Equal(t, "nested/c.toml", results[2].Path)
+	require.Equal(t, "toml", results[2].FileType)
+}
+
+func Test_ValidateFSTypeNotes(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"etc/app.cnf": {Data: []byte("<?xml version=\"1.0\"?>\n<app/>")},
+		"app.ini":     {Data: []byte("; -*- mode: conf -*-\n[server]\n")},
+	}
+	results, err := ValidateFS(context.Background(), fsys, Options{Sniff: true, TypeHints: true})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "app.ini", results[0].Path)
+	require.True(t, results[0].Valid)
+	require.Len(t, results[0].Findings, 1)
+	require.Equal(t, "hint/unknown-type", results[0].Findings[0].Rule)
+	require.Equal(t, reporter.SeverityWarning, results[0].Findings[0].Severity)
+	require.Equal(t, "etc/app.cnf", results[1].Path)
+	require.Equal(t, []string{"file type xml detected from content (high confidence)"}, results[1].Notes)
+}
+
+func Test_ValidateFSUnreadableArchive(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"a.json":     {Data: []byte(`{}`)},
+		"bundle.zip": {Data: []byte("not a zip file")},
+	}
+	results, err := ValidateFS(context.Background(), fsys, Options{Archives: true})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.True(t, results[0].Valid)
+	require.Equal(t, "bundle.zip", results[1].Path)
+	require.False(t, results[1].Valid)
+	require.Equal(t, "fs/unreadable-archive", results[1].Findings[0].Rule)
+	require.Contains(t, results[1].Findings[0].Message, "unreadable archive")
+}
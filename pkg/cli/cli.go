@@ -1,19 +1,29 @@
 package cli
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/bmatcuk/doublestar/v4"
 
+	"github.com/Boeing/config-file-validator/v2/pkg/baseline"
 	"github.com/Boeing/config-file-validator/v2/pkg/filetype"
 	"github.com/Boeing/config-file-validator/v2/pkg/finder"
 	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+	"github.com/Boeing/config-file-validator/v2/pkg/resultcache"
 	"github.com/Boeing/config-file-validator/v2/pkg/schemastore"
+	"github.com/Boeing/config-file-validator/v2/pkg/suppress"
 	"github.com/Boeing/config-file-validator/v2/pkg/tools"
 	"github.com/Boeing/config-file-validator/v2/pkg/validator"
 )
@@ -28,12 +38,35 @@ type CLI struct {
 	requireSchema bool
 	noSchema      bool
 	schemaMap     map[string]string
+	schemaMapFunc func(filePath string) (string, bool)
+	configFiles   func(filePath string) []string
 	schemaStore   *schemastore.Store
 	stdinData     []byte
 	stdinFileType filetype.FileType
+	jobs          int
+	resultCache   *resultcache.Cache
+	baseline      *baseline.Baseline
+	updateBase    bool
+	failOn        FailOn
+	disabledRules []string
+	schemaHashes  sync.Map // schema location -> []byte, for resultCacheKey
+	configHashes  sync.Map // config file path -> []byte, for resultCacheKey
 	errorFound    bool
 }
 
+// FailOn is the lowest severity of finding that makes Run return exit
+// status 1.
+type FailOn string
+
+const (
+	// FailOnError fails the run when any file has an error. It is the default.
+	FailOnError FailOn = "error"
+	// FailOnWarning also fails the run on warnings.
+	FailOnWarning FailOn = "warning"
+	// FailOnNever never fails the run because of findings.
+	FailOnNever FailOn = "never"
+)
+
 // Option configures a CLI instance.
 type Option func(*CLI)
 
@@ -79,6 +112,24 @@ func WithSchemaMap(m map[string]string) Option {
 	}
 }
 
+// WithSchemaMapFunc sets a function that maps a file to a schema before
+// the schema map is consulted, such as one applying per-directory
+// configuration. It returns false for files it does not map.
+func WithSchemaMapFunc(f func(filePath string) (string, bool)) Option {
+	return func(c *CLI) {
+		c.schemaMapFunc = f
+	}
+}
+
+// WithConfigFilesFunc sets a function that returns the configuration
+// files, such as nested .cfv.toml files, that apply to a file. Their
+// content is part of the file's result cache key.
+func WithConfigFilesFunc(f func(filePath string) []string) Option {
+	return func(c *CLI) {
+		c.configFiles = f
+	}
+}
+
 func WithSchemaStore(s *schemastore.Store) Option {
 	return func(c *CLI) {
 		c.schemaStore = s
@@ -92,6 +143,54 @@ func WithStdinData(data []byte, ft filetype.FileType) Option {
 	}
 }
 
+// WithJobs sets the number of files validated concurrently. A value of
+// zero or less uses the number of available CPUs.
+func WithJobs(jobs int) Option {
+	return func(c *CLI) {
+		c.jobs = jobs
+	}
+}
+
+// WithResultCache replays reports for unchanged files from rc instead of
+// validating them again, and stores new reports in it. Stdin is never cached.
+func WithResultCache(rc *resultcache.Cache) Option {
+	return func(c *CLI) {
+		c.resultCache = rc
+	}
+}
+
+// WithBaseline suppresses the failures recorded in b and reports stale
+// entries as warnings. Stdin is never compared against a baseline.
+func WithBaseline(b *baseline.Baseline) Option {
+	return func(c *CLI) {
+		c.baseline = b
+	}
+}
+
+// WithUpdateBaseline records the failures of the run in the baseline set
+// with WithBaseline, and saves it, before the baseline is applied.
+func WithUpdateBaseline(update bool) Option {
+	return func(c *CLI) {
+		c.updateBase = update
+	}
+}
+
+// WithFailOn sets the severity threshold that decides the exit status.
+func WithFailOn(failOn FailOn) Option {
+	return func(c *CLI) {
+		c.failOn = failOn
+	}
+}
+
+// WithDisabledRules drops findings whose rule ID matches one of patterns.
+// A pattern is a rule ID, or a category followed by "/*" to match every
+// rule in it, such as "schema/*".
+func WithDisabledRules(patterns []string) Option {
+	return func(c *CLI) {
+		c.disabledRules = patterns
+	}
+}
+
 func Init(opts ...Option) *CLI {
 	c := &CLI{
 		finder:    finder.FileSystemFinderInit(),
@@ -115,31 +214,23 @@ func (c *CLI) Run() (int, error) {
 		return 2, fmt.Errorf("unable to find files: %w", err)
 	}
 
-	var reports []reporter.Report
-	for _, f := range foundFiles {
-		content, err := os.ReadFile(f.Path)
-		if err != nil {
-			if isBrokenSymlink(f.Path) {
-				report := reporter.Report{
-					FileName:         f.Name,
-					FilePath:         f.Path,
-					IsValid:          false,
-					ValidationError:  errors.New("broken symlink"),
-					ValidationErrors: []string{"broken symlink"},
-					ErrorType:        "other",
-				}
-				c.errorFound = true
-				reports = append(reports, report)
-				continue
+	reports, err := c.validateFiles(foundFiles)
+	if err != nil {
+		return 2, err
+	}
+	if c.baseline != nil {
+		if c.updateBase {
+			c.baseline.Update(reports)
+			if err := c.baseline.Save(); err != nil {
+				return 2, err
 			}
-			return 2, fmt.Errorf("unable to read file: %w", err)
 		}
-
-		report := c.validate(content, f.FileType, f.Name, f.Path)
-		if !report.IsValid {
+		reports = c.baseline.Apply(reports)
+	}
+	for _, report := range reports {
+		if c.fails(report) {
 			c.errorFound = true
 		}
-		reports = append(reports, report)
 	}
 
 	if err := c.printReports(reports); err != nil {
@@ -152,6 +243,312 @@ func (c *CLI) Run() (int, error) {
 	return 0, nil
 }
 
+// validateFiles validates files on a bounded pool of workers. Reports are
+// returned in the same order as files, regardless of which worker finishes
+// first, so output stays deterministic.
+func (c *CLI) validateFiles(files []finder.FileMetadata) ([]reporter.Report, error) {
+	reports := make([]reporter.Report, len(files))
+	errs := make([]error, len(files))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(c.workerCount(), len(files)) {
+		wg.Go(func() {
+			for i := range indexes {
+				reports[i], errs[i] = c.validateFile(files[i])
+			}
+		})
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return reports, nil
+}
+
+func (c *CLI) workerCount() int {
+	if c.jobs > 0 {
+		return c.jobs
+	}
+	return runtime.NumCPU()
+}
+
+// validateFile reads and validates a single file found by the finder.
+func (c *CLI) validateFile(f finder.FileMetadata) (reporter.Report, error) {
+	content, err := f.ReadFile()
+	if err != nil {
+		if f.FS == nil && isBrokenSymlink(f.Path) {
+			return reporter.Report{
+				FileName:         f.Name,
+				FilePath:         f.Path,
+				IsValid:          false,
+				ValidationError:  errors.New("broken symlink"),
+				ValidationErrors: []string{"broken symlink"},
+				ErrorType:        "other",
+				ErrorRules:       []string{validator.RuleBrokenSymlink},
+			}, nil
+		}
+		if errors.Is(err, finder.ErrUnreadableArchive) {
+			return UnreadableArchiveReport(f, err), nil
+		}
+		return reporter.Report{}, fmt.Errorf("unable to read file: %w", err)
+	}
+
+	if c.resultCache == nil {
+		return c.withTypeNote(c.validate(content, f.FileType, f.Name, f.Path), f), nil
+	}
+
+	key := c.resultCacheKey(content, f)
+	if report, ok := c.resultCache.Get(key); ok {
+		report.FileName = f.Name
+		report.FilePath = f.Path
+		report.IsQuiet = c.quiet
+		return c.withTypeNote(report, f), nil
+	}
+
+	report := c.validate(content, f.FileType, f.Name, f.Path)
+	// The cache is an optimisation; failing to store an entry only means the
+	// file is validated again next time.
+	_ = c.resultCache.Put(key, report)
+	return c.withTypeNote(report, f), nil
+}
+
+// withTypeNote adds a note on how the finder chose the file's type, when it
+// was not from the file name, and a warning finding for a type hint the
+// finder could not use.
+func (c *CLI) withTypeNote(report reporter.Report, f finder.FileMetadata) reporter.Report {
+	if f.TypeNote != "" {
+		report.Notes = append(slices.Clone(report.Notes), f.TypeNote)
+	}
+	if f.TypeWarning != "" && !c.ruleDisabled(validator.RuleUnknownTypeHint) {
+		report = appendDiagnostics(report, []validator.Diagnostic{{
+			Severity: validator.SeverityWarning,
+			Message:  f.TypeWarning,
+			Rule:     validator.RuleUnknownTypeHint,
+		}})
+	}
+	return report
+}
+
+// resultCacheKey derives the result cache key for a file from everything that
+// can change its report: the path (relative schema references resolve
+// against it), the content, the file type and validator options, the schema
+// options of this CLI, the content of the configuration files that apply to
+// the file, and the content of the schemas the file is validated against and
+// of the schemas they reference.
+func (c *CLI) resultCacheKey(content []byte, f finder.FileMetadata) string {
+	parts := [][]byte{
+		[]byte(f.Path),
+		content,
+		[]byte(f.FileType.Name),
+		validatorOptions(f.FileType.Validator),
+		fmt.Appendf(nil, "noSchema=%t requireSchema=%t schemaStore=%t",
+			c.noSchema, c.requireSchema, c.schemaStore != nil),
+		fmt.Appendf(nil, "disabledRules=%q", c.disabledRules),
+	}
+	for _, pattern := range slices.Sorted(maps.Keys(c.schemaMap)) {
+		parts = append(parts, []byte(pattern), []byte(c.schemaMap[pattern]))
+	}
+	if c.configFiles != nil {
+		for _, path := range c.configFiles(f.Path) {
+			parts = append(parts, []byte(path), c.configFingerprint(path))
+		}
+	}
+	for _, schema := range c.schemaLocations(f.FileType.Validator, content, f.Path) {
+		parts = append(parts, []byte(schema), c.schemaFingerprint(schema))
+	}
+	if cu, ok := f.FileType.Validator.(validator.CatalogUser); ok {
+		for _, catalog := range cu.CatalogFiles() {
+			parts = append(parts, []byte(catalog), c.schemaFingerprint(catalog))
+		}
+	}
+	return c.resultCache.Key(parts...)
+}
+
+// schemaLocations returns the schemas validateSchema would apply to the
+// file, each of a document's schemas when it declares several.
+func (c *CLI) schemaLocations(v validator.Validator, content []byte, filePath string) []string {
+	if msl, ok := v.(validator.MultiSchemaLocator); ok && !c.noSchema {
+		if locations := msl.SchemaLocations(content, filePath); len(locations) > 0 {
+			return locations
+		}
+	}
+	if schema := c.schemaLocation(v, content, filePath); schema != "" {
+		return []string{schema}
+	}
+	return nil
+}
+
+// schemaLocation returns the schema validateSchema would apply to the file,
+// following the same precedence: document-declared, then --schema-map, then
+// SchemaStore.
+func (c *CLI) schemaLocation(v validator.Validator, content []byte, filePath string) string {
+	if c.noSchema {
+		return ""
+	}
+	if sl, ok := v.(validator.SchemaLocator); ok {
+		if loc := sl.SchemaLocation(content, filePath); loc != "" {
+			return loc
+		}
+	}
+	if schemaPath, ok := c.lookupSchemaMap(filePath); ok {
+		return schemaPath
+	}
+	if c.schemaStore != nil {
+		if schemaPath, ok := c.schemaStore.Resolve(filePath); ok {
+			return schemaPath
+		}
+	}
+	return ""
+}
+
+// validatorOptions serializes the type and options of a validator for the
+// result cache key. Options are encoded as JSON, field by field, so that the
+// key follows pointers and does not depend on how a struct prints.
+func validatorOptions(v validator.Validator) []byte {
+	opts, err := json.Marshal(v)
+	if err != nil {
+		opts = []byte(err.Error())
+	}
+	return fmt.Appendf(nil, "%T%s", v, opts)
+}
+
+// schemaFingerprint returns a hash of a local schema file's content and of
+// every local file its $ref keywords reach, directly or through the files
+// they reference. Remote schemas and references, and local files that
+// cannot be read, are identified by their location alone. Hashes are
+// computed once per run.
+func (c *CLI) schemaFingerprint(location string) []byte {
+	if sum, ok := c.schemaHashes.Load(location); ok {
+		return sum.([]byte)
+	}
+	h := sha256.New()
+	seen := make(map[string]bool)
+	for queue := []string{location}; len(queue) > 0; queue = queue[1:] {
+		loc := queue[0]
+		if seen[loc] {
+			continue
+		}
+		seen[loc] = true
+		fmt.Fprintf(h, "%s\x00", loc)
+		path, ok := schemaFilePath(loc)
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		h.Write(sum[:])
+		queue = append(queue, schemaRefs(data, path)...)
+	}
+	sum := h.Sum(nil)
+	c.schemaHashes.Store(location, sum)
+	return sum
+}
+
+// configFingerprint returns a hash of the configuration file at path, or
+// of nothing when it cannot be read. Hashes are computed once per run.
+func (c *CLI) configFingerprint(path string) []byte {
+	if sum, ok := c.configHashes.Load(path); ok {
+		return sum.([]byte)
+	}
+	var sum [sha256.Size]byte
+	if data, err := os.ReadFile(path); err == nil {
+		sum = sha256.Sum256(data)
+	}
+	c.configHashes.Store(path, sum[:])
+	return sum[:]
+}
+
+// schemaFilePath returns the local file a schema location names, or false
+// for a remote schema.
+func schemaFilePath(location string) (string, bool) {
+	if strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://") {
+		return "", false
+	}
+	path := location
+	if p, ok := tools.FileURLToPath(location); ok {
+		path = p
+	}
+	// A CUE schema may name a definition after the file, as in
+	// "app.cue#Config".
+	if i := strings.LastIndex(path, ".cue#"); i >= 0 {
+		path = path[:i+len(".cue")]
+	}
+	return path, true
+}
+
+// schemaRefs returns the schemas the $ref keywords of a JSON Schema at path
+// point to, resolved against it and sorted. References within the schema
+// itself are skipped, and so is everything in files that are not JSON.
+func schemaRefs(data []byte, path string) []string {
+	var schema any
+	if json.Unmarshal(data, &schema) != nil {
+		return nil
+	}
+	refs := make(map[string]struct{})
+	collectSchemaRefs(schema, path, refs)
+	return slices.Sorted(maps.Keys(refs))
+}
+
+func collectSchemaRefs(v any, path string, refs map[string]struct{}) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, val := range v {
+			if ref, ok := val.(string); ok && key == "$ref" {
+				if target := resolveSchemaRef(ref, path); target != "" {
+					refs[target] = struct{}{}
+				}
+				continue
+			}
+			collectSchemaRefs(val, path, refs)
+		}
+	case []any:
+		for _, val := range v {
+			collectSchemaRefs(val, path, refs)
+		}
+	default:
+	}
+}
+
+// resolveSchemaRef resolves a $ref against the schema file at path, without
+// its fragment. It returns "" for a reference within the same schema.
+func resolveSchemaRef(ref, path string) string {
+	ref, _, _ = strings.Cut(ref, "#")
+	switch {
+	case ref == "":
+		return ""
+	case strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://"):
+		return ref
+	}
+	if p, ok := tools.FileURLToPath(ref); ok {
+		return p
+	}
+	if u, err := url.Parse(ref); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+		// A URN or another scheme names a schema by its $id, which no
+		// file lookup can find.
+		return ref
+	}
+	ref, err := url.PathUnescape(ref)
+	if err != nil {
+		return ""
+	}
+	ref = filepath.FromSlash(ref)
+	if filepath.IsAbs(ref) {
+		return ref
+	}
+	return filepath.Join(filepath.Dir(path), ref)
+}
+
 // validate runs syntax and schema validation on content and returns a Report.
 func (c *CLI) validate(content []byte, ft filetype.FileType, name, path string) reporter.Report {
 	isValid, syntaxErr := ft.Validator.ValidateSyntax(content)
@@ -164,12 +561,18 @@ func (c *CLI) validate(content []byte, ft filetype.FileType, name, path string)
 
 	err := syntaxErr
 	errorType := ""
+	rule := ""
 	if syntaxErr != nil {
 		errorType = "syntax"
+		rule = validator.SyntaxRule(ft.Name)
 	}
 	if schemaErr != nil {
 		err = schemaErr
 		errorType = "schema"
+		rule = validator.RuleSchemaInvalid
+		if errors.Is(schemaErr, validator.ErrNoSchema) {
+			rule = validator.RuleSchemaMissing
+		}
 	}
 
 	var line, col int
@@ -179,10 +582,10 @@ func (c *CLI) validate(content []byte, ft filetype.FileType, name, path string)
 		col = ve.Column
 	}
 
-	validationErrors, errLines, errCols := formatErrors(err, line, col)
+	validationErrors, errLines, errCols, errRules := formatErrors(err, line, col, rule)
 	notes := checkJSONCFallback(syntaxErr, ft, content, name)
 
-	return reporter.Report{
+	report := reporter.Report{
 		FileName:         name,
 		FilePath:         path,
 		IsValid:          isValid,
@@ -196,7 +599,20 @@ func (c *CLI) validate(content []byte, ft filetype.FileType, name, path string)
 		StartColumn:      col,
 		ErrorLines:       errLines,
 		ErrorColumns:     errCols,
+		ErrorRules:       errRules,
+	}
+	if d, ok := ft.Validator.(validator.Diagnoser); ok && syntaxErr == nil {
+		report = appendDiagnostics(report, d.Diagnostics(content))
+	}
+	if len(c.disabledRules) > 0 {
+		report, _ = report.DropErrors(func(i int, _ string) bool {
+			return c.ruleDisabled(report.Rule(i))
+		})
+	}
+	if cm, ok := ft.Validator.(validator.Commenter); ok && len(report.ValidationErrors) > 0 {
+		report = suppress.Parse(content, cm.CommentPrefixes()).Apply(report)
 	}
+	return report
 }
 
 // runSingle validates a single piece of content (used for stdin mode).
@@ -207,58 +623,132 @@ func (c *CLI) runSingle(content []byte, ft filetype.FileType, name string) (int,
 		return 2, err
 	}
 
-	if !report.IsValid {
+	if c.fails(report) {
 		return 1, nil
 	}
 	return 0, nil
 }
 
-func formatErrors(err error, line, col int) (errs []string, lines []int, cols []int) {
+// ValidateContent validates content as the file at path, with the same
+// schema resolution, rule filtering and suppression as Run, and returns the
+// report without printing it. Reporters, the result cache and the baseline
+// are not used.
+func (c *CLI) ValidateContent(content []byte, ft filetype.FileType, path string) reporter.Report {
+	return c.validate(content, ft, filepath.Base(path), path)
+}
+
+// ValidateFile validates content read from a file the finder found, as
+// ValidateContent does, and notes how the finder chose its type as Run
+// does.
+func (c *CLI) ValidateFile(content []byte, f finder.FileMetadata) reporter.Report {
+	return c.withTypeNote(c.validate(content, f.FileType, f.Name, f.Path), f)
+}
+
+// fails reports whether report makes the run fail under the --fail-on
+// threshold.
+func (c *CLI) fails(report reporter.Report) bool {
+	switch c.failOn {
+	case FailOnNever:
+		return false
+	case FailOnWarning:
+		sev, ok := report.HighestSeverity()
+		return ok && sev.AtLeast(reporter.SeverityWarning)
+	default:
+		return !report.IsValid
+	}
+}
+
+// ruleDisabled reports whether findings of the rule ID id are dropped.
+func (c *CLI) ruleDisabled(id string) bool {
+	for _, pattern := range c.disabledRules {
+		if validator.MatchRule(pattern, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendDiagnostics adds diagnostics to the report's findings with their
+// severities. Diagnostics never make a report invalid.
+func appendDiagnostics(report reporter.Report, diags []validator.Diagnostic) reporter.Report {
+	if len(diags) == 0 {
+		return report
+	}
+	if len(report.ValidationErrors) == 0 {
+		report.StartLine, report.StartColumn = diags[0].Line, diags[0].Column
+	}
+	for range len(report.ValidationErrors) - len(report.ErrorSeverities) {
+		report.ErrorSeverities = append(report.ErrorSeverities, reporter.SeverityError)
+	}
+	for _, d := range diags {
+		report.ValidationErrors = append(report.ValidationErrors, positionPrefix("syntax", d.Line, d.Column)+d.Message)
+		report.ErrorLines = append(report.ErrorLines, d.Line)
+		report.ErrorColumns = append(report.ErrorColumns, d.Column)
+		report.ErrorSeverities = append(report.ErrorSeverities, reporter.Severity(d.Severity.String()))
+		report.ErrorRules = append(report.ErrorRules, d.Rule)
+	}
+	return report
+}
+
+// positionPrefix returns the "<kind>: line N, column M: " prefix of a
+// formatted finding, omitting whichever parts of the position are unknown.
+func positionPrefix(kind string, line, col int) string {
+	switch {
+	case line > 0 && col > 0:
+		return fmt.Sprintf("%s: line %d, column %d: ", kind, line, col)
+	case line > 0:
+		return fmt.Sprintf("%s: line %d: ", kind, line)
+	default:
+		return kind + ": "
+	}
+}
+
+// formatErrors splits err into formatted findings with their positions and
+// rule IDs. rule is the rule ID of an error that does not carry its own.
+func formatErrors(err error, line, col int, rule string) (errs []string, lines []int, cols []int, rules []string) {
 	if err == nil {
-		return nil, nil, nil
+		return nil, nil, nil, nil
 	}
 	var se *validator.SchemaErrors
 	if errors.As(err, &se) {
-		var errs []string
-		var lines, cols []int
 		for i, e := range se.Errors() {
 			var pos validator.SchemaErrorPosition
 			if i < len(se.Positions) {
 				pos = se.Positions[i]
 			}
-			var prefix string
-			switch {
-			case pos.Line > 0 && pos.Column > 0:
-				prefix = fmt.Sprintf("schema: line %d, column %d: ", pos.Line, pos.Column)
-			case pos.Line > 0:
-				prefix = fmt.Sprintf("schema: line %d: ", pos.Line)
-			default:
-				prefix = "schema: "
-			}
-			errs = append(errs, prefix+e)
+			errs = append(errs, positionPrefix("schema", pos.Line, pos.Column)+e)
 			lines = append(lines, pos.Line)
 			cols = append(cols, pos.Column)
+			rules = append(rules, se.Rule(i))
+		}
+		return errs, lines, cols, rules
+	}
+
+	var syn *validator.SyntaxErrors
+	if errors.As(err, &syn) {
+		for _, item := range syn.Items {
+			errs = append(errs, positionPrefix("syntax", item.Line, item.Column)+item.Err.Error())
+			lines = append(lines, item.Line)
+			cols = append(cols, item.Column)
+			if item.Rule != "" {
+				rules = append(rules, item.Rule)
+			} else {
+				rules = append(rules, rule)
+			}
 		}
-		return errs, lines, cols
+		return errs, lines, cols, rules
 	}
 
 	msg := err.Error()
 	var ve *validator.ValidationError
 	if errors.As(err, &ve) {
 		msg = ve.Err.Error()
+		if ve.Rule != "" {
+			rule = ve.Rule
+		}
 	}
 
-	var prefix string
-	switch {
-	case line > 0 && col > 0:
-		prefix = fmt.Sprintf("syntax: line %d, column %d: ", line, col)
-	case line > 0:
-		prefix = fmt.Sprintf("syntax: line %d: ", line)
-	default:
-		prefix = "syntax: "
-	}
-
-	return []string{prefix + msg}, []int{line}, []int{col}
+	return []string{positionPrefix("syntax", line, col) + msg}, []int{line}, []int{col}, []string{rule}
 }
 
 // checkJSONCFallback checks if a failed JSON file is valid JSONC and returns a note if so.
@@ -298,6 +788,7 @@ func (c *CLI) validateSchema(v validator.Validator, content []byte, filePath str
 			if c.requireSchema {
 				return false, nil, &validator.SchemaErrors{
 					Items: []string{schemaMapUnsupportedError(schemaPath)},
+					Rules: []string{validator.RuleSchemaNoSupport},
 				}
 			}
 			return valid, []string{schemaMapUnsupportedWarning(schemaPath)}, nil
@@ -319,6 +810,11 @@ func (c *CLI) validateSchema(v validator.Validator, content []byte, filePath str
 }
 
 func (c *CLI) lookupSchemaMap(filePath string) (string, bool) {
+	if c.schemaMapFunc != nil {
+		if schemaPath, ok := c.schemaMapFunc(filePath); ok {
+			return schemaPath, true
+		}
+	}
 	if len(c.schemaMap) == 0 {
 		return "", false
 	}
@@ -339,12 +835,23 @@ func (c *CLI) lookupSchemaMap(filePath string) (string, bool) {
 }
 
 func validateWithExternal(v validator.Validator, content []byte, schemaPath string) (valid bool, skipped bool, err error) {
-	if _, ok := v.(validator.XMLSchemaValidator); ok {
-		absSchema, err := filepath.Abs(schemaPath)
-		if err != nil {
-			return false, false, fmt.Errorf("resolving schema path: %w", err)
+	if validator.IsCUESchema(schemaPath) {
+		return validateWithCUE(v, content, schemaPath)
+	}
+
+	if validator.IsDTDSchema(schemaPath) || validator.IsRelaxNGSchema(schemaPath) {
+		return validateWithXMLGrammar(v, content, schemaPath)
+	}
+
+	if xv, ok := v.(validator.XMLSchemaValidator); ok {
+		if !strings.HasPrefix(schemaPath, "https://") && !strings.HasPrefix(schemaPath, "http://") {
+			absSchema, err := filepath.Abs(schemaPath)
+			if err != nil {
+				return false, false, fmt.Errorf("resolving schema path: %w", err)
+			}
+			schemaPath = absSchema
 		}
-		valid, err := validator.ValidateXSD(content, absSchema)
+		valid, err := xv.ValidateXSD(content, schemaPath)
 		return valid, false, err
 	}
 
@@ -363,7 +870,67 @@ func validateWithExternal(v validator.Validator, content []byte, schemaPath stri
 		return false, false, err
 	}
 
-	valid, err = validator.JSONSchemaValidate(schemaURL, docJSON)
+	valid, err = validator.JSONSchemaValidateWithPositions(schemaURL, docJSON, positionMap(v, content))
+	return valid, false, err
+}
+
+// validateWithXMLGrammar validates content against a DTD or a RELAX NG
+// schema. Only XML documents have grammars, so other validators skip the
+// schema.
+func validateWithXMLGrammar(v validator.Validator, content []byte, schemaPath string) (valid bool, skipped bool, err error) {
+	if !strings.HasPrefix(schemaPath, "https://") && !strings.HasPrefix(schemaPath, "http://") {
+		absSchema, err := filepath.Abs(schemaPath)
+		if err != nil {
+			return false, false, fmt.Errorf("resolving schema path: %w", err)
+		}
+		schemaPath = absSchema
+	}
+	if validator.IsDTDSchema(schemaPath) {
+		if dv, ok := v.(validator.DTDSchemaValidator); ok {
+			valid, err := dv.ValidateDTD(content, schemaPath)
+			return valid, false, err
+		}
+		return true, true, nil
+	}
+	if rv, ok := v.(validator.RelaxNGSchemaValidator); ok {
+		valid, err := rv.ValidateRelaxNG(content, schemaPath)
+		return valid, false, err
+	}
+	return true, true, nil
+}
+
+// positionMap locates the JSON form of content in the source, or returns
+// nil when the validator cannot.
+func positionMap(v validator.Validator, content []byte) map[string]validator.SourcePosition {
+	if pm, ok := v.(validator.PositionMapper); ok {
+		return pm.PositionMap(content)
+	}
+	return nil
+}
+
+// validateWithCUE validates content against a CUE schema. Documents that
+// are not CUE are converted to JSON first, and errors are located through
+// the validator's position map, as positions in the converted document do
+// not match the file.
+func validateWithCUE(v validator.Validator, content []byte, schemaPath string) (valid bool, skipped bool, err error) {
+	absSchema, err := filepath.Abs(schemaPath)
+	if err != nil {
+		return false, false, fmt.Errorf("resolving schema path: %w", err)
+	}
+	if cv, ok := v.(validator.CUESchemaValidator); ok {
+		valid, err := cv.ValidateCUE(content, absSchema)
+		return valid, false, err
+	}
+
+	jm, ok := v.(validator.JSONMarshaler)
+	if !ok {
+		return true, true, nil
+	}
+	docJSON, err := jm.MarshalToJSON(content)
+	if err != nil {
+		return false, false, err
+	}
+	valid, err = validator.ValidateCUEWithPositions(docJSON, absSchema, positionMap(v, content))
 	return valid, false, err
 }
 
@@ -417,6 +984,20 @@ func (c *CLI) printGroup(reports []reporter.Report) error {
 	return reporter.PrintGroupStdout(reportGroup)
 }
 
+// UnreadableArchiveReport is the report for an archive the finder could not
+// read, whose ReadFile returned err.
+func UnreadableArchiveReport(f finder.FileMetadata, err error) reporter.Report {
+	return reporter.Report{
+		FileName:         f.Name,
+		FilePath:         f.Path,
+		IsValid:          false,
+		ValidationError:  err,
+		ValidationErrors: []string{err.Error()},
+		ErrorType:        "other",
+		ErrorRules:       []string{validator.RuleBadArchive},
+	}
+}
+
 // isBrokenSymlink reports whether path is a symlink whose target does not exist.
 func isBrokenSymlink(path string) bool {
 	fi, err := os.Lstat(path)
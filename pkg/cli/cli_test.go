@@ -1,16 +1,25 @@
 package cli
 
 import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/Boeing/config-file-validator/v2/internal/testhelper"
+	"github.com/Boeing/config-file-validator/v2/pkg/baseline"
 	"github.com/Boeing/config-file-validator/v2/pkg/filetype"
 	"github.com/Boeing/config-file-validator/v2/pkg/finder"
 	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+	"github.com/Boeing/config-file-validator/v2/pkg/resultcache"
 	"github.com/Boeing/config-file-validator/v2/pkg/schemastore"
 	"github.com/Boeing/config-file-validator/v2/pkg/validator"
 )
@@ -40,6 +49,86 @@ func Test_CLI(t *testing.T) {
 	require.Equal(t, 0, exitStatus)
 }
 
+func Test_CLIWithFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults/app.json":  {Data: []byte(testhelper.ValidContent["json"])},
+		"defaults/bad.yaml":  {Data: []byte(testhelper.InvalidContent["yaml"])},
+		"defaults/notes.txt": {Data: []byte("notes")},
+	}
+	rep := &captureReporter{}
+	exitStatus, err := Init(
+		WithFinder(finder.FileSystemFinderInit(finder.WithFS(fsys), finder.WithPathRoots("defaults"))),
+		WithReporters(rep),
+	).Run()
+	require.NoError(t, err)
+	require.Equal(t, 1, exitStatus)
+	require.Len(t, rep.reports, 2)
+	require.Equal(t, "defaults/app.json", rep.reports[0].FilePath)
+	require.True(t, rep.reports[0].IsValid)
+	require.Equal(t, "defaults/bad.yaml", rep.reports[1].FilePath)
+	require.False(t, rep.reports[1].IsValid)
+}
+
+func Test_CLIWithFSUnreadableFile(t *testing.T) {
+	files := staticFinder{{Name: "gone.json", Path: "gone.json", FileType: filetype.JSONFileType, FS: fstest.MapFS{}}}
+	_, err := Init(WithFinder(files), WithReporters(&captureReporter{})).Run()
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func Test_CLIArchiveMembers(t *testing.T) {
+	dir := t.TempDir()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range map[string]string{"config/good.json": testhelper.ValidContent["json"], "config/bad.yaml": testhelper.InvalidContent["yaml"]} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	archive := testhelper.WriteFile(t, dir, "bundle.zip", b.String())
+
+	rep := &captureReporter{}
+	exitStatus, err := Init(
+		WithFinder(finder.FileSystemFinderInit(finder.WithPathRoots(dir), finder.WithArchives(true))),
+		WithReporters(rep),
+	).Run()
+	require.NoError(t, err)
+	require.Equal(t, 1, exitStatus)
+	results := make(map[string]bool)
+	for _, r := range rep.reports {
+		results[r.FilePath] = r.IsValid
+	}
+	require.Equal(t, map[string]bool{
+		archive + "!/config/good.json": true,
+		archive + "!/config/bad.yaml":  false,
+	}, results)
+}
+
+func Test_CLIUnreadableArchive(t *testing.T) {
+	dir := t.TempDir()
+	testhelper.WriteFile(t, dir, "good.json", testhelper.ValidContent["json"])
+	archive := testhelper.WriteFile(t, dir, "bundle.tgz", "not gzip")
+
+	rep := &captureReporter{}
+	exitStatus, err := Init(
+		WithFinder(finder.FileSystemFinderInit(finder.WithPathRoots(dir), finder.WithArchives(true))),
+		WithReporters(rep),
+	).Run()
+	require.NoError(t, err)
+	require.Equal(t, 1, exitStatus)
+	require.Len(t, rep.reports, 2)
+	for _, r := range rep.reports {
+		if r.FilePath != archive {
+			require.True(t, r.IsValid, r.FilePath)
+			continue
+		}
+		require.False(t, r.IsValid)
+		require.Equal(t, []string{validator.RuleBadArchive}, r.ErrorRules)
+		require.Contains(t, r.ValidationErrors[0], "unreadable archive")
+	}
+}
+
 func Test_CLIWithMultipleReporters(t *testing.T) {
 	dir := testhelper.CreateFixtureDir(t, "json", "yaml")
 	tmpOut := t.TempDir()
@@ -248,6 +337,298 @@ func Test_CLIWithBrokenSymlink(t *testing.T) {
 	require.Equal(t, "other", errorType)
 }
 
+func Test_CLIWithJobsKeepsFinderOrder(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 50 {
+		content := testhelper.ValidContent["json"]
+		if i%7 == 0 {
+			content = testhelper.InvalidContent["json"]
+		}
+		testhelper.WriteFile(t, dir, fmt.Sprintf("file%02d.json", i), content)
+	}
+
+	fsFinder := finder.FileSystemFinderInit(
+		finder.WithPathRoots(dir),
+	)
+	files, err := fsFinder.Find()
+	require.NoError(t, err)
+
+	for _, jobs := range []int{0, 1, 4, 100} {
+		rep := &captureReporter{}
+		cli := Init(WithFinder(fsFinder), WithReporters(rep), WithJobs(jobs))
+		exitStatus, err := cli.Run()
+		require.NoError(t, err)
+		require.Equal(t, 1, exitStatus)
+
+		require.Len(t, rep.reports, len(files))
+		for i, r := range rep.reports {
+			require.Equal(t, files[i].Path, r.FilePath)
+			require.Equal(t, i%7 != 0, r.IsValid, r.FilePath)
+		}
+	}
+}
+
+// countingValidator wraps a validator and counts ValidateSyntax calls.
+type countingValidator struct {
+	validator.JSONValidator
+	calls *atomic.Int32
+}
+
+func (v countingValidator) ValidateSyntax(b []byte) (bool, error) {
+	v.calls.Add(1)
+	return v.JSONValidator.ValidateSyntax(b)
+}
+
+type staticFinder []finder.FileMetadata
+
+func (f staticFinder) Find() ([]finder.FileMetadata, error) {
+	return f, nil
+}
+
+func Test_CLIResultCacheReplaysUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	good := testhelper.WriteFile(t, dir, "good.json", testhelper.ValidContent["json"])
+	bad := testhelper.WriteFile(t, dir, "bad.json", testhelper.InvalidContent["json"])
+
+	calls := &atomic.Int32{}
+	ft := filetype.FileType{Name: "json", Validator: countingValidator{calls: calls}}
+	files := staticFinder{
+		{Name: "good.json", Path: good, FileType: ft},
+		{Name: "bad.json", Path: bad, FileType: ft},
+	}
+	rc, err := resultcache.Open(t.TempDir(), "test")
+	require.NoError(t, err)
+
+	run := func() []reporter.Report {
+		rep := &captureReporter{}
+		exitStatus, err := Init(WithFinder(files), WithReporters(rep), WithResultCache(rc)).Run()
+		require.NoError(t, err)
+		require.Equal(t, 1, exitStatus)
+		return rep.reports
+	}
+
+	first := run()
+	require.Equal(t, int32(2), calls.Load())
+
+	second := run()
+	require.Equal(t, int32(2), calls.Load(), "unchanged files must not be validated again")
+	require.Len(t, second, 2)
+	for i := range first {
+		require.Equal(t, first[i].FilePath, second[i].FilePath)
+		require.Equal(t, first[i].IsValid, second[i].IsValid)
+		require.Equal(t, first[i].ValidationErrors, second[i].ValidationErrors)
+		require.Equal(t, first[i].ErrorLines, second[i].ErrorLines)
+	}
+
+	require.NoError(t, os.WriteFile(good, []byte(`{"changed": true}`), 0600))
+	run()
+	require.Equal(t, int32(3), calls.Load(), "a changed file must be validated again")
+}
+
+func Test_CLIResultCacheInvalidatedBySchemaChange(t *testing.T) {
+	dir := t.TempDir()
+	schema := testhelper.WriteFile(t, dir, "schema.json", `{"type": "object", "required": ["name"]}`)
+	doc := testhelper.WriteFile(t, dir, "config.json", `{"$schema": "schema.json", "name": "x"}`)
+
+	files := staticFinder{{Name: "config.json", Path: doc, FileType: filetype.JSONFileType}}
+	rc, err := resultcache.Open(t.TempDir(), "test")
+	require.NoError(t, err)
+
+	run := func() int {
+		exitStatus, err := Init(WithFinder(files), WithReporters(&captureReporter{}), WithResultCache(rc)).Run()
+		require.NoError(t, err)
+		return exitStatus
+	}
+
+	require.Equal(t, 0, run())
+	require.NoError(t, os.WriteFile(schema, []byte(`{"type": "object", "required": ["other"]}`), 0600))
+	require.Equal(t, 1, run())
+}
+
+func Test_CLIResultCacheKeyIncludesOptions(t *testing.T) {
+	dir := t.TempDir()
+	doc := testhelper.WriteFile(t, dir, "config.json", `{"name": "x"}`)
+	f := finder.FileMetadata{Name: "config.json", Path: doc, FileType: filetype.JSONFileType}
+	content := []byte(`{"name": "x"}`)
+	rc, err := resultcache.Open(t.TempDir(), "test")
+	require.NoError(t, err)
+
+	base := Init(WithResultCache(rc)).resultCacheKey(content, f)
+	require.Equal(t, base, Init(WithResultCache(rc)).resultCacheKey(content, f))
+	require.NotEqual(t, base, Init(WithResultCache(rc), WithRequireSchema(true)).resultCacheKey(content, f))
+	require.NotEqual(t, base, Init(WithResultCache(rc), WithSchemaMap(map[string]string{"*.json": "s.json"})).resultCacheKey(content, f))
+
+	strict := f
+	strict.FileType.Validator = validator.JSONValidator{ForbidDuplicateKeys: true}
+	require.NotEqual(t, base, Init(WithResultCache(rc)).resultCacheKey(content, strict))
+
+	require.Equal(t, `validator.CsvValidator{"Delimiter":59,"Comment":0,"LazyQuotes":true}`,
+		string(validatorOptions(validator.CsvValidator{Delimiter: ';', LazyQuotes: true})))
+}
+
+func Test_CLIResultCacheKeyIncludesConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	nested := testhelper.WriteFile(t, dir, ".cfv.toml", "exclude-dirs = [\"a\"]\n")
+	content := []byte(`{"name": "x"}`)
+	doc := testhelper.WriteFile(t, dir, "config.json", string(content))
+	f := finder.FileMetadata{Name: "config.json", Path: doc, FileType: filetype.JSONFileType}
+	rc, err := resultcache.Open(t.TempDir(), "test")
+	require.NoError(t, err)
+	configFiles := WithConfigFilesFunc(func(string) []string { return []string{nested} })
+
+	base := Init(WithResultCache(rc)).resultCacheKey(content, f)
+	key := Init(WithResultCache(rc), configFiles).resultCacheKey(content, f)
+	require.NotEqual(t, base, key)
+	require.NoError(t, os.WriteFile(nested, []byte("exclude-dirs = [\"b\"]\n"), 0600))
+	require.NotEqual(t, key, Init(WithResultCache(rc), configFiles).resultCacheKey(content, f))
+}
+
+func Test_CLIResultCacheKeyIncludesSchemaRefs(t *testing.T) {
+	dir := t.TempDir()
+	testhelper.WriteFile(t, dir, "schema.json", `{"$ref": "defs/base.json#/definitions/app", "properties": {"self": {"$ref": "#/definitions/x"}}}`)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "defs"), 0o755))
+	base := testhelper.WriteFile(t, dir, "defs/base.json", `{"definitions": {"app": {"$ref": "../common.json"}}}`)
+	common := testhelper.WriteFile(t, dir, "common.json", `{"type": "object"}`)
+	content := []byte(`{"$schema": "schema.json", "name": "x"}`)
+	doc := testhelper.WriteFile(t, dir, "config.json", string(content))
+	f := finder.FileMetadata{Name: "config.json", Path: doc, FileType: filetype.JSONFileType}
+	rc, err := resultcache.Open(t.TempDir(), "test")
+	require.NoError(t, err)
+
+	key := Init(WithResultCache(rc)).resultCacheKey(content, f)
+	require.NoError(t, os.WriteFile(common, []byte(`{"type": "object", "required": ["other"]}`), 0600))
+	edited := Init(WithResultCache(rc)).resultCacheKey(content, f)
+	require.NotEqual(t, key, edited, "schemas reached through $ref are part of the key")
+
+	require.NoError(t, os.WriteFile(base, []byte(`{"definitions": {"app": {"$ref": "https://example.com/app.json"}}}`), 0600))
+	require.NotEqual(t, edited, Init(WithResultCache(rc)).resultCacheKey(content, f))
+
+	require.Equal(t, []string{filepath.Join(dir, "defs", "base.json"), "https://example.com/x.json", "urn:example:y"},
+		schemaRefs([]byte(`{"$ref": "defs/base.json", "items": [{"$ref": "https://example.com/x.json#/a"}, {"$ref": "urn:example:y"}, {"$ref": "#/b"}]}`), filepath.Join(dir, "schema.json")))
+}
+
+func Test_CLIResultCacheKeyIncludesXMLSchemasAndCatalog(t *testing.T) {
+	dir := t.TempDir()
+	testhelper.WriteFile(t, dir, "a.xsd", `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`)
+	second := testhelper.WriteFile(t, dir, "b.xsd", `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`)
+	catalog := testhelper.WriteFile(t, dir, "catalog.xml", `<catalog xmlns="urn:oasis:names:tc:entity:xmlns:xml:catalog"/>`)
+	content := []byte(`<root xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="urn:a a.xsd urn:b b.xsd"/>`)
+	doc := testhelper.WriteFile(t, dir, "config.xml", string(content))
+	ft := filetype.FileType{Name: "xml", Validator: validator.XMLValidator{Catalog: catalog}}
+	f := finder.FileMetadata{Name: "config.xml", Path: doc, FileType: ft}
+	rc, err := resultcache.Open(t.TempDir(), "test")
+	require.NoError(t, err)
+
+	base := Init(WithResultCache(rc)).resultCacheKey(content, f)
+	require.NoError(t, os.WriteFile(second, []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="root"/></xs:schema>`), 0600))
+	edited := Init(WithResultCache(rc)).resultCacheKey(content, f)
+	require.NotEqual(t, base, edited, "every schema the document names is part of the key")
+
+	require.NoError(t, os.WriteFile(catalog, []byte(`<catalog xmlns="urn:oasis:names:tc:entity:xmlns:xml:catalog"><uri name="urn:z" uri="z.xsd"/></catalog>`), 0600))
+	require.NotEqual(t, edited, Init(WithResultCache(rc)).resultCacheKey(content, f), "the catalog is part of the key")
+}
+
+func Test_CLIBaseline(t *testing.T) {
+	dir := t.TempDir()
+	bad := testhelper.WriteFile(t, dir, "bad.json", testhelper.InvalidContent["json"])
+	files := staticFinder{{Name: "bad.json", Path: bad, FileType: filetype.JSONFileType}}
+	path := filepath.Join(dir, "baseline.json")
+
+	run := func(update bool) (int, []reporter.Report) {
+		b, err := baseline.Load(path)
+		require.NoError(t, err)
+		rep := &captureReporter{}
+		exitStatus, err := Init(WithFinder(files), WithReporters(rep), WithBaseline(b), WithUpdateBaseline(update)).Run()
+		require.NoError(t, err)
+		return exitStatus, rep.reports
+	}
+
+	exitStatus, _ := run(false)
+	require.Equal(t, 1, exitStatus, "an empty baseline suppresses nothing")
+
+	exitStatus, reports := run(true)
+	require.Equal(t, 0, exitStatus)
+	require.True(t, reports[0].IsValid)
+	require.FileExists(t, path)
+
+	exitStatus, _ = run(false)
+	require.Equal(t, 0, exitStatus, "known failures are suppressed")
+
+	require.NoError(t, os.WriteFile(bad, []byte(testhelper.ValidContent["json"]), 0600))
+	exitStatus, reports = run(false)
+	require.Equal(t, 0, exitStatus)
+	require.Len(t, reports[0].Warnings, 1)
+	require.Contains(t, reports[0].Warnings[0], "stale baseline entry")
+}
+
+func Test_CLIFailOn(t *testing.T) {
+	dir := t.TempDir()
+	warn := testhelper.WriteFile(t, dir, "justfile", "set foo := true\n\ndefault:\n    echo hi\n")
+	bad := testhelper.WriteFile(t, dir, "bad.json", testhelper.InvalidContent["json"])
+	warnOnly := staticFinder{{Name: "justfile", Path: warn, FileType: filetype.JustfileFileType}}
+	withError := append(staticFinder{{Name: "bad.json", Path: bad, FileType: filetype.JSONFileType}}, warnOnly...)
+
+	cases := []struct {
+		name   string
+		files  staticFinder
+		failOn FailOn
+		want   int
+	}{
+		{"default ignores warnings", warnOnly, "", 0},
+		{"error ignores warnings", warnOnly, FailOnError, 0},
+		{"warning fails on warnings", warnOnly, FailOnWarning, 1},
+		{"warning fails on errors", withError, FailOnWarning, 1},
+		{"error fails on errors", withError, FailOnError, 1},
+		{"never", withError, FailOnNever, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rep := &captureReporter{}
+			exitStatus, err := Init(WithFinder(tc.files), WithReporters(rep), WithFailOn(tc.failOn)).Run()
+			require.NoError(t, err)
+			require.Equal(t, tc.want, exitStatus)
+		})
+	}
+
+	rep := &captureReporter{}
+	_, err := Init(WithFinder(warnOnly), WithReporters(rep)).Run()
+	require.NoError(t, err)
+	require.True(t, rep.reports[0].IsValid)
+	require.Equal(t, []string{"syntax: line 1, column 1: unknown setting 'foo'"}, rep.reports[0].ValidationErrors)
+	require.Equal(t, []reporter.Severity{reporter.SeverityWarning}, rep.reports[0].ErrorSeverities)
+}
+
+func Test_CLIRules(t *testing.T) {
+	dir := t.TempDir()
+	bad := testhelper.WriteFile(t, dir, "bad.json", testhelper.InvalidContent["json"])
+	dup := testhelper.WriteFile(t, dir, "dup.json", `{"a": 1, "a": 2}`)
+	warn := testhelper.WriteFile(t, dir, "justfile", "set foo := true\n\ndefault:\n    echo hi\n")
+	files := staticFinder{
+		{Name: "bad.json", Path: bad, FileType: filetype.JSONFileType},
+		{Name: "dup.json", Path: dup, FileType: filetype.FileType{
+			Name: "json", Validator: validator.JSONValidator{ForbidDuplicateKeys: true},
+		}},
+		{Name: "justfile", Path: warn, FileType: filetype.JustfileFileType},
+	}
+
+	rep := &captureReporter{}
+	_, err := Init(WithFinder(files), WithReporters(rep)).Run()
+	require.NoError(t, err)
+	require.Equal(t, []string{"json/syntax"}, rep.reports[0].ErrorRules)
+	require.Equal(t, []string{validator.RuleJSONDuplicateKey}, rep.reports[1].ErrorRules)
+	require.Equal(t, []string{"justfile/unknown-setting"}, rep.reports[2].ErrorRules)
+
+	rep = &captureReporter{}
+	exitStatus, err := Init(WithFinder(files), WithReporters(rep),
+		WithDisabledRules([]string{"json/duplicate-key", "justfile/*"})).Run()
+	require.NoError(t, err)
+	require.Equal(t, 1, exitStatus)
+	require.False(t, rep.reports[0].IsValid)
+	require.True(t, rep.reports[1].IsValid)
+	require.Empty(t, rep.reports[1].ValidationErrors)
+	require.Empty(t, rep.reports[2].ValidationErrors)
+}
+
 func Test_CLISingleGroupJSON(t *testing.T) {
 	file := testhelper.CreateFixtureFile(t, "json")
 
@@ -444,6 +825,37 @@ func Test_CLISchemaMapTOML(t *testing.T) {
 	require.Equal(t, 0, exitStatus)
 }
 
+func Test_CLISchemaMapCUE(t *testing.T) {
+	dir := t.TempDir()
+	testhelper.WriteFile(t, dir, "app.cue", "name: \"api\"\nport: 0\n")
+	testhelper.WriteFile(t, dir, "app.yaml", "name: api\nport: 0\n")
+	schema := testhelper.WriteFile(t, dir, "schema.cue", "#App: {\n\tname: string\n\tport: int & >0\n}\n")
+
+	fsFinder := finder.FileSystemFinderInit(
+		finder.WithPathRoots(dir+"/app.cue", dir+"/app.yaml"),
+	)
+	capturingReporter := &captureReporter{}
+	cli := Init(
+		WithFinder(fsFinder),
+		WithReporters(capturingReporter),
+		WithSchemaMap(map[string]string{"**/app.*": schema + "#App"}),
+	)
+	exitStatus, err := cli.Run()
+	require.NoError(t, err)
+	require.Equal(t, 1, exitStatus)
+	require.Len(t, capturingReporter.reports, 2)
+	for _, report := range capturingReporter.reports {
+		require.False(t, report.IsValid)
+		require.Equal(t, "schema", report.ErrorType)
+		require.Equal(t, []string{validator.RuleCUESchema}, report.ErrorRules)
+		if report.FileName == "app.cue" {
+			require.Equal(t, []string{"schema: line 2, column 7: port: invalid value 0 (out of bound >0)"}, report.ValidationErrors)
+		} else {
+			require.Equal(t, []string{"schema: line 2, column 1: port: invalid value 0 (out of bound >0)"}, report.ValidationErrors)
+		}
+	}
+}
+
 func Test_CLISchemaMapUnmatched(t *testing.T) {
 	// File doesn't match schema-map pattern — passes syntax-only
 	file := testhelper.CreateFixtureFile(t, "json")
@@ -462,17 +874,17 @@ func Test_CLISchemaMapUnmatched(t *testing.T) {
 
 func Test_CLISchemaMapUnsupportedValidatorWarnsAndPasses(t *testing.T) {
 	dir := t.TempDir()
-	envFile := testhelper.WriteFile(t, dir, ".env", "KEY=VALUE\n")
+	csvFile := testhelper.WriteFile(t, dir, "data.csv", "a,b\n1,2\n")
 	schema := testhelper.WriteFile(t, dir, "schema.json", `{"type": "object"}`)
 
 	fsFinder := finder.FileSystemFinderInit(
-		finder.WithPathRoots(envFile),
+		finder.WithPathRoots(csvFile),
 	)
 	capturingReporter := &captureReporter{}
 	cli := Init(
 		WithFinder(fsFinder),
 		WithReporters(capturingReporter),
-		WithSchemaMap(map[string]string{".env": schema}),
+		WithSchemaMap(map[string]string{"data.csv": schema}),
 	)
 	exitStatus, err := cli.Run()
 	require.NoError(t, err)
@@ -486,17 +898,17 @@ func Test_CLISchemaMapUnsupportedValidatorWarnsAndPasses(t *testing.T) {
 
 func Test_CLISchemaMapUnsupportedValidatorFailsWithRequireSchema(t *testing.T) {
 	dir := t.TempDir()
-	envFile := testhelper.WriteFile(t, dir, ".env", "KEY=VALUE\n")
+	csvFile := testhelper.WriteFile(t, dir, "data.csv", "a,b\n1,2\n")
 	schema := testhelper.WriteFile(t, dir, "schema.json", `{"type": "object"}`)
 
 	fsFinder := finder.FileSystemFinderInit(
-		finder.WithPathRoots(envFile),
+		finder.WithPathRoots(csvFile),
 	)
 	capturingReporter := &captureReporter{}
 	cli := Init(
 		WithFinder(fsFinder),
 		WithReporters(capturingReporter),
-		WithSchemaMap(map[string]string{".env": schema}),
+		WithSchemaMap(map[string]string{"data.csv": schema}),
 		WithRequireSchema(true),
 	)
 	exitStatus, err := cli.Run()
@@ -841,8 +1253,9 @@ func Test_formatErrorsSchemaWithPositions(t *testing.T) {
 			{Line: 3, Column: 5},
 			{Line: 0, Column: 0},
 		},
+		Rules: []string{"schema/type"},
 	}
-	errs, lines, cols := formatErrors(se, 0, 0)
+	errs, lines, cols, rules := formatErrors(se, 0, 0, validator.RuleSchemaInvalid)
 	require.Len(t, errs, 2)
 	require.Contains(t, errs[0], "line 3, column 5")
 	require.Contains(t, errs[1], "schema: ")
@@ -850,6 +1263,7 @@ func Test_formatErrorsSchemaWithPositions(t *testing.T) {
 	require.Equal(t, 3, lines[0])
 	require.Equal(t, 0, lines[1])
 	require.Equal(t, 5, cols[0])
+	require.Equal(t, []string{"schema/type", validator.RuleSchemaInvalid}, rules)
 }
 
 func Test_formatErrorsSchemaLineOnly(t *testing.T) {
@@ -861,7 +1275,7 @@ func Test_formatErrorsSchemaLineOnly(t *testing.T) {
 			{Line: 7, Column: 0},
 		},
 	}
-	errs, lines, _ := formatErrors(se, 0, 0)
+	errs, lines, _, _ := formatErrors(se, 0, 0, validator.RuleSchemaInvalid)
 	require.Len(t, errs, 1)
 	require.Contains(t, errs[0], "line 7:")
 	require.NotContains(t, errs[0], "column")
@@ -870,10 +1284,64 @@ func Test_formatErrorsSchemaLineOnly(t *testing.T) {
 
 func Test_formatErrorsNil(t *testing.T) {
 	t.Parallel()
-	errs, lines, cols := formatErrors(nil, 0, 0)
+	errs, lines, cols, rules := formatErrors(nil, 0, 0, "")
 	require.Nil(t, errs)
 	require.Nil(t, lines)
 	require.Nil(t, cols)
+	require.Nil(t, rules)
+}
+
+func Test_formatErrorsRule(t *testing.T) {
+	t.Parallel()
+	_, _, _, rules := formatErrors(errors.New("bad"), 1, 2, "json/syntax")
+	require.Equal(t, []string{"json/syntax"}, rules)
+
+	ve := &validator.ValidationError{Err: errors.New(`duplicate key "a"`), Rule: validator.RuleJSONDuplicateKey}
+	_, _, _, rules = formatErrors(ve, 0, 0, "json/syntax")
+	require.Equal(t, []string{validator.RuleJSONDuplicateKey}, rules)
+}
+
+func Test_formatErrorsSyntaxErrors(t *testing.T) {
+	t.Parallel()
+	se := &validator.SyntaxErrors{Items: []*validator.ValidationError{
+		{Err: errors.New("missing ','"), Line: 1, Column: 7},
+		{Err: errors.New("undefined recipe"), Line: 4, Rule: "justfile/undefined-recipe"},
+	}}
+	errs, lines, cols, rules := formatErrors(se, 1, 7, "cue/syntax")
+	require.Equal(t, []string{
+		"syntax: line 1, column 7: missing ','",
+		"syntax: line 4: undefined recipe",
+	}, errs)
+	require.Equal(t, []int{1, 4}, lines)
+	require.Equal(t, []int{7, 0}, cols)
+	require.Equal(t, []string{"cue/syntax", "justfile/undefined-recipe"}, rules)
+}
+
+func Test_CLIMultipleSyntaxErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := testhelper.WriteFile(t, dir, "bad.env", "A=1\nB C\nD='x\n")
+	files := staticFinder{{Name: "bad.env", Path: path, FileType: filetype.EnvFileType}}
+
+	rep := &captureReporter{}
+	exitStatus, err := Init(WithFinder(files), WithReporters(rep)).Run()
+	require.NoError(t, err)
+	require.Equal(t, 1, exitStatus)
+	require.Equal(t, []string{"syntax: line 2: missing =", "syntax: line 3: unmatched '"}, rep.reports[0].ValidationErrors)
+	require.Equal(t, []int{2, 3}, rep.reports[0].ErrorLines)
+	require.Equal(t, 2, rep.reports[0].StartLine)
+}
+
+func Test_CLIValidateContent(t *testing.T) {
+	dir := t.TempDir()
+	schema := testhelper.WriteFile(t, dir, "schema.json", `{"type": "object", "required": ["name"]}`)
+	rep := &captureReporter{}
+	c := Init(WithSchemaMap(map[string]string{"**/config.json": schema}), WithReporters(rep))
+
+	report := c.ValidateContent([]byte(`{}`), filetype.JSONFileType, filepath.Join(dir, "config.json"))
+	require.False(t, report.IsValid)
+	require.Equal(t, "config.json", report.FileName)
+	require.Equal(t, []string{"schema/required"}, report.ErrorRules)
+	require.Empty(t, rep.reports)
 }
 
 func Test_CLINoJSONCNoteOnYAML(t *testing.T) {
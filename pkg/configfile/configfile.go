@@ -23,6 +23,12 @@ type Config struct {
 	IgnoreFiles      []string          `toml:"ignore-files"`
 	FileTypes        []string          `toml:"file-types"`
 	Depth            *int              `toml:"depth"`
+	Jobs             *int              `toml:"jobs"`
+	Cache            *bool             `toml:"cache"`
+	CacheDir         *string           `toml:"cache-dir"`
+	Baseline         *string           `toml:"baseline"`
+	FailOn           *string           `toml:"fail-on"`
+	DisableRules     []string          `toml:"disable-rules"`
 	Reporter         []string          `toml:"reporter"`
 	GroupBy          []string          `toml:"groupby"`
 	Quiet            *bool             `toml:"quiet"`
@@ -32,6 +38,10 @@ type Config struct {
 	SchemaStorePath  *string           `toml:"schemastore-path"`
 	Globbing         *bool             `toml:"globbing"`
 	Gitignore        *bool             `toml:"gitignore"`
+	Archives         *bool             `toml:"archives"`
+	ArchiveMemberMB  *int              `toml:"archive-member-mb"`
+	Sniff            *bool             `toml:"sniff"`
+	TypeHints        *bool             `toml:"type-hints"`
 	SchemaMap        map[string]string `toml:"schema-map"`
 	TypeMap          map[string]string `toml:"type-map"`
 	Validators       ValidatorOptions  `toml:"validators"`
@@ -39,9 +49,11 @@ type Config struct {
 
 // ValidatorOptions holds per-validator configuration.
 type ValidatorOptions struct {
-	CSV  *CSVOptions  `toml:"csv"`
-	JSON *JSONOptions `toml:"json"`
-	INI  *INIOptions  `toml:"ini"`
+	CSV        *CSVOptions        `toml:"csv"`
+	JSON       *JSONOptions       `toml:"json"`
+	INI        *INIOptions        `toml:"ini"`
+	Properties *PropertiesOptions `toml:"properties"`
+	XML        *XMLOptions        `toml:"xml"`
 }
 
 // CSVOptions configures the CSV validator.
@@ -61,24 +73,50 @@ type INIOptions struct {
 	ForbidDuplicateKeys *bool `toml:"forbid-duplicate-keys"`
 }
 
+// PropertiesOptions configures the properties validator.
+type PropertiesOptions struct {
+	NestedKeys *bool `toml:"nested-keys"`
+}
+
+// XMLOptions configures the XML validator.
+type XMLOptions struct {
+	// Catalog is the path of an OASIS XML catalog. In a nested
+	// configuration file it is relative to the file's directory.
+	Catalog *string `toml:"catalog"`
+	// DTD validates documents against the external DTD their DOCTYPE
+	// names.
+	DTD *bool `toml:"dtd"`
+	// ExternalEntities allows remote DTDs and external entities to be
+	// loaded.
+	ExternalEntities *bool `toml:"external-entities"`
+	// RemoteSchemas allows remote XML Schemas to be downloaded.
+	RemoteSchemas *bool `toml:"remote-schemas"`
+}
+
 // Load reads and validates a .cfv.toml file at the given path.
 // It validates TOML syntax first, then validates against the embedded schema.
 func Load(path string) (*Config, error) {
+	cfg, _, err := load(path)
+	return cfg, err
+}
+
+// load is Load that also returns the keys the file sets.
+func load(path string) (*Config, map[string]any, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("reading config file: %w", err)
+		return nil, nil, fmt.Errorf("reading config file: %w", err)
 	}
 
 	// Validate TOML syntax
-	var raw any
+	raw := map[string]any{}
 	if err := toml.Unmarshal(data, &raw); err != nil {
-		return nil, fmt.Errorf("config file %s: invalid TOML syntax: %w", path, err)
+		return nil, nil, fmt.Errorf("config file %s: invalid TOML syntax: %w", path, err)
 	}
 
 	// Convert to JSON for schema validation
 	docJSON, err := json.Marshal(raw)
 	if err != nil {
-		return nil, fmt.Errorf("config file %s: %w", path, err)
+		return nil, nil, fmt.Errorf("config file %s: %w", path, err)
 	}
 
 	// Validate against embedded schema
@@ -87,23 +125,23 @@ func Load(path string) (*Config, error) {
 		gojsonschema.NewBytesLoader(docJSON),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("config file %s: schema validation error: %w", path, err)
+		return nil, nil, fmt.Errorf("config file %s: schema validation error: %w", path, err)
 	}
 	if !result.Valid() {
 		var errs []string
 		for _, desc := range result.Errors() {
 			errs = append(errs, desc.String())
 		}
-		return nil, fmt.Errorf("config file %s: schema validation failed: %s", path, joinErrors(errs))
+		return nil, nil, fmt.Errorf("config file %s: schema validation failed: %s", path, joinErrors(errs))
 	}
 
 	// Parse into Config struct
 	var cfg Config
 	if err := toml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("config file %s: %w", path, err)
+		return nil, nil, fmt.Errorf("config file %s: %w", path, err)
 	}
 
-	return &cfg, nil
+	return &cfg, raw, nil
 }
 
 // Discover walks up from startDir looking for a .cfv.toml file.
@@ -100,6 +100,37 @@ func TestLoadDepthNegative(t *testing.T) {
 	require.Contains(t, err.Error(), "schema validation failed")
 }
 
+func TestLoadJobs(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeConfig(t, dir, `jobs = 8`)
+
+	cfg, err := Load(filepath.Join(dir, FileName))
+	require.NoError(t, err)
+	require.Equal(t, 8, *cfg.Jobs)
+}
+
+func TestLoadJobsNegative(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeConfig(t, dir, `jobs = -1`)
+
+	_, err := Load(filepath.Join(dir, FileName))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "schema validation failed")
+}
+
+func TestLoadCache(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeConfig(t, dir, "cache = true\ncache-dir = \".cfv-cache\"")
+
+	cfg, err := Load(filepath.Join(dir, FileName))
+	require.NoError(t, err)
+	require.True(t, *cfg.Cache)
+	require.Equal(t, ".cfv-cache", *cfg.CacheDir)
+}
+
 func TestLoadInvalidGroupBy(t *testing.T) {
 	t.Parallel()
 	dir := t.TempDir()
@@ -0,0 +1,282 @@
+package configfile
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// nestedKeys are the keys a nested configuration file may set. The other
+// keys configure a whole run and belong in the top-level file.
+var nestedKeys = map[string]struct{}{
+	"exclude-dirs":       {},
+	"exclude-file-types": {},
+	"schema-map":         {},
+	"type-map":           {},
+	"validators":         {},
+}
+
+// LoadNested reads a .cfv.toml file below the top-level one. Such a file
+// configures its directory tree and may only set exclude-dirs,
+// exclude-file-types, schema-map, type-map and validators.
+func LoadNested(path string) (*Config, error) {
+	cfg, raw, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range slices.Sorted(maps.Keys(raw)) {
+		if _, ok := nestedKeys[key]; !ok {
+			return nil, fmt.Errorf("config file %s: %q can only be set in the top-level configuration file", path, key)
+		}
+	}
+	return cfg, nil
+}
+
+// Scope is a nested configuration file and the nested files in the
+// directories above it.
+type Scope struct {
+	// Dir is the absolute directory of the configuration file.
+	Dir    string
+	Config *Config
+	// Parent is the scope of the closest nested file above Dir, or nil.
+	Parent *Scope
+}
+
+// Mapping is a schema-map or type-map entry of a nested configuration
+// file.
+type Mapping struct {
+	// Dir is the directory of the file that sets the entry. Glob patterns
+	// match paths relative to it.
+	Dir     string
+	Pattern string
+	// Value is a file type name, or a schema path that is absolute or a
+	// URL.
+	Value string
+}
+
+// Effective is the configuration a scope puts in effect in its directory
+// tree, on top of the top-level configuration.
+type Effective struct {
+	// ExcludeDirs and ExcludeFileTypes add up those of every file in the
+	// scope.
+	ExcludeDirs      []string
+	ExcludeFileTypes []string
+	// TypeMap and SchemaMap hold the entries of the closest file first, so
+	// that the first match wins.
+	TypeMap   []Mapping
+	SchemaMap []Mapping
+	// Validators holds each option from the closest file that sets it.
+	Validators ValidatorOptions
+}
+
+// Effective merges the files of the scope, from the outermost to s.
+func (s *Scope) Effective() Effective {
+	var chain []*Scope
+	for scope := s; scope != nil; scope = scope.Parent {
+		chain = append(chain, scope)
+	}
+
+	var eff Effective
+	for i := len(chain) - 1; i >= 0; i-- {
+		cfg := chain[i].Config
+		eff.ExcludeDirs = append(eff.ExcludeDirs, cfg.ExcludeDirs...)
+		eff.ExcludeFileTypes = append(eff.ExcludeFileTypes, cfg.ExcludeFileTypes...)
+		eff.Validators = MergeValidatorOptions(eff.Validators, resolveValidatorPaths(chain[i].Dir, cfg.Validators))
+	}
+	for _, scope := range chain {
+		cfg := scope.Config
+		for _, pattern := range slices.Sorted(maps.Keys(cfg.TypeMap)) {
+			eff.TypeMap = append(eff.TypeMap, Mapping{Dir: scope.Dir, Pattern: pattern, Value: cfg.TypeMap[pattern]})
+		}
+		for _, pattern := range slices.Sorted(maps.Keys(cfg.SchemaMap)) {
+			eff.SchemaMap = append(eff.SchemaMap, Mapping{Dir: scope.Dir, Pattern: pattern, Value: resolveSchemaPath(scope.Dir, cfg.SchemaMap[pattern])})
+		}
+	}
+	return eff
+}
+
+// resolveSchemaPath resolves a relative schema path against dir.
+func resolveSchemaPath(dir, schema string) string {
+	if strings.HasPrefix(schema, "https://") || strings.HasPrefix(schema, "http://") || filepath.IsAbs(schema) {
+		return schema
+	}
+	return filepath.Join(dir, filepath.FromSlash(schema))
+}
+
+// resolveValidatorPaths resolves the relative paths among a nested file's
+// validator options against dir.
+func resolveValidatorPaths(dir string, opts ValidatorOptions) ValidatorOptions {
+	if opts.XML != nil && opts.XML.Catalog != nil {
+		xml := *opts.XML
+		catalog := resolveSchemaPath(dir, *xml.Catalog)
+		xml.Catalog = &catalog
+		opts.XML = &xml
+	}
+	return opts
+}
+
+// MergeValidatorOptions returns base with every option override sets
+// replaced.
+func MergeValidatorOptions(base, override ValidatorOptions) ValidatorOptions {
+	merged := base
+	if o := override.CSV; o != nil {
+		csv := CSVOptions{}
+		if base.CSV != nil {
+			csv = *base.CSV
+		}
+		if o.Delimiter != nil {
+			csv.Delimiter = o.Delimiter
+		}
+		if o.Comment != nil {
+			csv.Comment = o.Comment
+		}
+		if o.LazyQuotes != nil {
+			csv.LazyQuotes = o.LazyQuotes
+		}
+		merged.CSV = &csv
+	}
+	if o := override.JSON; o != nil && o.ForbidDuplicateKeys != nil {
+		merged.JSON = &JSONOptions{ForbidDuplicateKeys: o.ForbidDuplicateKeys}
+	}
+	if o := override.INI; o != nil && o.ForbidDuplicateKeys != nil {
+		merged.INI = &INIOptions{ForbidDuplicateKeys: o.ForbidDuplicateKeys}
+	}
+	if o := override.Properties; o != nil && o.NestedKeys != nil {
+		merged.Properties = &PropertiesOptions{NestedKeys: o.NestedKeys}
+	}
+	if o := override.XML; o != nil {
+		xml := XMLOptions{}
+		if base.XML != nil {
+			xml = *base.XML
+		}
+		if o.Catalog != nil {
+			xml.Catalog = o.Catalog
+		}
+		if o.DTD != nil {
+			xml.DTD = o.DTD
+		}
+		if o.ExternalEntities != nil {
+			xml.ExternalEntities = o.ExternalEntities
+		}
+		if o.RemoteSchemas != nil {
+			xml.RemoteSchemas = o.RemoteSchemas
+		}
+		merged.XML = &xml
+	}
+	return merged
+}
+
+// Tree finds the nested configuration files that apply to directories. It
+// caches what it finds and is safe for concurrent use.
+type Tree struct {
+	top   string
+	roots []treeRoot
+
+	mu     sync.Mutex
+	scopes map[treeKey]*Scope
+}
+
+// treeRoot is a search root and the directory of the first configuration
+// file at or above it, which is top-level for the root.
+type treeRoot struct {
+	dir      string
+	boundary string
+}
+
+type treeKey struct {
+	boundary string
+	dir      string
+}
+
+// NewTree returns a Tree for the given search roots. For directories below
+// a root, nested files are looked for up to, but not including, the
+// directory of the top-level configuration file: the one discovered from
+// the current directory when it is at or above the root, as it is even
+// when top replaces it, or else the first one at or above the root. Other
+// directories are looked at up to the file system root. top is the
+// top-level configuration file in effect, which is never treated as
+// nested.
+func NewTree(top string, roots ...string) *Tree {
+	t := &Tree{scopes: make(map[treeKey]*Scope)}
+	if top != "" {
+		t.top, _ = filepath.Abs(top)
+	}
+	home := ""
+	if discovered := Discover("."); discovered != "" {
+		home = filepath.Dir(discovered)
+	}
+	for _, root := range roots {
+		dir, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		boundary := home
+		if home == "" || !within(home, dir) {
+			boundary = ""
+			if discovered := Discover(dir); discovered != "" {
+				boundary = filepath.Dir(discovered)
+			}
+		}
+		t.roots = append(t.roots, treeRoot{dir: dir, boundary: boundary})
+	}
+	return t
+}
+
+// within reports whether path is dir or below it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// ScopeFor returns the scope of the closest nested file in dir or above
+// it, or nil when there is none.
+func (t *Tree) ScopeFor(dir string) (*Scope, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.scopeFor(t.boundaryFor(dir), dir)
+}
+
+// boundaryFor returns the boundary of the innermost root holding dir.
+func (t *Tree) boundaryFor(dir string) string {
+	boundary, depth := "", -1
+	for _, root := range t.roots {
+		if within(root.dir, dir) && len(root.dir) > depth {
+			boundary, depth = root.boundary, len(root.dir)
+		}
+	}
+	return boundary
+}
+
+func (t *Tree) scopeFor(boundary, dir string) (*Scope, error) {
+	key := treeKey{boundary: boundary, dir: dir}
+	if scope, ok := t.scopes[key]; ok {
+		return scope, nil
+	}
+	parent := filepath.Dir(dir)
+	if dir == boundary || parent == dir {
+		return nil, nil
+	}
+	outer, err := t.scopeFor(boundary, parent)
+	if err != nil {
+		return nil, err
+	}
+	scope := outer
+	path := filepath.Join(dir, FileName)
+	if _, statErr := os.Stat(path); statErr == nil && path != t.top {
+		cfg, err := LoadNested(path)
+		if err != nil {
+			return nil, err
+		}
+		scope = &Scope{Dir: dir, Config: cfg, Parent: outer}
+	}
+	t.scopes[key] = scope
+	return scope, nil
+}
@@ -0,0 +1,134 @@
+package configfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadNestedRejectsRunKeys(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeConfig(t, dir, `
+exclude-dirs = ["gen"]
+quiet = true
+`)
+
+	_, err := LoadNested(filepath.Join(dir, FileName))
+	require.ErrorContains(t, err, `"quiet" can only be set in the top-level configuration file`)
+}
+
+func TestTreeScopes(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	app := filepath.Join(root, "app")
+	api := filepath.Join(app, "api")
+	require.NoError(t, os.MkdirAll(filepath.Join(api, "v1"), 0o755))
+	writeConfig(t, root, `quiet = true`)
+	writeConfig(t, app, `
+exclude-dirs = ["gen"]
+
+[schema-map]
+"*.json" = "schemas/app.json"
+"https.json" = "https://example.com/schema.json"
+
+[type-map]
+"**/*.cfg" = "ini"
+
+[validators.csv]
+delimiter = ";"
+comment = "#"
+
+[validators.xml]
+catalog = "xml/catalog.xml"
+dtd = true
+`)
+	writeConfig(t, api, `
+exclude-dirs = ["tmp"]
+
+[schema-map]
+"*.json" = "../shared/api.json"
+
+[validators.csv]
+delimiter = "|"
+
+[validators.xml]
+external-entities = true
+remote-schemas = true
+`)
+
+	tree := NewTree(filepath.Join(root, FileName), root)
+	scope, err := tree.ScopeFor(root)
+	require.NoError(t, err)
+	require.Nil(t, scope)
+
+	scope, err = tree.ScopeFor(filepath.Join(api, "v1"))
+	require.NoError(t, err)
+	require.Equal(t, api, scope.Dir)
+	require.Equal(t, app, scope.Parent.Dir)
+
+	eff := scope.Effective()
+	require.Equal(t, []string{"gen", "tmp"}, eff.ExcludeDirs)
+	require.Equal(t, []Mapping{
+		{Dir: api, Pattern: "*.json", Value: filepath.Join(app, "shared", "api.json")},
+		{Dir: app, Pattern: "*.json", Value: filepath.Join(app, "schemas", "app.json")},
+		{Dir: app, Pattern: "https.json", Value: "https://example.com/schema.json"},
+	}, eff.SchemaMap)
+	require.Equal(t, []Mapping{{Dir: app, Pattern: "**/*.cfg", Value: "ini"}}, eff.TypeMap)
+	require.Equal(t, "|", *eff.Validators.CSV.Delimiter)
+	require.Equal(t, "#", *eff.Validators.CSV.Comment)
+	require.Equal(t, filepath.Join(app, "xml", "catalog.xml"), *eff.Validators.XML.Catalog)
+	require.True(t, *eff.Validators.XML.DTD)
+	require.True(t, *eff.Validators.XML.ExternalEntities)
+	require.True(t, *eff.Validators.XML.RemoteSchemas)
+}
+
+func TestTreeRejectsInvalidNestedFile(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	writeConfig(t, root, `depth = 1`)
+
+	_, err := NewTree("").ScopeFor(root)
+	require.ErrorContains(t, err, `"depth" can only be set`)
+}
+
+func TestTreeRootBoundaries(t *testing.T) {
+	t.Chdir(t.TempDir())
+	other := t.TempDir()
+	writeConfig(t, other, `jobs = 2`)
+	nested := filepath.Join(other, "app")
+	require.NoError(t, os.Mkdir(nested, 0755))
+	writeConfig(t, nested, `exclude-dirs = ["gen"]`)
+
+	tree := NewTree("", other)
+	scope, err := tree.ScopeFor(other)
+	require.NoError(t, err, "the first file at or above a root is top-level")
+	require.Nil(t, scope)
+	scope, err = tree.ScopeFor(nested)
+	require.NoError(t, err)
+	require.Equal(t, nested, scope.Dir)
+	require.Nil(t, scope.Parent)
+}
+
+func TestMergeValidatorOptions(t *testing.T) {
+	t.Parallel()
+	yes, no := true, false
+	tab := "\t"
+	base := ValidatorOptions{
+		CSV:  &CSVOptions{Delimiter: &tab, LazyQuotes: &yes},
+		JSON: &JSONOptions{ForbidDuplicateKeys: &yes},
+	}
+
+	merged := MergeValidatorOptions(base, ValidatorOptions{
+		CSV:  &CSVOptions{LazyQuotes: &no},
+		JSON: &JSONOptions{},
+		INI:  &INIOptions{ForbidDuplicateKeys: &yes},
+	})
+	require.Equal(t, "\t", *merged.CSV.Delimiter)
+	require.False(t, *merged.CSV.LazyQuotes)
+	require.True(t, *merged.JSON.ForbidDuplicateKeys)
+	require.True(t, *merged.INI.ForbidDuplicateKeys)
+	require.True(t, *base.CSV.LazyQuotes, "base is not modified")
+}
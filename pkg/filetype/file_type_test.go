@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/validator"
 )
 
 func TestLinguistKnownFilesPopulated(t *testing.T) {
@@ -61,3 +63,11 @@ func TestEditorConfigNotInINIKnownFiles(t *testing.T) {
 	_, ok := IniFileType.KnownFiles[".editorconfig"]
 	require.False(t, ok, ".editorconfig should NOT be in INI KnownFiles")
 }
+
+func TestFileTypesHaveSyntaxRules(t *testing.T) {
+	t.Parallel()
+	for _, ft := range FileTypes {
+		_, ok := validator.LookupRule(validator.SyntaxRule(ft.Name))
+		require.True(t, ok, "no syntax rule in the catalogue for %s", ft.Name)
+	}
+}
@@ -0,0 +1,146 @@
+package filetype
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// typeHintLines is how many leading lines of a file are searched for a
+// type hint.
+const typeHintLines = 5
+
+// Sources of a TypeHint.
+const (
+	HintDirective = "cfv directive"
+	HintModeline  = "modeline"
+	HintShebang   = "shebang"
+)
+
+// TypeHint is a file type a file declares for itself.
+type TypeHint struct {
+	// Name is the type as written in the file, such as "yml" or "yaml".
+	Name string
+	// Source is HintDirective, HintModeline or HintShebang.
+	Source string
+}
+
+var (
+	directiveRe   = regexp.MustCompile(`\bcfv:\s*type=([\w.+-]+)`)
+	vimModelineRe = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex)(?:[<=>]?\d+)?:(.*)`)
+	vimTypeRe     = regexp.MustCompile(`(?:^|[\s:])(?:ft|filetype|syn|syntax)=([\w.+-]+)`)
+	emacsLineRe   = regexp.MustCompile(`-\*-(.*?)-\*-`)
+	emacsModeRe   = regexp.MustCompile(`(?:^|;)\s*mode:\s*([\w.+-]+)`)
+)
+
+// hintAliases maps the names editors use for a type to the validator's.
+var hintAliases = map[string]string{
+	"yml":           "yaml",
+	"dosini":        "ini",
+	"confini":       "ini",
+	"conf-windows":  "ini",
+	"jproperties":   "properties",
+	"conf-javaprop": "properties",
+	"conf-toml":     "toml",
+	"terraform":     "hcl",
+	"nxml":          "xml",
+	"js-json":       "json",
+	"dotenv":        "env",
+	"just":          "justfile",
+}
+
+// shebangTypes maps interpreters to the file type of the scripts they run.
+var shebangTypes = map[string]string{
+	"just": "justfile",
+}
+
+// FindTypeHint returns the type hint in the first lines of head: a
+// "cfv: type=<type>" directive, a Vim ("vim: ft=yaml") or Emacs
+// ("-*- mode: json -*-") modeline, or a shebang whose interpreter runs one
+// file type, such as "#!/usr/bin/env just --justfile". A directive wins
+// over a modeline, which wins over a shebang.
+func FindTypeHint(head []byte) (TypeHint, bool) {
+	var modeline, shebang string
+	n := 0
+	for line := range bytes.Lines(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))) {
+		if n == typeHintLines {
+			break
+		}
+		text := strings.TrimSpace(string(line))
+		if m := directiveRe.FindStringSubmatch(text); m != nil {
+			return TypeHint{Name: m[1], Source: HintDirective}, true
+		}
+		if modeline == "" {
+			modeline = modelineType(text)
+		}
+		if n == 0 && strings.HasPrefix(text, "#!") {
+			shebang = shebangType(text)
+		}
+		n++
+	}
+	switch {
+	case modeline != "":
+		return TypeHint{Name: modeline, Source: HintModeline}, true
+	case shebang != "":
+		return TypeHint{Name: shebang, Source: HintShebang}, true
+	default:
+		return TypeHint{}, false
+	}
+}
+
+func modelineType(line string) string {
+	if m := vimModelineRe.FindStringSubmatch(line); m != nil {
+		if t := vimTypeRe.FindStringSubmatch(m[1]); t != nil {
+			// Vim joins file types with dots, as in yaml.ansible.
+			name, _, _ := strings.Cut(t[1], ".")
+			return name
+		}
+	}
+	if m := emacsLineRe.FindStringSubmatch(line); m != nil {
+		vars := strings.TrimSpace(m[1])
+		if !strings.Contains(vars, ":") {
+			return strings.TrimSuffix(vars, "-ts")
+		}
+		if t := emacsModeRe.FindStringSubmatch(vars); t != nil {
+			return strings.TrimSuffix(t[1], "-ts")
+		}
+	}
+	return ""
+}
+
+func shebangType(line string) string {
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) > 0 && path.Base(fields[0]) == "env" {
+		fields = fields[1:]
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return shebangTypes[path.Base(fields[0])]
+}
+
+// LookupTypeHint returns the file type among types that the hint names,
+// accepting the names editors use, such as yml, dosini or terraform. ok is
+// false when that type is not among types, and known is false when the
+// validator supports no such type at all.
+func LookupTypeHint(hint TypeHint, types []FileType) (ft FileType, ok, known bool) {
+	name := strings.ToLower(hint.Name)
+	if alias, isAlias := hintAliases[name]; isAlias {
+		name = alias
+	}
+	for _, t := range types {
+		if t.Name == name {
+			return t, true, true
+		}
+	}
+	for _, t := range FileTypes {
+		if t.Name == name {
+			return FileType{}, false, true
+		}
+	}
+	return FileType{}, false, false
+}
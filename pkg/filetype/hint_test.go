@@ -0,0 +1,66 @@
+package filetype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindTypeHint(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		head string
+		want TypeHint
+	}{
+		{"vim ft", "# vim: ft=yaml\na: 1\n", TypeHint{"yaml", HintModeline}},
+		{"vim set", "// vim: set filetype=json ts=2 :\n{}", TypeHint{"json", HintModeline}},
+		{"vim dotted", "# vim: ft=yaml.ansible\n", TypeHint{"yaml", HintModeline}},
+		{"vi syntax", "; vi:syntax=dosini\n", TypeHint{"dosini", HintModeline}},
+		{"emacs mode", "# -*- mode: conf-toml; coding: utf-8 -*-\n", TypeHint{"conf-toml", HintModeline}},
+		{"emacs bare", "// -*- json -*-\n", TypeHint{"json", HintModeline}},
+		{"emacs tree-sitter", "# -*- mode: yaml-ts -*-\n", TypeHint{"yaml", HintModeline}},
+		{"shebang", "#!/usr/bin/env -S just --justfile\nbuild:\n", TypeHint{"justfile", HintShebang}},
+		{"shebang path", "#!/usr/local/bin/just --justfile\n", TypeHint{"justfile", HintShebang}},
+		{"directive", "#!/usr/bin/env just\n# vim: ft=yaml\n# cfv: type=ini\n", TypeHint{"ini", HintDirective}},
+		{"modeline over shebang", "#!/usr/bin/env just\n# vim: ft=make\n", TypeHint{"make", HintModeline}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			hint, ok := FindTypeHint([]byte(tc.head))
+			require.True(t, ok)
+			require.Equal(t, tc.want, hint)
+		})
+	}
+
+	for _, head := range []string{
+		"#!/bin/sh\nexport A=1\n",
+		"a: 1\nb: 2\nc: 3\nd: 4\ne: 5\n# vim: ft=json\n",
+		"see https://example.com/vim:ft=json for details",
+		"",
+	} {
+		_, ok := FindTypeHint([]byte(head))
+		require.False(t, ok, head)
+	}
+}
+
+func TestLookupTypeHint(t *testing.T) {
+	t.Parallel()
+	ft, ok, known := LookupTypeHint(TypeHint{Name: "YML"}, FileTypes)
+	require.True(t, ok)
+	require.True(t, known)
+	require.Equal(t, "yaml", ft.Name)
+
+	ft, ok, _ = LookupTypeHint(TypeHint{Name: "terraform"}, FileTypes)
+	require.True(t, ok)
+	require.Equal(t, "hcl", ft.Name)
+
+	_, ok, known = LookupTypeHint(TypeHint{Name: "yaml"}, []FileType{JSONFileType})
+	require.False(t, ok)
+	require.True(t, known)
+
+	_, ok, known = LookupTypeHint(TypeHint{Name: "python"}, FileTypes)
+	require.False(t, ok)
+	require.False(t, known)
+}
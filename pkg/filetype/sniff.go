@@ -0,0 +1,116 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+)
+
+// Confidence is how certain Sniff is of the type it detected.
+type Confidence int
+
+const (
+	LowConfidence Confidence = iota + 1
+	MediumConfidence
+	HighConfidence
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case LowConfidence:
+		return "low"
+	case MediumConfidence:
+		return "medium"
+	case HighConfidence:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	iniSectionRe = regexp.MustCompile(`^\[[^\[\]"]+\]$`)
+	envLineRe    = regexp.MustCompile(`^(export\s+)?[A-Za-z_][A-Za-z0-9_]*=`)
+	hclBlockRe   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*(\s+("[^"]*"|[A-Za-z_][A-Za-z0-9_-]*))*\s*\{$`)
+)
+
+// Sniff detects the type of a file from head, the first bytes of its
+// content, choosing among types. It recognises an XML prolog or root
+// element, a plist DOCTYPE, a JSON object or array, YAML document
+// markers, INI section headers, HCL blocks and env KEY=VALUE lines. It
+// returns false when head matches none of these or the match is not one of
+// types. Binary content is never matched.
+func Sniff(head []byte, types []FileType) (FileType, Confidence, bool) {
+	name, confidence := sniffName(head)
+	if name == "" {
+		return FileType{}, 0, false
+	}
+	for _, ft := range types {
+		if ft.Name == name {
+			return ft, confidence, true
+		}
+	}
+	return FileType{}, 0, false
+}
+
+func sniffName(head []byte) (string, Confidence) {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return "", 0
+	}
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(head)
+	if len(trimmed) == 0 {
+		return "", 0
+	}
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<!DOCTYPE plist")), bytes.HasPrefix(trimmed, []byte("<plist")):
+		return "plist", HighConfidence
+	case bytes.HasPrefix(trimmed, []byte("<?xml")):
+		if bytes.Contains(trimmed, []byte("<!DOCTYPE plist")) {
+			return "plist", HighConfidence
+		}
+		return "xml", HighConfidence
+	case trimmed[0] == '<':
+		return "xml", MediumConfidence
+	case json.Valid(trimmed):
+		return "json", HighConfidence
+	}
+
+	lines := significantLines(trimmed)
+	if len(lines) == 0 {
+		return "", 0
+	}
+	first := lines[0]
+	switch {
+	case bytes.Equal(first, []byte("---")), bytes.HasPrefix(first, []byte("--- ")), bytes.HasPrefix(first, []byte("%YAML")):
+		return "yaml", HighConfidence
+	case iniSectionRe.Match(first):
+		return "ini", HighConfidence
+	case trimmed[0] == '{' || trimmed[0] == '[':
+		// Likely JSON that is invalid or cut short by the head.
+		return "json", LowConfidence
+	case hclBlockRe.Match(first):
+		return "hcl", MediumConfidence
+	}
+	for _, line := range lines {
+		if !envLineRe.Match(line) {
+			return "", 0
+		}
+	}
+	return "env", MediumConfidence
+}
+
+// significantLines returns the trimmed lines of content that are neither
+// blank nor comments.
+func significantLines(content []byte) [][]byte {
+	var lines [][]byte
+	for line := range bytes.Lines(content) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' || line[0] == ';' || bytes.HasPrefix(line, []byte("//")) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
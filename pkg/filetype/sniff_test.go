@@ -0,0 +1,58 @@
+package filetype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniff(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name       string
+		head       string
+		want       string
+		confidence Confidence
+	}{
+		{"xml prolog", "<?xml version=\"1.0\"?>\n<config/>", "xml", HighConfidence},
+		{"xml root", "\n<config>\n</config>", "xml", MediumConfidence},
+		{"plist doctype", "<?xml version=\"1.0\"?>\n<!DOCTYPE plist PUBLIC \"-//Apple//DTD PLIST 1.0//EN\" \"\">\n<plist/>", "plist", HighConfidence},
+		{"json object", "\xef\xbb\xbf{\"a\": [1, 2]}\n", "json", HighConfidence},
+		{"json array", "[1, 2]", "json", HighConfidence},
+		{"truncated json", "{\n  \"a\": [1,", "json", LowConfidence},
+		{"yaml marker", "# app\n---\na: 1\n", "yaml", HighConfidence},
+		{"yaml directive", "%YAML 1.2\n---\n", "yaml", HighConfidence},
+		{"ini section", "; settings\n[server]\nport = 80\n", "ini", HighConfidence},
+		{"hcl block", "# main\nresource \"aws_s3_bucket\" \"b\" {\n  bucket = \"b\"\n}\n", "hcl", MediumConfidence},
+		{"hcl bare block", "terraform {\n}\n", "hcl", MediumConfidence},
+		{"env", "# env\nexport HOME_DIR=/home\nPORT=80\n\n", "env", MediumConfidence},
+		{"spaced assignments", "port = 80\n", "", 0},
+		{"env with prose", "PORT=80\nnot an assignment\n", "", 0},
+		{"binary", "PORT=80\x00", "", 0},
+		{"blank", " \n\t", "", 0},
+		{"comments only", "# nothing\n", "", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			ft, confidence, ok := Sniff([]byte(tc.head), FileTypes)
+			if tc.want == "" {
+				require.False(t, ok, "sniffed %s", ft.Name)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, tc.want, ft.Name)
+			require.Equal(t, tc.confidence, confidence)
+		})
+	}
+}
+
+func TestSniffLimitedToTypes(t *testing.T) {
+	t.Parallel()
+	_, _, ok := Sniff([]byte(`{"a": 1}`), []FileType{YAMLFileType})
+	require.False(t, ok)
+	ft, _, ok := Sniff([]byte("---\n"), []FileType{YAMLFileType})
+	require.True(t, ok)
+	require.Equal(t, "yaml", ft.Name)
+	require.Equal(t, "high", HighConfidence.String())
+}
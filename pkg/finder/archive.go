@@ -0,0 +1,265 @@
+package finder
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveSeparator separates the path of an archive from the path of a
+// member inside it in FileMetadata.Path, as in
+// "bundle.tgz!/chart/values.yaml".
+const ArchiveSeparator = "!/"
+
+// DefaultMaxArchiveMemberSize is the largest archive member, in bytes, a
+// finder reads unless WithMaxArchiveMemberSize sets another limit.
+const DefaultMaxArchiveMemberSize int64 = 64 << 20
+
+// ErrUnreadableArchive is returned by FileMetadata.ReadFile for an archive
+// the finder could not read, such as a corrupt archive or one with a member
+// over the size limit. The rest of the search is unaffected.
+var ErrUnreadableArchive = errors.New("unreadable archive")
+
+// archiveReadError is an error reading an archive, as opposed to an error
+// selecting its members, such as an unknown type in a directive.
+type archiveReadError struct{ err error }
+
+func (e archiveReadError) Error() string { return e.err.Error() }
+func (e archiveReadError) Unwrap() error { return e.err }
+
+type archiveKind int
+
+const (
+	notArchive archiveKind = iota
+	zipArchive
+	tarArchive
+	tarGzArchive
+)
+
+// archiveKindOf returns the kind of archive the file name denotes by its
+// extension.
+func archiveKindOf(name string) archiveKind {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return tarGzArchive
+	case strings.HasSuffix(lower, ".tar"):
+		return tarArchive
+	case strings.HasSuffix(lower, ".zip"), strings.HasSuffix(lower, ".jar"):
+		return zipArchive
+	default:
+		return notArchive
+	}
+}
+
+// memberAdder returns a function that adds an in-memory file named name,
+// reported as prefix+name, if the finder's file types, type overrides and
+// exclusions select it. open is only called for files typeFile reads or
+// selects, and only selected files are read past their first bytes. A
+// positive limit is the largest file, in bytes, that is read.
+func (fsf *FileSystemFinder) memberAdder(prefix string, limit int64, seenMap map[string]struct{}, matchingFiles *[]FileMetadata) func(name string, open func() (io.ReadCloser, error)) error {
+	members := make(archiveFS)
+	return func(name string, open func() (io.ReadCloser, error)) error {
+		name = path.Clean(strings.TrimPrefix(name, "/"))
+		if name == "." || name == ".." || strings.HasPrefix(name, "../") || fsf.inExcludedDir(name) {
+			return nil
+		}
+		memberPath := prefix + name
+		if _, seen := seenMap[memberPath]; seen {
+			return nil
+		}
+		// Typing and selection share a single pass over the member, which
+		// may be a stream: the head typeFile reads is kept for the rest.
+		var rc io.ReadCloser
+		var head []byte
+		var readErr error
+		readHead := func() ([]byte, error) {
+			if rc == nil && readErr == nil {
+				if rc, readErr = open(); readErr == nil {
+					head, readErr = io.ReadAll(io.LimitReader(rc, sniffHeadSize))
+				}
+			}
+			return head, readErr
+		}
+		defer func() {
+			if rc != nil {
+				rc.Close()
+			}
+		}()
+		fileType, notes, ok, err := fsf.typeFile(memberPath, func() (io.ReadCloser, error) {
+			data, err := readHead()
+			return io.NopCloser(bytes.NewReader(data)), err
+		})
+		if err != nil || !ok || fsf.isFileTypeExcluded(fileType) {
+			return err
+		}
+		if _, err := readHead(); err != nil {
+			return archiveReadError{err}
+		}
+		rest := io.Reader(rc)
+		if limit > 0 {
+			rest = io.LimitReader(rc, max(limit+1-int64(len(head)), 0))
+		}
+		content, err := io.ReadAll(io.MultiReader(bytes.NewReader(head), rest))
+		if err != nil {
+			return archiveReadError{err}
+		}
+		if limit > 0 && int64(len(content)) > limit {
+			return archiveReadError{fmt.Errorf("member %s is larger than %d bytes", name, limit)}
+		}
+		members[name] = content
+		seenMap[memberPath] = struct{}{}
+		*matchingFiles = append(*matchingFiles, FileMetadata{
+			Name:        path.Base(name),
+			Path:        memberPath,
+			FileType:    fileType,
+			TypeNote:    notes.note,
+			TypeWarning: notes.warning,
+			FS:          members,
+			fsPath:      name,
+		})
+		return nil
+	}
+}
+
+// addArchiveMembers adds the members of the archive at archivePath, whose
+// content is data, that the finder's file types, type overrides and
+// exclusions select. Only the selected members are read into memory, and
+// none past the finder's member size limit. Archives inside archives are
+// not opened. An archive that cannot be read is added as a file whose
+// ReadFile fails with ErrUnreadableArchive, after any members read before
+// the failure.
+func (fsf *FileSystemFinder) addArchiveMembers(archivePath string, kind archiveKind, data []byte, seenMap map[string]struct{}, matchingFiles *[]FileMetadata) error {
+	limit := fsf.MaxArchiveMemberSize
+	if limit <= 0 {
+		limit = DefaultMaxArchiveMemberSize
+	}
+	add := fsf.memberAdder(archivePath+ArchiveSeparator, limit, seenMap, matchingFiles)
+	var err error
+	switch kind {
+	case zipArchive:
+		err = addZipMembers(data, add)
+	case tarArchive:
+		err = addTarMembers(bytes.NewReader(data), add)
+	case tarGzArchive:
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(bytes.NewReader(data)); err != nil {
+			err = archiveReadError{err}
+		} else {
+			err = addTarMembers(gz, add)
+		}
+	}
+	var readErr archiveReadError
+	if errors.As(err, &readErr) {
+		addUnreadableArchive(archivePath, readErr.err, seenMap, matchingFiles)
+		return nil
+	}
+	return err
+}
+
+// addUnreadableArchive adds the archive at archivePath as a file whose
+// ReadFile returns err, so that it is reported like any invalid file.
+func addUnreadableArchive(archivePath string, err error, seenMap map[string]struct{}, matchingFiles *[]FileMetadata) {
+	if _, seen := seenMap[archivePath]; seen {
+		return
+	}
+	seenMap[archivePath] = struct{}{}
+	*matchingFiles = append(*matchingFiles, FileMetadata{
+		Name:    path.Base(filepath.ToSlash(archivePath)),
+		Path:    archivePath,
+		readErr: fmt.Errorf("%w: %w", ErrUnreadableArchive, err),
+	})
+}
+
+func addZipMembers(data []byte, add func(string, func() (io.ReadCloser, error)) error) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return archiveReadError{err}
+	}
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		if err := add(f.Name, f.Open); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addTarMembers(r io.Reader, add func(string, func() (io.ReadCloser, error)) error) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return archiveReadError{err}
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := add(hdr.Name, func() (io.ReadCloser, error) { return io.NopCloser(tr), nil }); err != nil {
+			return err
+		}
+	}
+}
+
+// inExcludedDir reports whether any directory in the slash-separated path
+// name is excluded.
+func (fsf *FileSystemFinder) inExcludedDir(name string) bool {
+	dirs := strings.Split(name, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if _, excluded := fsf.ExcludeDirs[dir]; excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveFS holds the archive members a finder selected, by their path in
+// the archive.
+type archiveFS map[string][]byte
+
+func (afs archiveFS) Open(name string) (fs.File, error) {
+	data, err := afs.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &archiveFile{Reader: bytes.NewReader(data), name: path.Base(name)}, nil
+}
+
+func (afs archiveFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	data, ok := afs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return data, nil
+}
+
+// archiveFile is an open archive member. It is its own fs.FileInfo.
+type archiveFile struct {
+	*bytes.Reader
+	name string
+}
+
+func (f *archiveFile) Stat() (fs.FileInfo, error) { return f, nil }
+func (*archiveFile) Close() error                 { return nil }
+func (f *archiveFile) Name() string               { return f.name }
+func (*archiveFile) Mode() fs.FileMode            { return 0o444 }
+func (*archiveFile) ModTime() time.Time           { return time.Time{} }
+func (*archiveFile) IsDir() bool                  { return false }
+func (*archiveFile) Sys() any                     { return nil }
@@ -0,0 +1,161 @@
+package finder
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/internal/testhelper"
+	"github.com/Boeing/config-file-validator/v2/pkg/filetype"
+	"github.com/Boeing/config-file-validator/v2/pkg/tools"
+	"github.com/Boeing/config-file-validator/v2/pkg/validator"
+)
+
+// member is a file in a test archive.
+type member struct{ name, content string }
+
+func buildZip(t *testing.T, members ...member) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, m := range members {
+		w, err := zw.Create(m.name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(m.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return b.Bytes()
+}
+
+func buildTar(t *testing.T, gzipped bool, members ...member) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&b)
+	if gzipped {
+		gz = gzip.NewWriter(&b)
+		tw = tar.NewWriter(gz)
+	}
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "chart/", Typeflag: tar.TypeDir, Mode: 0755}))
+	for _, m := range members {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: m.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(m.content))}))
+		_, err := tw.Write([]byte(m.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	if gz != nil {
+		require.NoError(t, gz.Close())
+	}
+	return b.Bytes()
+}
+
+func Test_fsFinderArchives(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	members := []member{
+		{"chart/values.yaml", "a: 1\n"},
+		{"chart/Chart.lock", "lock"},
+		{"chart/charts/sub/values.yaml", "b: 2\n"},
+		{"./app/application.properties", "a=1\n"},
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bundle.tgz"), buildTar(t, true, members...), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bundle.tar"), buildTar(t, false, members...), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.jar"), buildZip(t, members...), 0600))
+	testhelper.WriteFile(t, dir, "plain.json", "{}")
+
+	files, err := FileSystemFinderInit(WithPathRoots(dir)).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 1, "archives are not opened by default")
+
+	files, err = FileSystemFinderInit(WithPathRoots(dir), WithArchives(true), WithExcludeDirs([]string{"charts"})).Find()
+	require.NoError(t, err)
+	got := make(map[string]string)
+	for _, f := range files {
+		content, err := f.ReadFile()
+		require.NoError(t, err)
+		rel, err := filepath.Rel(dir, f.Path)
+		require.NoError(t, err)
+		got[filepath.ToSlash(rel)] = string(content)
+	}
+	require.Equal(t, map[string]string{
+		"plain.json":                             "{}",
+		"app.jar!/chart/values.yaml":             "a: 1\n",
+		"app.jar!/app/application.properties":    "a=1\n",
+		"bundle.tar!/chart/values.yaml":          "a: 1\n",
+		"bundle.tar!/app/application.properties": "a=1\n",
+		"bundle.tgz!/chart/values.yaml":          "a: 1\n",
+		"bundle.tgz!/app/application.properties": "a=1\n",
+	}, got)
+}
+
+func Test_fsFinderArchiveTypeOverrides(t *testing.T) {
+	t.Parallel()
+	iniType := filetype.FileType{Name: "ini", Extensions: tools.ArrToMap("ini"), Validator: validator.IniValidator{}}
+	fsys := fstest.MapFS{
+		"dist/bundle.zip": {Data: buildZip(t, member{"inventory", "[web]"}, member{"values.yaml", "a: 1"})},
+	}
+	files, err := FileSystemFinderInit(
+		WithFS(fsys),
+		WithArchives(true),
+		WithExcludeFileTypes([]string{"yaml"}),
+		WithTypeOverrides([]TypeOverride{{Pattern: "**/bundle.zip!/inventory", FileType: iniType}}),
+	).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "dist/bundle.zip!/inventory", files[0].Path)
+	require.Equal(t, "inventory", files[0].Name)
+	require.Equal(t, "ini", files[0].FileType.Name)
+	content, err := files[0].ReadFile()
+	require.NoError(t, err)
+	require.Equal(t, "[web]", string(content))
+}
+
+func Test_fsFinderArchiveCorrupt(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"bundle.tgz": {Data: []byte("not a gzip stream")},
+		"app.json":   {Data: []byte("{}")},
+	}
+	files, err := FileSystemFinderInit(WithFS(fsys), WithArchives(true)).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "bundle.tgz", files[1].Path)
+	_, err = files[1].ReadFile()
+	require.ErrorIs(t, err, ErrUnreadableArchive)
+	require.ErrorContains(t, err, "gzip: invalid header")
+}
+
+func Test_fsFinderArchiveMemberSizeLimit(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"app.jar": {Data: buildZip(t, member{"a.json", "{}"}, member{"b.json", `{"key": "value"}`},
+			member{"lib/blob.bin", strings.Repeat("x", 64)})},
+	}
+	// Members that are not selected, such as blob.bin, are not loaded and
+	// so do not count against the limit.
+	files, err := FileSystemFinderInit(WithFS(fsys), WithArchives(true), WithMaxArchiveMemberSize(8)).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "app.jar!/a.json", files[0].Path)
+	require.Equal(t, "app.jar", files[1].Path)
+	_, err = files[1].ReadFile()
+	require.ErrorIs(t, err, ErrUnreadableArchive)
+	require.ErrorContains(t, err, "member b.json is larger than 8 bytes")
+
+	files, err = FileSystemFinderInit(WithFS(fsys), WithArchives(true), WithMaxArchiveMemberSize(16)).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, f := range files {
+		_, err := f.ReadFile()
+		require.NoError(t, err)
+	}
+}
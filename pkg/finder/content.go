@@ -0,0 +1,106 @@
+package finder
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/filetype"
+)
+
+// sniffHeadSize is how much of a file is read to detect its type from its
+// content.
+const sniffHeadSize = 8 << 10
+
+// sniffExtensions are generic extensions that say a file holds
+// configuration but not in which format.
+var sniffExtensions = map[string]struct{}{
+	"conf":   {},
+	"cfg":    {},
+	"config": {},
+	"cnf":    {},
+}
+
+// sniffable reports whether the type of a file that its name does not
+// give may be detected from its content: when the name has no extension,
+// is a dotfile, or has a generic configuration extension.
+func sniffable(name string) bool {
+	base := filepath.Base(name)
+	ext := strings.TrimPrefix(filepath.Ext(base), ".")
+	if ext == "" || "."+ext == base {
+		return true
+	}
+	_, ok := sniffExtensions[strings.ToLower(ext)]
+	return ok
+}
+
+// typeNotes explain the type typeFile chose for a file.
+type typeNotes struct {
+	// note explains a type that was not chosen by name.
+	note string
+	// warning is a problem with a type the file declares that did not
+	// set its type.
+	warning string
+}
+
+// typeFile returns the type of the file at path. A type override wins,
+// then a type hint in the file when hints are on, then the file name, and
+// finally, when sniffing is on, the content. Content is read, through
+// open, only for files typed by name when hints are on and for the files
+// sniffable accepts, so most files that are not selected are never
+// opened. Files that cannot be read are typed by name alone.
+func (fsf *FileSystemFinder) typeFile(path string, open func() (io.ReadCloser, error)) (filetype.FileType, typeNotes, bool, error) {
+	if fileType, ok, err := fsf.overrideTypeFor(path); err != nil || ok {
+		return fileType, typeNotes{}, ok, err
+	}
+	fileType, named := fsf.nameTypeFor(path)
+	candidate := !named && sniffable(path)
+	readHints := fsf.TypeHints && (named || candidate)
+	readSniff := fsf.Sniff && candidate
+	if !readHints && !readSniff {
+		return fileType, typeNotes{}, named, nil
+	}
+
+	head, err := readHead(open)
+	if err != nil {
+		return fileType, typeNotes{}, named, nil
+	}
+	if readHints {
+		if hint, ok := filetype.FindTypeHint(head); ok {
+			hinted, ok, known := filetype.LookupTypeHint(hint, fsf.FileTypes)
+			switch {
+			case ok:
+				return hinted, typeNotes{note: fmt.Sprintf("file type %s from %s", hinted.Name, hint.Source)}, true, nil
+			case !known && hint.Source == filetype.HintDirective:
+				return filetype.FileType{}, typeNotes{}, false, fmt.Errorf("%s: %s names unknown file type %q", path, hint.Source, hint.Name)
+			case !known && hint.Source == filetype.HintModeline && named:
+				// Modelines also name editor modes, such as conf in an
+				// .ini file, so the file keeps the type of its name.
+				warning := fmt.Sprintf("%s names unknown file type %q; typed as %s by name", hint.Source, hint.Name, fileType.Name)
+				return fileType, typeNotes{warning: warning}, true, nil
+			case known:
+				// The declared type is not selected, as by --file-types.
+				return filetype.FileType{}, typeNotes{}, false, nil
+			}
+		}
+	}
+	if !readSniff {
+		return fileType, typeNotes{}, named, nil
+	}
+	fileType, confidence, ok := filetype.Sniff(head, fsf.FileTypes)
+	if !ok {
+		return filetype.FileType{}, typeNotes{}, false, nil
+	}
+	return fileType, typeNotes{note: fmt.Sprintf("file type %s detected from content (%s confidence)", fileType.Name, confidence)}, true, nil
+}
+
+// readHead reads up to sniffHeadSize bytes of the file open returns.
+func readHead(open func() (io.ReadCloser, error)) ([]byte, error) {
+	rc, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, sniffHeadSize))
+}
@@ -0,0 +1,113 @@
+package finder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/internal/testhelper"
+	"github.com/Boeing/config-file-validator/v2/pkg/filetype"
+)
+
+func Test_fsFinderSniff(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	testhelper.WriteFile(t, dir, "app.conf", "[server]\nport = 80\n")
+	testhelper.WriteFile(t, dir, "settings", `{"a": 1}`)
+	testhelper.WriteFile(t, dir, ".appenv", "PORT=80\n")
+	testhelper.WriteFile(t, dir, "script.sh", "PORT=80\n")
+	testhelper.WriteFile(t, dir, "notes.cfg", "just some notes\n")
+	testhelper.WriteFile(t, dir, "good.json", "{}")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bundle.zip"), buildZip(t, member{"conf/site.config", "---\na: 1\n"}), 0600))
+
+	files, err := FileSystemFinderInit(WithPathRoots(dir)).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 1, "sniffing is off by default")
+
+	files, err = FileSystemFinderInit(WithPathRoots(dir), WithSniff(true), WithArchives(true)).Find()
+	require.NoError(t, err)
+	got := make(map[string]string)
+	for _, f := range files {
+		got[filepath.ToSlash(f.Path[len(dir)+1:])] = f.FileType.Name + " " + f.TypeNote
+	}
+	require.Equal(t, map[string]string{
+		"app.conf":                     "ini file type ini detected from content (high confidence)",
+		"settings":                     "json file type json detected from content (high confidence)",
+		".appenv":                      "env file type env detected from content (medium confidence)",
+		"good.json":                    "json ",
+		"bundle.zip!/conf/site.config": "yaml file type yaml detected from content (high confidence)",
+	}, got)
+
+	// Type overrides win over content, and sniffed types can be excluded.
+	files, err = FileSystemFinderInit(WithPathRoots(dir), WithSniff(true),
+		WithTypeOverrides([]TypeOverride{{Pattern: "**/app.conf", FileType: filetype.PropFileType}}),
+		WithExcludeFileTypes([]string{"json", "env"})).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "properties", files[0].FileType.Name)
+	require.Empty(t, files[0].TypeNote)
+}
+
+func Test_fsFinderSniffFS(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"etc/app.cnf": {Data: []byte("<?xml version=\"1.0\"?>\n<app/>")},
+		"Makefile.in": {Data: []byte("all:\n")},
+	}
+	files, err := FileSystemFinderInit(WithFS(fsys), WithSniff(true), WithFileTypes([]filetype.FileType{filetype.XMLFileType})).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "etc/app.cnf", files[0].Path)
+	require.Equal(t, "xml", files[0].FileType.Name)
+}
+
+func Test_fsFinderTypeHints(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	testhelper.WriteFile(t, dir, "config.json", "// vim: ft=jsonc\n{\"a\": 1,}\n")
+	testhelper.WriteFile(t, dir, "inventory", "# cfv: type=ini\n[web]\nhost1\n")
+	testhelper.WriteFile(t, dir, "build", "#!/usr/bin/env -S just --justfile\nbuild:\n\techo hi\n")
+	testhelper.WriteFile(t, dir, "deploy", "#!/bin/sh\n# vim: ft=sh\necho hi\n")
+	testhelper.WriteFile(t, dir, "values.tmpl", "# cfv: type=yaml\na: 1\n")
+	testhelper.WriteFile(t, dir, "app.yaml", "a: 1\n")
+	testhelper.WriteFile(t, dir, "app.ini", "# -*- mode: conf -*-\n[server]\n")
+
+	files, err := FileSystemFinderInit(WithPathRoots(dir)).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 3, "hints are off by default")
+
+	// values.tmpl is neither typed by name nor sniffable, so it is not read.
+	files, err = FileSystemFinderInit(WithPathRoots(dir), WithTypeHints(true)).Find()
+	require.NoError(t, err)
+	got := make(map[string]string)
+	for _, f := range files {
+		got[f.Name] = f.FileType.Name + " " + f.TypeNote + f.TypeWarning
+	}
+	require.Equal(t, map[string]string{
+		"config.json": "jsonc file type jsonc from modeline",
+		"inventory":   "ini file type ini from cfv directive",
+		"build":       "justfile file type justfile from shebang",
+		"app.yaml":    "yaml ",
+		"app.ini":     `ini modeline names unknown file type "conf"; typed as ini by name`,
+	}, got)
+
+	// Type overrides win over hints, and hinted types can be excluded.
+	files, err = FileSystemFinderInit(WithPathRoots(dir), WithTypeHints(true),
+		WithTypeOverrides([]TypeOverride{{Pattern: "**/config.json", FileType: filetype.JSONFileType}}),
+		WithExcludeFileTypes([]string{"ini", "justfile", "yaml"})).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "json", files[0].FileType.Name)
+	require.Empty(t, files[0].TypeNote)
+
+	// A cfv directive naming an unknown type is a configuration error.
+	// Modelines also name editor modes, so an unknown one only warns, as
+	// in app.ini above, or is ignored, as in deploy.
+	unknown := t.TempDir()
+	testhelper.WriteFile(t, unknown, "broken", "# cfv: type=yamll\n")
+	_, err = FileSystemFinderInit(WithPathRoots(unknown), WithTypeHints(true)).Find()
+	require.ErrorContains(t, err, `cfv directive names unknown file type "yamll"`)
+}
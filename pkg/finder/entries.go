@@ -0,0 +1,37 @@
+package finder
+
+import (
+	"bytes"
+	"io"
+	"path"
+)
+
+// Entry is a named file held in memory.
+type Entry struct {
+	// Path is the slash-separated path the file is typed and reported by.
+	Path    string
+	Content []byte
+}
+
+// findEntries selects from fsf.Entries. When two entries have the same
+// path, the first is kept.
+func (fsf *FileSystemFinder) findEntries() ([]FileMetadata, error) {
+	if fsf.usesGitFilters() {
+		return nil, errFSGitFilters
+	}
+	seen := make(map[string]struct{})
+	matches := make([]FileMetadata, 0)
+	add := fsf.memberAdder("", 0, seen, &matches)
+	for _, entry := range fsf.Entries {
+		if kind := archiveKindOf(entry.Path); fsf.Archives && kind != notArchive {
+			if err := fsf.addArchiveMembers(path.Clean(entry.Path), kind, entry.Content, seen, &matches); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := add(entry.Path, func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(entry.Content)), nil }); err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
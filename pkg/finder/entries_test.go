@@ -0,0 +1,53 @@
+package finder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/filetype"
+)
+
+func Test_fsFinderEntries(t *testing.T) {
+	t.Parallel()
+	entries := []Entry{
+		{Path: "config/app.json", Content: []byte(`{}`)},
+		{Path: "config/app.json", Content: []byte(`{"second": true}`)},
+		{Path: "/ci/.editorconfig", Content: []byte("root = true\n")},
+		{Path: "vendor/lib.yaml", Content: []byte("a: 1\n")},
+		{Path: "notes.md", Content: []byte("# notes")},
+		{Path: "settings.conf", Content: []byte(`{}`)},
+		{Path: "bundle.tgz", Content: buildTar(t, true, member{"chart/values.yaml", "a: 1\n"})},
+	}
+
+	files, err := FileSystemFinderInit(WithEntries(entries), WithExcludeDirs([]string{"vendor"})).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "config/app.json", files[0].Path)
+	require.Equal(t, "app.json", files[0].Name)
+	data, err := files[0].ReadFile()
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(data), "the first entry with a path wins")
+	require.Equal(t, "ci/.editorconfig", files[1].Path)
+	require.Equal(t, "editorconfig", files[1].FileType.Name)
+
+	overrides := []TypeOverride{{Pattern: "**/*.conf", FileType: filetype.JSONFileType}}
+	files, err = FileSystemFinderInit(WithEntries(entries), WithTypeOverrides(overrides), WithArchives(true),
+		WithFileTypes([]filetype.FileType{filetype.JSONFileType, filetype.YAMLFileType})).Find()
+	require.NoError(t, err)
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	require.Equal(t, []string{"config/app.json", "vendor/lib.yaml", "settings.conf", "bundle.tgz" + ArchiveSeparator + "chart/values.yaml"}, paths)
+	data, err = files[3].ReadFile()
+	require.NoError(t, err)
+	require.Equal(t, "a: 1\n", string(data))
+
+	_, err = FileSystemFinderInit(WithEntries(entries), WithGitignore(true)).Find()
+	require.ErrorIs(t, err, errFSGitFilters)
+
+	files, err = FileSystemFinderInit(WithEntries(nil), WithPathRoots("-")).Find()
+	require.NoError(t, err)
+	require.Empty(t, files, "no entries selects no files rather than the path roots")
+}
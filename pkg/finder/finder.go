@@ -1,6 +1,9 @@
 package finder
 
 import (
+	"io/fs"
+	"os"
+
 	"github.com/Boeing/config-file-validator/v2/pkg/filetype"
 )
 
@@ -11,6 +14,37 @@ type FileMetadata struct {
 	Name     string
 	Path     string
 	FileType filetype.FileType
+	// TypeNote explains how FileType was chosen when it was not from the
+	// file name, as for types detected from content.
+	TypeNote string
+	// TypeWarning is a problem with a type the file declares that did not
+	// set its type, such as a modeline naming an unknown file type in a
+	// file typed by name.
+	TypeWarning string
+	// FS is the file system Path is in. When nil, Path is on the OS file
+	// system.
+	FS fs.FS
+	// fsPath is the path of the file in FS when it differs from Path, as
+	// for archive members.
+	fsPath string
+	// readErr, when set, is returned by ReadFile, as for an archive that
+	// could not be read.
+	readErr error
+}
+
+// ReadFile reads the file from FS, or from the OS file system when FS is
+// nil.
+func (f FileMetadata) ReadFile() ([]byte, error) {
+	switch {
+	case f.readErr != nil:
+		return nil, f.readErr
+	case f.FS == nil:
+		return os.ReadFile(f.Path)
+	case f.fsPath != "":
+		return fs.ReadFile(f.FS, f.fsPath)
+	default:
+		return fs.ReadFile(f.FS, f.Path)
+	}
 }
 
 // FileFinder is the interface that wraps the Find method
@@ -2,11 +2,13 @@ package finder
 
 import (
 	"context"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/require"
 
@@ -496,6 +498,145 @@ func Test_fsFinderMatchFile(t *testing.T) {
 	require.Empty(t, files)
 }
 
+func Test_fsFinderFiles(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile := testhelper.WriteFile(t, dir, "good.json", testhelper.ValidContent["json"])
+	yamlFile := testhelper.WriteFile(t, dir, "good.yaml", testhelper.ValidContent["yaml"])
+	testhelper.WriteFile(t, dir, "other.json", testhelper.ValidContent["json"])
+	outside := testhelper.WriteFile(t, t.TempDir(), "outside.json", testhelper.ValidContent["json"])
+
+	files, err := FileSystemFinderInit(
+		WithPathRoots(dir),
+		WithExcludeFileTypes([]string{"yaml"}),
+		WithFiles([]string{jsonFile, yamlFile, jsonFile, outside, filepath.Join(dir, "deleted.json"), dir}),
+	).Find()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, jsonFile, files[0].Path)
+
+	files, err = FileSystemFinderInit(WithPathRoots(dir), WithFiles([]string{})).Find()
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func Test_fsFinderDirConfigs(t *testing.T) {
+	dir := t.TempDir()
+	app := filepath.Join(dir, "app")
+	require.NoError(t, os.MkdirAll(filepath.Join(app, "gen"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "gen"), 0o755))
+	testhelper.WriteFile(t, dir, "gen/a.json", testhelper.ValidContent["json"])
+	testhelper.WriteFile(t, dir, "top.cfg", testhelper.ValidContent["json"])
+	testhelper.WriteFile(t, app, "gen/b.json", testhelper.ValidContent["json"])
+	testhelper.WriteFile(t, app, "c.yaml", testhelper.ValidContent["yaml"])
+	cfg := testhelper.WriteFile(t, app, "d.cfg", testhelper.ValidContent["json"])
+
+	csvType := filetype.CsvFileType
+	csvType.Validator = validator.CsvValidator{Delimiter: ';'}
+	var calls int
+	dirConfigs := func(d string) (*DirConfig, error) {
+		calls++
+		if !containsPath(app, d) {
+			return nil, nil
+		}
+		return &DirConfig{
+			ExcludeDirs:      []string{"gen"},
+			ExcludeFileTypes: []string{"yaml"},
+			TypeOverrides:    []TypeOverride{{Pattern: "*.cfg", FileType: filetype.JSONFileType, Dir: app}},
+			FileTypes:        []filetype.FileType{csvType},
+		}, nil
+	}
+
+	files, err := FileSystemFinderInit(WithPathRoots(dir), WithDirConfigs(dirConfigs)).Find()
+	require.NoError(t, err)
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	require.ElementsMatch(t, []string{filepath.Join(dir, "gen", "a.json"), cfg}, paths)
+	require.Equal(t, 4, calls, "called once per directory")
+
+	f := FileSystemFinderInit(WithDirConfigs(dirConfigs))
+	ft, ok, err := f.FileTypeFor(filepath.Join(app, "x.csv"), nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, csvType.Validator, ft.Validator)
+}
+
+func Test_fsFinderFileTypeFor(t *testing.T) {
+	t.Parallel()
+	iniType := filetype.FileType{
+		Name:       "ini",
+		Extensions: tools.ArrToMap("ini"),
+		Validator:  validator.IniValidator{},
+	}
+	fsFinder := FileSystemFinderInit(
+		WithTypeOverrides([]TypeOverride{{Pattern: "**/inventory", FileType: iniType}}),
+		WithExcludeFileTypes([]string{"yaml"}),
+		WithTypeHints(true),
+		WithSniff(true),
+	)
+
+	tests := []struct {
+		path     string
+		content  string
+		wantType string
+	}{
+		{"/nowhere/config.JSON", "{}", "json"},
+		{"/nowhere/tsconfig.json", "{}", "jsonc"},
+		{"/nowhere/inventory", "[web]", "ini"},
+		{"/nowhere/values.yaml", "a: 1", ""},
+		{"/nowhere/README.md", "# readme", ""},
+		{"/nowhere/deploy", "# cfv: type=toml\na = 1\n", "toml"},
+		{"/nowhere/deploy.j2", "# cfv: type=toml\na = 1\n", ""},
+		{"/nowhere/app.cnf", "<app/>", "xml"},
+	}
+	for _, tt := range tests {
+		ft, ok, err := fsFinder.FileTypeFor(tt.path, []byte(tt.content))
+		require.NoError(t, err)
+		require.Equal(t, tt.wantType != "", ok, tt.path)
+		require.Equal(t, tt.wantType, ft.Name, tt.path)
+	}
+}
+
+func Test_fsFinderFS(t *testing.T) {
+	t.Parallel()
+	fsys := fstest.MapFS{
+		"app.json":               {Data: []byte(`{}`)},
+		"README.md":              {Data: []byte("# readme")},
+		"conf/db.yaml":           {Data: []byte("a: 1")},
+		"conf/deep/inventory":    {Data: []byte("[web]")},
+		"node_modules/pkg.json":  {Data: []byte(`{}`)},
+		"conf/deep/settings.ini": {Data: []byte("[a]")},
+	}
+	iniType := filetype.FileType{Name: "ini", Extensions: tools.ArrToMap("ini"), Validator: validator.IniValidator{}}
+	paths := func(opts ...FSFinderOptions) []string {
+		t.Helper()
+		files, err := FileSystemFinderInit(append([]FSFinderOptions{WithFS(fsys)}, opts...)...).Find()
+		require.NoError(t, err)
+		var got []string
+		for _, f := range files {
+			require.Equal(t, fsys, f.FS)
+			got = append(got, f.Path)
+		}
+		return got
+	}
+
+	require.Equal(t, []string{"app.json", "conf/db.yaml", "conf/deep/settings.ini", "node_modules/pkg.json"}, paths())
+	require.Equal(t, []string{"app.json"}, paths(WithDepth(0)))
+	require.Equal(t, []string{"conf/db.yaml"}, paths(WithPathRoots("conf/"), WithDepth(0)))
+	require.Equal(t, []string{"app.json", "conf/db.yaml", "conf/deep/settings.ini"}, paths(WithExcludeDirs([]string{"node_modules"})))
+	require.Equal(t, []string{"conf/deep/inventory", "conf/deep/settings.ini"},
+		paths(WithPathRoots("conf/deep"), WithTypeOverrides([]TypeOverride{{Pattern: "**/inventory", FileType: iniType}})))
+	require.Equal(t, []string{"app.json"}, paths(WithPathRoots("app.json", "./app.json")))
+
+	_, err := FileSystemFinderInit(WithFS(fsys), WithPathRoots("missing")).Find()
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = FileSystemFinderInit(WithFS(fsys), WithGitignore(true)).Find()
+	require.ErrorIs(t, err, errFSGitFilters)
+	_, err = FileSystemFinderInit(WithFS(fsys)).MatchFile("app.json")
+	require.Error(t, err)
+}
+
 func Test_fsFinderMatchFileGitignore(t *testing.T) {
 	dir := initGitRepo(t)
 	keepFile := testhelper.WriteFile(t, dir, "keep.json", testhelper.ValidContent["json"])
@@ -811,3 +952,111 @@ func Test_fsFinderExtensionCacheNoPoison(t *testing.T) {
 	require.Contains(t, names, "good.json")
 	require.Len(t, names, 2, "LICENSE should not be found (unrecognized)")
 }
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.CommandContext(context.Background(), "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %s: %v", args, out, err)
+	}
+}
+
+func Test_fsFinderChangedSince(t *testing.T) {
+	dir := initGitRepo(t)
+	testhelper.WriteFile(t, dir, "modified.json", testhelper.ValidContent["json"])
+	testhelper.WriteFile(t, dir, "unchanged.json", testhelper.ValidContent["json"])
+	testhelper.WriteFile(t, dir, "renamed.yaml", testhelper.ValidContent["yaml"])
+	testhelper.WriteFile(t, dir, "deleted.json", testhelper.ValidContent["json"])
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "base")
+	runGit(t, dir, "tag", "base")
+
+	testhelper.WriteFile(t, dir, "modified.json", `{"changed": true}`)
+	testhelper.WriteFile(t, dir, "added.json", testhelper.ValidContent["json"])
+	vendor := testhelper.CreateSubdir(t, dir, "vendor")
+	testhelper.WriteFile(t, vendor, "excluded.json", testhelper.ValidContent["json"])
+	runGit(t, dir, "mv", "renamed.yaml", "new-name.yaml")
+	runGit(t, dir, "rm", "-q", "deleted.json")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "change")
+
+	// Uncommitted and untracked files are not compared against the ref.
+	testhelper.WriteFile(t, dir, "untracked.toml", testhelper.ValidContent["toml"])
+	testhelper.WriteFile(t, dir, "unchanged.json", `{"uncommitted": true}`)
+
+	fsFinder := FileSystemFinderInit(
+		WithPathRoots(dir),
+		WithExcludeDirs([]string{"vendor"}),
+		WithChangedSince("base"),
+	)
+	files, err := fsFinder.Find()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"modified.json", "added.json", "new-name.yaml"}, fileNames(files))
+}
+
+func Test_fsFinderChangedSinceUsesMergeBase(t *testing.T) {
+	dir := initGitRepo(t)
+	testhelper.WriteFile(t, dir, "a.json", testhelper.ValidContent["json"])
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "base")
+	runGit(t, dir, "branch", "main-line")
+
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+	testhelper.WriteFile(t, dir, "feature.json", testhelper.ValidContent["json"])
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "feature")
+
+	// A commit on the base branch after the feature branched off must not
+	// make its files count as changed on the feature branch.
+	runGit(t, dir, "checkout", "-q", "main-line")
+	testhelper.WriteFile(t, dir, "a.json", `{"upstream": true}`)
+	runGit(t, dir, "commit", "-qam", "upstream")
+	runGit(t, dir, "checkout", "-q", "feature")
+
+	files, err := FileSystemFinderInit(WithPathRoots(dir), WithChangedSince("main-line")).Find()
+	require.NoError(t, err)
+	require.Equal(t, []string{"feature.json"}, fileNames(files))
+}
+
+func Test_fsFinderStaged(t *testing.T) {
+	dir := initGitRepo(t)
+	testhelper.WriteFile(t, dir, "first.json", testhelper.ValidContent["json"])
+	runGit(t, dir, "add", ".")
+
+	// Before the first commit every staged file is added.
+	files, err := FileSystemFinderInit(WithPathRoots(dir), WithStaged(true)).Find()
+	require.NoError(t, err)
+	require.Equal(t, []string{"first.json"}, fileNames(files))
+
+	testhelper.WriteFile(t, dir, "staged.json", testhelper.ValidContent["json"])
+	testhelper.WriteFile(t, dir, "unstaged.json", testhelper.ValidContent["json"])
+	testhelper.WriteFile(t, dir, "renamed.yaml", testhelper.ValidContent["yaml"])
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "base")
+
+	testhelper.WriteFile(t, dir, "staged.json", `{"changed": true}`)
+	testhelper.WriteFile(t, dir, "unstaged.json", `{"changed": true}`)
+	testhelper.WriteFile(t, dir, "new.yaml", testhelper.ValidContent["yaml"])
+	testhelper.WriteFile(t, dir, "untracked.json", testhelper.ValidContent["json"])
+	runGit(t, dir, "add", "staged.json", "new.yaml")
+	runGit(t, dir, "mv", "renamed.yaml", "new-name.yaml")
+
+	files, err = FileSystemFinderInit(WithPathRoots(dir), WithStaged(true)).Find()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"staged.json", "new.yaml", "new-name.yaml"}, fileNames(files))
+}
+
+func Test_fsFinderChangedSinceErrors(t *testing.T) {
+	_, err := FileSystemFinderInit(WithPathRoots(t.TempDir()), WithStaged(true)).Find()
+	require.ErrorContains(t, err, "is not inside a git repository")
+
+	dir := initGitRepo(t)
+	testhelper.WriteFile(t, dir, "a.json", testhelper.ValidContent["json"])
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "base")
+
+	_, err = FileSystemFinderInit(WithPathRoots(dir), WithChangedSince("no-such-ref")).Find()
+	require.ErrorContains(t, err, `resolving "no-such-ref"`)
+}
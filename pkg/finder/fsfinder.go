@@ -2,11 +2,16 @@ package finder
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
+	"io"
 	"io/fs"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
@@ -18,8 +23,29 @@ import (
 )
 
 type TypeOverride struct {
-	Pattern  string
+	Pattern string
+	// FileType is the type of matching files. When the finder has a file
+	// type of the same name, such as one WithFileTypes configured, that
+	// one is used, so its validator options apply.
 	FileType filetype.FileType
+	// Dir, when set, makes Pattern match paths relative to this absolute
+	// directory, and only paths below it, instead of paths as found.
+	Dir string
+}
+
+// DirConfig is configuration that applies to a directory and the
+// directories below it on top of the finder's own, as a nested .cfv.toml
+// does.
+type DirConfig struct {
+	// ExcludeDirs and ExcludeFileTypes are excluded in addition to the
+	// finder's.
+	ExcludeDirs      []string
+	ExcludeFileTypes []string
+	// TypeOverrides are tried before the finder's.
+	TypeOverrides []TypeOverride
+	// FileTypes replace the finder's file types of the same name, as to
+	// configure their validators.
+	FileTypes []filetype.FileType
 }
 
 type FileSystemFinder struct {
@@ -32,7 +58,39 @@ type FileSystemFinder struct {
 	TypeOverrides    []TypeOverride
 	Gitignore        bool
 	IgnoreFiles      []string
-}
+	ChangedSince     string
+	Staged           bool
+	// Archives makes the finder select members of zip and tar archives.
+	Archives bool
+	// MaxArchiveMemberSize is the largest archive member, in bytes, the
+	// finder reads. Zero means DefaultMaxArchiveMemberSize.
+	MaxArchiveMemberSize int64
+	// TypeHints makes the finder honour the type a file declares in its
+	// first lines, with a modeline, a shebang or a cfv directive.
+	TypeHints bool
+	// Sniff makes the finder detect the type of files whose name does not
+	// give one from their content.
+	Sniff bool
+	// InMemory makes the finder search Entries instead of PathRoots, even
+	// when there are none.
+	InMemory bool
+	// Entries are the files searched when InMemory is set.
+	Entries []Entry
+	// Files, when set, are matched against PathRoots with MatchFile
+	// instead of walking them.
+	Files []string
+	// FS is the file system to search. PathRoots are slash-separated paths
+	// within it. When nil, the OS file system is searched.
+	FS fs.FS
+	// DirConfigs returns the DirConfig for an absolute directory on the OS
+	// file system, or nil when there is none.
+	DirConfigs func(dir string) (*DirConfig, error)
+	dirFinders map[string]*FileSystemFinder
+}
+
+// errFSGitFilters is returned by Find for filters that need the files to be
+// on disk in a git work tree.
+var errFSGitFilters = errors.New("gitignore, ignore files, changed-since and staged filters cannot be used with an fs.FS or in-memory entries")
 
 type FSFinderOptions func(*FileSystemFinder)
 
@@ -96,6 +154,101 @@ func WithIgnoreFiles(paths []string) FSFinderOptions {
 	}
 }
 
+// WithChangedSince limits results to files added, modified or renamed in
+// the commits between the merge base of the given git revision and HEAD.
+// Uncommitted and untracked files are not included.
+func WithChangedSince(ref string) FSFinderOptions {
+	return func(fsf *FileSystemFinder) {
+		fsf.ChangedSince = ref
+	}
+}
+
+// WithStaged limits results to files added, modified or renamed in the git index.
+func WithStaged(enabled bool) FSFinderOptions {
+	return func(fsf *FileSystemFinder) {
+		fsf.Staged = enabled
+	}
+}
+
+// WithArchives makes the finder open .zip, .jar, .tar, .tar.gz and .tgz
+// files and select their members as if they were files in the search path.
+// Members are reported as "<archive>!/<member path>".
+func WithArchives(enabled bool) FSFinderOptions {
+	return func(fsf *FileSystemFinder) {
+		fsf.Archives = enabled
+	}
+}
+
+// WithMaxArchiveMemberSize sets the largest archive member, in bytes, the
+// finder reads. An archive with a larger member that the finder selects is
+// reported as unreadable.
+func WithMaxArchiveMemberSize(size int64) FSFinderOptions {
+	return func(fsf *FileSystemFinder) {
+		fsf.MaxArchiveMemberSize = size
+	}
+}
+
+// WithTypeHints makes a type declared in the first five lines of a file
+// win over its name: a "cfv: type=<type>" directive, a Vim or Emacs
+// modeline, or a just shebang. Only type overrides take precedence. Hints
+// are read from files typed by name and from the files sniffing would
+// consider. A cfv directive naming an unknown type makes Find fail. A
+// modeline naming one in a file typed by name sets the file's
+// TypeWarning, and in other files, such as "vim: ft=sh" in a script, it is
+// ignored.
+func WithTypeHints(enabled bool) FSFinderOptions {
+	return func(fsf *FileSystemFinder) {
+		fsf.TypeHints = enabled
+	}
+}
+
+// WithSniff detects the type of extensionless files, dotfiles and .conf,
+// .cfg, .config and .cnf files from their content when their name does
+// not give one. Type overrides and known files still take precedence.
+func WithSniff(enabled bool) FSFinderOptions {
+	return func(fsf *FileSystemFinder) {
+		fsf.Sniff = enabled
+	}
+}
+
+// WithFiles checks only the listed files, each as MatchFile does: a file
+// is selected when it is under a path root and passes every filter.
+// Listed files that do not exist and directories are skipped.
+func WithFiles(paths []string) FSFinderOptions {
+	return func(fsf *FileSystemFinder) {
+		fsf.Files = paths
+	}
+}
+
+// WithEntries searches files held in memory, such as files read from a
+// stream, instead of the path roots. Entries are selected by file type,
+// type overrides and exclusions as files on disk are, and reported by
+// their slash-separated path. An empty list selects no files.
+func WithEntries(entries []Entry) FSFinderOptions {
+	return func(fsf *FileSystemFinder) {
+		fsf.InMemory = true
+		fsf.Entries = entries
+	}
+}
+
+// WithDirConfigs applies the DirConfig dirConfigs returns for each
+// directory to the files in it. It is called once per directory, with the
+// directory's absolute path, and only when searching the OS file system.
+func WithDirConfigs(dirConfigs func(dir string) (*DirConfig, error)) FSFinderOptions {
+	return func(fsf *FileSystemFinder) {
+		fsf.DirConfigs = dirConfigs
+	}
+}
+
+// WithFS searches fsys instead of the OS file system. Path roots are
+// slash-separated paths within fsys, and found files carry fsys so that
+// they are read from it.
+func WithFS(fsys fs.FS) FSFinderOptions {
+	return func(fsf *FileSystemFinder) {
+		fsf.FS = fsys
+	}
+}
+
 func FileSystemFinderInit(opts ...FSFinderOptions) *FileSystemFinder {
 	defaultExcludeDirs := make(map[string]struct{})
 	defaultExcludeFileTypes := make(map[string]struct{})
@@ -121,25 +274,46 @@ func FileSystemFinderInit(opts ...FSFinderOptions) *FileSystemFinder {
 func (fsf FileSystemFinder) Find() ([]FileMetadata, error) {
 	finder := fsf
 	finder.extCache = make(map[string]struct{})
+	finder.dirFinders = make(map[string]*FileSystemFinder)
+	if finder.InMemory {
+		return finder.findEntries()
+	}
+	if finder.FS != nil {
+		return finder.findFS()
+	}
 
 	seen := make(map[string]struct{}, 0)
 	uniqueMatches := make([]FileMetadata, 0)
-	for _, pathRoot := range finder.PathRoots {
-		// remove all leading and trailing whitespace
-		trimmedPathRoot := strings.TrimSpace(pathRoot)
-		matches, err := finder.findOne(trimmedPathRoot, seen)
-		if err != nil {
+	if finder.Files != nil {
+		if err := finder.findFiles(seen, &uniqueMatches); err != nil {
 			return nil, err
 		}
-		uniqueMatches = append(uniqueMatches, matches...)
+	} else {
+		for _, pathRoot := range finder.PathRoots {
+			// remove all leading and trailing whitespace
+			trimmedPathRoot := strings.TrimSpace(pathRoot)
+			matches, err := finder.findOne(trimmedPathRoot, seen)
+			if err != nil {
+				return nil, err
+			}
+			uniqueMatches = append(uniqueMatches, matches...)
+		}
+	}
+	if finder.ChangedSince != "" || finder.Staged {
+		return finder.filterChanged(uniqueMatches)
 	}
 	return uniqueMatches, nil
 }
 
-// MatchFile applies the finder filters to a single file path.
+// MatchFile applies the finder filters to a single file path on the OS
+// file system.
 func (fsf FileSystemFinder) MatchFile(path string) ([]FileMetadata, error) {
 	finder := fsf
 	finder.extCache = make(map[string]struct{})
+	finder.dirFinders = make(map[string]*FileSystemFinder)
+	if finder.FS != nil {
+		return nil, errors.New("MatchFile cannot be used with an fs.FS")
+	}
 
 	path = strings.TrimSpace(path)
 	info, err := os.Stat(path)
@@ -165,6 +339,26 @@ func (fsf FileSystemFinder) MatchFile(path string) ([]FileMetadata, error) {
 	return matches, nil
 }
 
+// findFiles adds the files fsf.Files lists that MatchFile selects.
+func (fsf *FileSystemFinder) findFiles(seen map[string]struct{}, matches *[]FileMetadata) error {
+	for _, path := range fsf.Files {
+		if _, err := os.Stat(strings.TrimSpace(path)); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		found, err := fsf.MatchFile(path)
+		if err != nil {
+			return err
+		}
+		for _, f := range found {
+			if _, ok := seen[f.Path]; !ok {
+				seen[f.Path] = struct{}{}
+				*matches = append(*matches, f)
+			}
+		}
+	}
+	return nil
+}
+
 func (fsf *FileSystemFinder) matchFileInRoot(
 	absPath string,
 	info os.FileInfo,
@@ -346,6 +540,72 @@ func (fsf *FileSystemFinder) findOne(pathRoot string, seenMap map[string]struct{
 	return matchingFiles, nil
 }
 
+// usesGitFilters reports whether any filter needs a git work tree.
+func (fsf *FileSystemFinder) usesGitFilters() bool {
+	return fsf.Gitignore || len(fsf.IgnoreFiles) > 0 || fsf.ChangedSince != "" || fsf.Staged
+}
+
+// findFS walks every path root in fsf.FS. Paths are kept as they are in
+// the file system, since it has no notion of absolute paths.
+func (fsf *FileSystemFinder) findFS() ([]FileMetadata, error) {
+	if fsf.usesGitFilters() {
+		return nil, errFSGitFilters
+	}
+
+	seen := make(map[string]struct{})
+	matches := make([]FileMetadata, 0)
+	for _, pathRoot := range fsf.PathRoots {
+		root := path.Clean(filepath.ToSlash(strings.TrimSpace(pathRoot)))
+		if _, err := fs.Stat(fsf.FS, root); err != nil {
+			return nil, err
+		}
+
+		// handleDir counts OS path separators. Entries under "." have no
+		// prefix, so their first level has no separator.
+		var depth int
+		if fsf.Depth != nil {
+			depth = *fsf.Depth
+		}
+		maxDepth := strings.Count(filepath.FromSlash(root), string(os.PathSeparator)) + depth
+		if root == "." {
+			maxDepth--
+		}
+
+		err := fs.WalkDir(fsf.FS, root, func(p string, dirEntry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if dirEntry.IsDir() {
+				if p == "." {
+					return nil
+				}
+				return fsf.handleDir(filepath.FromSlash(p), dirEntry, maxDepth)
+			}
+			if kind := archiveKindOf(p); fsf.Archives && kind != notArchive {
+				data, err := fs.ReadFile(fsf.FS, p)
+				if err != nil {
+					addUnreadableArchive(p, err, seen, &matches)
+					return nil
+				}
+				return fsf.addArchiveMembers(p, kind, data, seen, &matches)
+			}
+			fileType, notes, ok, err := fsf.typeFile(p, func() (io.ReadCloser, error) { return fsf.FS.Open(p) })
+			if err != nil || !ok || fsf.isFileTypeExcluded(fileType) {
+				return err
+			}
+			if _, ok := seen[p]; !ok {
+				seen[p] = struct{}{}
+				matches = append(matches, FileMetadata{Name: dirEntry.Name(), Path: p, FileType: fileType, TypeNote: notes.note, TypeWarning: notes.warning, FS: fsf.FS})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
 // gitignoreMatcher lazily loads .gitignore files during WalkDir.
 type gitignoreMatcher struct {
 	patterns      []gitignore.Pattern
@@ -477,7 +737,12 @@ func findRepoRoot(absDir string) string {
 }
 
 func (fsf *FileSystemFinder) handleDir(path string, dirEntry fs.DirEntry, maxDepth int) error {
-	_, isExcluded := fsf.ExcludeDirs[dirEntry.Name()]
+	// A directory is excluded by the configuration of the one holding it.
+	parent, err := fsf.finderFor(path)
+	if err != nil {
+		return err
+	}
+	_, isExcluded := parent.ExcludeDirs[dirEntry.Name()]
 	if isExcluded || (fsf.Depth != nil && strings.Count(path, string(os.PathSeparator)) > maxDepth) {
 		return filepath.SkipDir
 	}
@@ -485,41 +750,162 @@ func (fsf *FileSystemFinder) handleDir(path string, dirEntry fs.DirEntry, maxDep
 }
 
 func (fsf *FileSystemFinder) handleFile(path string, dirEntry fs.DirEntry, seenMap map[string]struct{}, matchingFiles *[]FileMetadata) error {
-	walkFileName := filepath.Base(path)
-	walkFileExtension := strings.TrimPrefix(filepath.Ext(path), ".")
-	extensionLowerCase := strings.ToLower(walkFileExtension)
-	pathForPatternMatch := filepath.ToSlash(path)
+	fsf, err := fsf.finderFor(path)
+	if err != nil {
+		return err
+	}
+	if kind := archiveKindOf(path); fsf.Archives && kind != notArchive {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(absPath)
+		if err != nil {
+			addUnreadableArchive(absPath, err, seenMap, matchingFiles)
+			return nil
+		}
+		return fsf.addArchiveMembers(absPath, kind, data, seenMap, matchingFiles)
+	}
+	fileType, notes, ok, err := fsf.typeFile(path, func() (io.ReadCloser, error) { return os.Open(path) })
+	if err != nil || !ok {
+		return err
+	}
+	return fsf.addFileIfNotExcluded(path, dirEntry, fileType, notes, seenMap, matchingFiles)
+}
+
+// FileTypeFor returns the file type the finder assigns to the file at path
+// holding content, as Find types the files it walks: type overrides, type
+// hints, known files, extensions, sniffing and excluded file types all
+// apply. Unlike MatchFile it does not require path to exist or to be under
+// a search path.
+func (fsf FileSystemFinder) FileTypeFor(path string, content []byte) (filetype.FileType, bool, error) {
+	fsf.extCache = nil
+	fsf.dirFinders = nil
+	finder, err := fsf.finderFor(path)
+	if err != nil {
+		return filetype.FileType{}, false, err
+	}
+	fileType, _, ok, err := finder.typeFile(path, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	})
+	if err != nil || !ok || finder.isFileTypeExcluded(fileType) {
+		return filetype.FileType{}, false, err
+	}
+	return fileType, true, nil
+}
 
-	// Check type overrides first (user-specified mappings take priority)
+// overrideTypeFor returns the type of the first type override matching
+// path, as the finder configures it.
+func (fsf *FileSystemFinder) overrideTypeFor(path string) (filetype.FileType, bool, error) {
+	pathForPatternMatch := filepath.ToSlash(path)
 	for _, override := range fsf.TypeOverrides {
-		matched, err := doublestar.PathMatch(override.Pattern, pathForPatternMatch)
+		matchPath := pathForPatternMatch
+		if override.Dir != "" {
+			absPath, err := filepath.Abs(path)
+			if err != nil {
+				return filetype.FileType{}, false, err
+			}
+			rel, err := filepath.Rel(override.Dir, absPath)
+			if err != nil || !containsPath(override.Dir, absPath) {
+				continue
+			}
+			matchPath = filepath.ToSlash(rel)
+		}
+		matched, err := doublestar.PathMatch(override.Pattern, matchPath)
 		if err != nil {
-			return err
+			return filetype.FileType{}, false, err
 		}
 		if matched {
-			return fsf.addFileIfNotExcluded(path, dirEntry, override.FileType, seenMap, matchingFiles)
+			for _, fileType := range fsf.FileTypes {
+				if fileType.Name == override.FileType.Name {
+					return fileType, true, nil
+				}
+			}
+			return override.FileType, true, nil
 		}
 	}
+	return filetype.FileType{}, false, nil
+}
+
+// nameTypeFor returns the type of path from its known file name or its
+// extension.
+func (fsf *FileSystemFinder) nameTypeFor(path string) (filetype.FileType, bool) {
+	walkFileName := filepath.Base(path)
+	walkFileExtension := strings.TrimPrefix(filepath.Ext(path), ".")
+	extensionLowerCase := strings.ToLower(walkFileExtension)
 
-	// Fall back to built-in file types.
 	// KnownFiles matches take priority over extension matches so that
 	// files like tsconfig.json resolve to jsonc (not json).
 	for _, fileType := range fsf.FileTypes {
 		if _, isKnownFile := fileType.KnownFiles[walkFileName]; isKnownFile {
-			return fsf.addFileIfNotExcluded(path, dirEntry, fileType, seenMap, matchingFiles)
+			return fileType, true
 		}
 	}
 	if fsf.isExtensionCached(extensionLowerCase) {
-		return nil
+		return filetype.FileType{}, false
 	}
 	for _, fileType := range fsf.FileTypes {
 		if _, hasExtension := fileType.Extensions[extensionLowerCase]; hasExtension {
-			return fsf.addFileIfNotExcluded(path, dirEntry, fileType, seenMap, matchingFiles)
+			return fileType, true
 		}
 	}
 
 	fsf.cacheUnsupportedExtension(extensionLowerCase)
-	return nil
+	return filetype.FileType{}, false
+}
+
+// finderFor returns the finder that applies to the file or directory at
+// path: fsf with the DirConfig of the directory holding path on top.
+func (fsf *FileSystemFinder) finderFor(path string) (*FileSystemFinder, error) {
+	if fsf.DirConfigs == nil || fsf.FS != nil {
+		return fsf, nil
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(absPath)
+	if finder, ok := fsf.dirFinders[dir]; ok {
+		return finder, nil
+	}
+	dirConfig, err := fsf.DirConfigs(dir)
+	if err != nil {
+		return nil, err
+	}
+	finder := fsf
+	if dirConfig != nil {
+		finder = fsf.withDirConfig(dirConfig)
+	}
+	if fsf.dirFinders != nil {
+		fsf.dirFinders[dir] = finder
+	}
+	return finder, nil
+}
+
+// withDirConfig returns a copy of fsf with dirConfig applied.
+func (fsf *FileSystemFinder) withDirConfig(dirConfig *DirConfig) *FileSystemFinder {
+	finder := *fsf
+	finder.DirConfigs = nil
+	finder.extCache = make(map[string]struct{})
+	finder.ExcludeDirs = tools.ArrToMap(dirConfig.ExcludeDirs...)
+	maps.Copy(finder.ExcludeDirs, fsf.ExcludeDirs)
+	finder.ExcludeFileTypes = tools.ArrToMap(dirConfig.ExcludeFileTypes...)
+	maps.Copy(finder.ExcludeFileTypes, fsf.ExcludeFileTypes)
+	finder.TypeOverrides = append(slices.Clone(dirConfig.TypeOverrides), fsf.TypeOverrides...)
+	for i := range finder.TypeOverrides {
+		finder.TypeOverrides[i].Pattern = filepath.ToSlash(finder.TypeOverrides[i].Pattern)
+	}
+	if len(dirConfig.FileTypes) > 0 {
+		finder.FileTypes = slices.Clone(fsf.FileTypes)
+		for i, ft := range finder.FileTypes {
+			for _, configured := range dirConfig.FileTypes {
+				if configured.Name == ft.Name {
+					finder.FileTypes[i] = configured
+				}
+			}
+		}
+	}
+	return &finder
 }
 
 func (fsf *FileSystemFinder) isExtensionCached(extension string) bool {
@@ -537,11 +923,11 @@ func (fsf *FileSystemFinder) cacheUnsupportedExtension(extension string) {
 	fsf.extCache[extension] = struct{}{}
 }
 
-func (fsf *FileSystemFinder) addFileIfNotExcluded(path string, dirEntry fs.DirEntry, fileType filetype.FileType, seenMap map[string]struct{}, matchingFiles *[]FileMetadata) error {
+func (fsf *FileSystemFinder) addFileIfNotExcluded(path string, dirEntry fs.DirEntry, fileType filetype.FileType, notes typeNotes, seenMap map[string]struct{}, matchingFiles *[]FileMetadata) error {
 	if fsf.isFileTypeExcluded(fileType) {
 		return nil
 	}
-	return fsf.addFile(path, dirEntry, fileType, seenMap, matchingFiles)
+	return fsf.addFile(path, dirEntry, fileType, notes, seenMap, matchingFiles)
 }
 
 func (fsf *FileSystemFinder) isFileTypeExcluded(fileType filetype.FileType) bool {
@@ -556,14 +942,14 @@ func (fsf *FileSystemFinder) isFileTypeExcluded(fileType filetype.FileType) bool
 	return false
 }
 
-func (*FileSystemFinder) addFile(path string, dirEntry fs.DirEntry, fileType filetype.FileType, seenMap map[string]struct{}, matchingFiles *[]FileMetadata) error {
+func (*FileSystemFinder) addFile(path string, dirEntry fs.DirEntry, fileType filetype.FileType, notes typeNotes, seenMap map[string]struct{}, matchingFiles *[]FileMetadata) error {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return err
 	}
 
 	if _, seen := seenMap[absPath]; !seen {
-		*matchingFiles = append(*matchingFiles, FileMetadata{dirEntry.Name(), absPath, fileType})
+		*matchingFiles = append(*matchingFiles, FileMetadata{Name: dirEntry.Name(), Path: absPath, FileType: fileType, TypeNote: notes.note, TypeWarning: notes.warning})
 		seenMap[absPath] = struct{}{}
 	}
 
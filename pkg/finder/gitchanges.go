@@ -0,0 +1,182 @@
+package finder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// filterChanged keeps only the matches that git reports as changed for the
+// finder's ChangedSince or Staged mode. Every path root must be inside a
+// git repository. Deleted files never appear in matches, so they are
+// ignored without special handling.
+func (fsf *FileSystemFinder) filterChanged(matches []FileMetadata) ([]FileMetadata, error) {
+	changed := make(map[string]struct{})
+	loaded := make(map[string]struct{})
+	for _, pathRoot := range fsf.PathRoots {
+		absRoot, err := filepath.Abs(pathRoot)
+		if err != nil {
+			return nil, err
+		}
+		repoRoot := findRepoRoot(absRoot)
+		if repoRoot == "" {
+			return nil, fmt.Errorf("%s is not inside a git repository", pathRoot)
+		}
+		if _, ok := loaded[repoRoot]; ok {
+			continue
+		}
+		loaded[repoRoot] = struct{}{}
+
+		paths, err := fsf.changedPaths(repoRoot)
+		if err != nil {
+			return nil, fmt.Errorf("listing changed files in %s: %w", repoRoot, err)
+		}
+		for _, p := range paths {
+			changed[filepath.Join(repoRoot, filepath.FromSlash(p))] = struct{}{}
+		}
+	}
+
+	filtered := make([]FileMetadata, 0, len(matches))
+	for _, m := range matches {
+		// Archive members are kept when their archive changed.
+		p := strings.TrimSuffix(m.Path, ArchiveSeparator+m.fsPath)
+		if _, ok := changed[p]; ok {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered, nil
+}
+
+// changedPaths returns the slash-separated, repository-relative paths of
+// files changed in the repository at repoRoot.
+//
+// In Staged mode these are the files added, modified or renamed in the
+// index, as `git diff --cached --diff-filter=AMR` would list them. In
+// ChangedSince mode they are the files added, modified or renamed between
+// the merge base of ChangedSince and HEAD, and HEAD, as
+// `git diff --diff-filter=AMR <ref>...HEAD` would list them. Uncommitted
+// and untracked files are not included, and neither mode reads the
+// working tree.
+func (fsf *FileSystemFinder) changedPaths(repoRoot string) ([]string, error) {
+	repo, err := git.PlainOpen(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	if fsf.Staged {
+		return stagedPaths(repo)
+	}
+	return committedSince(repo, fsf.ChangedSince)
+}
+
+// stagedPaths returns the paths whose index entry differs from HEAD. A
+// renamed file is listed under its new path, and unmerged entries are
+// skipped.
+func stagedPaths(repo *git.Repository) ([]string, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	headFiles := make(map[string]plumbing.Hash)
+	ref, err := repo.Head()
+	switch {
+	case err == nil:
+		head, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return nil, err
+		}
+		tree, err := head.Tree()
+		if err != nil {
+			return nil, err
+		}
+		walker := object.NewTreeWalker(tree, true, nil)
+		defer walker.Close()
+		for {
+			name, entry, err := walker.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if entry.Mode.IsFile() {
+				headFiles[name] = entry.Hash
+			}
+		}
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+		// No commits yet, so every staged file is added.
+	default:
+		return nil, fmt.Errorf("resolving HEAD: %w", err)
+	}
+
+	var paths []string
+	for _, e := range idx.Entries {
+		// Unmerged entries have a non-zero stage.
+		if e.Stage != 0 {
+			continue
+		}
+		if hash, ok := headFiles[e.Name]; !ok || hash != e.Hash {
+			paths = append(paths, e.Name)
+		}
+	}
+	return paths, nil
+}
+
+// committedSince returns the paths changed between the merge base of ref
+// and HEAD, and HEAD.
+func committedSince(repo *git.Repository, ref string) ([]string, error) {
+	base, err := resolveCommit(repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %q: %w", ref, err)
+	}
+	head, err := resolveCommit(repo, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("resolving HEAD: %w", err)
+	}
+
+	bases, err := base.MergeBase(head)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("%q has no common ancestor with HEAD", ref)
+	}
+
+	baseTree, err := bases[0].Tree()
+	if err != nil {
+		return nil, err
+	}
+	headTree, err := head.Tree()
+	if err != nil {
+		return nil, err
+	}
+	changes, err := object.DiffTree(baseTree, headTree)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(changes))
+	for _, c := range changes {
+		if c.To.Name != "" {
+			paths = append(paths, c.To.Name)
+		}
+	}
+	return paths, nil
+}
+
+func resolveCommit(repo *git.Repository, rev string) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return nil, errors.New("unknown revision")
+		}
+		return nil, err
+	}
+	return repo.CommitObject(*hash)
+}
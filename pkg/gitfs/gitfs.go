@@ -0,0 +1,258 @@
+// Package gitfs exposes the tree of a git revision as an fs.FS.
+//
+// Files are read straight from the repository's object database, so a
+// revision can be validated in a bare repository, or without checking it
+// out, and nothing is written to a work tree. Paths are relative to the
+// root of the repository. Symbolic links and submodules are left out.
+package gitfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// FS is the tree of a single git revision. It implements fs.FS and
+// fs.ReadDirFS, and is safe for concurrent use.
+type FS struct {
+	// mu serializes reads from the object database, which go-git does not
+	// guard.
+	mu    sync.Mutex
+	repo  *git.Repository
+	nodes map[string]*node
+}
+
+// node is a file or directory of the tree. nodes is built once by Open and
+// never changed, so it is read without locking.
+type node struct {
+	entry object.TreeEntry
+	// children are the names of a directory's entries, sorted.
+	children []string
+}
+
+// Open returns the tree of rev in the repository at gitDir. gitDir may be
+// a bare repository, a .git directory or a work tree; when it is empty the
+// repository containing the current directory is used. rev is anything
+// that names a commit, such as a branch, tag or abbreviated hash, or the
+// full hash of a tree.
+func Open(gitDir, rev string) (*FS, error) {
+	var repo *git.Repository
+	var err error
+	if gitDir == "" {
+		repo, err = git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+	} else {
+		repo, err = git.PlainOpen(gitDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening git repository: %w", err)
+	}
+
+	tree, err := resolveTree(repo, rev)
+	if err != nil {
+		return nil, fmt.Errorf("resolving revision %q: %w", rev, err)
+	}
+	nodes, err := index(tree)
+	if err != nil {
+		return nil, fmt.Errorf("reading revision %q: %w", rev, err)
+	}
+	return &FS{repo: repo, nodes: nodes}, nil
+}
+
+func resolveTree(repo *git.Repository, rev string) (*object.Tree, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err == nil {
+		commit, err := repo.CommitObject(*hash)
+		if err != nil {
+			return nil, err
+		}
+		return commit.Tree()
+	}
+	// ResolveRevision only resolves commits and tags.
+	if plumbing.IsHash(rev) {
+		if tree, treeErr := repo.TreeObject(plumbing.NewHash(rev)); treeErr == nil {
+			return tree, nil
+		}
+	}
+	return nil, err
+}
+
+// index walks tree and returns its files and directories by path.
+func index(tree *object.Tree) (map[string]*node, error) {
+	root := &node{entry: object.TreeEntry{Name: ".", Mode: filemode.Dir, Hash: tree.Hash}}
+	nodes := map[string]*node{".": root}
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !included(entry.Mode) {
+			continue
+		}
+		nodes[name] = &node{entry: entry}
+		parent := nodes[path.Dir(name)]
+		parent.children = append(parent.children, entry.Name)
+	}
+	for _, n := range nodes {
+		slices.Sort(n.children)
+	}
+	return nodes, nil
+}
+
+// Open opens the named file or directory.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	n, ok := fsys.nodes[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if n.entry.Mode == filemode.Dir {
+		return &dir{fsys: fsys, path: name, node: n, info: entryInfo(&n.entry, 0)}, nil
+	}
+
+	data, err := fsys.readBlob(n.entry.Hash)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &file{Reader: bytes.NewReader(data), info: entryInfo(&n.entry, int64(len(data)))}, nil
+}
+
+// ReadDir reads the named directory and returns its entries sorted by
+// name.
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := f.(*dir)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return d.ReadDir(-1)
+}
+
+// readBlob returns the contents of the blob with the given hash.
+func (fsys *FS) readBlob(hash plumbing.Hash) ([]byte, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	blob, err := fsys.repo.BlobObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// blobSize returns the size of the blob with the given hash without
+// reading it.
+func (fsys *FS) blobSize(hash plumbing.Hash) (int64, error) {
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	return fsys.repo.Storer.EncodedObjectSize(hash)
+}
+
+// included reports whether tree entries with mode m are part of the FS.
+func included(m filemode.FileMode) bool {
+	switch m {
+	case filemode.Dir, filemode.Regular, filemode.Executable, filemode.Deprecated:
+		return true
+	default:
+		return false
+	}
+}
+
+func entryInfo(entry *object.TreeEntry, size int64) fileInfo {
+	info := fileInfo{name: entry.Name, size: size, mode: 0o444}
+	if entry.Mode == filemode.Dir {
+		info.mode = fs.ModeDir | 0o555
+	}
+	return info
+}
+
+type fileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (fi fileInfo) Name() string      { return fi.name }
+func (fi fileInfo) Size() int64       { return fi.size }
+func (fi fileInfo) Mode() fs.FileMode { return fi.mode }
+func (fileInfo) ModTime() time.Time   { return time.Time{} }
+func (fi fileInfo) IsDir() bool       { return fi.mode.IsDir() }
+func (fileInfo) Sys() any             { return nil }
+
+// file is an open blob.
+type file struct {
+	*bytes.Reader
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (*file) Close() error                 { return nil }
+
+// dir is an open tree.
+type dir struct {
+	fsys    *FS
+	path    string
+	node    *node
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (*dir) Close() error                 { return nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+// ReadDir returns the next n entries of the tree, or all remaining entries
+// when n <= 0.
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.entries == nil {
+		d.entries = make([]fs.DirEntry, 0, len(d.node.children))
+		for _, name := range d.node.children {
+			child := d.fsys.nodes[path.Join(d.path, name)]
+			var size int64
+			if child.entry.Mode != filemode.Dir {
+				size, _ = d.fsys.blobSize(child.entry.Hash)
+			}
+			d.entries = append(d.entries, fs.FileInfoToDirEntry(entryInfo(&child.entry, size)))
+		}
+	}
+
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
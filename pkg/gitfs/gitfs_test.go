@@ -0,0 +1,131 @@
+package gitfs
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/internal/testhelper"
+)
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.CommandContext(context.Background(), "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %s: %v", args, out, err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitRepo creates a repository with a tagged commit, then changes the
+// work tree without committing.
+func commitRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "config", "user.email", "test@test.com")
+	runGit(t, dir, "config", "user.name", "test")
+	testhelper.WriteFile(t, dir, "app.json", `{"committed": true}`)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "conf"), 0755))
+	testhelper.WriteFile(t, filepath.Join(dir, "conf"), "db.yaml", "a: 1\n")
+	require.NoError(t, os.Symlink("app.json", filepath.Join(dir, "link.json")))
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "base")
+	runGit(t, dir, "tag", "v1")
+
+	testhelper.WriteFile(t, dir, "app.json", `{"committed": false}`)
+	testhelper.WriteFile(t, dir, "untracked.json", `{}`)
+	return dir
+}
+
+func Test_Open(t *testing.T) {
+	dir := commitRepo(t)
+	fsys, err := Open(dir, "v1")
+	require.NoError(t, err)
+	require.NoError(t, fstest.TestFS(fsys, "app.json", "conf/db.yaml"))
+
+	data, err := fs.ReadFile(fsys, "app.json")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"committed": true}`, string(data))
+
+	_, err = fs.Stat(fsys, "untracked.json")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = fs.Stat(fsys, "link.json")
+	require.ErrorIs(t, err, fs.ErrNotExist, "symlinks are left out")
+}
+
+func Test_OpenRevisions(t *testing.T) {
+	dir := commitRepo(t)
+	bare := filepath.Join(t.TempDir(), "repo.git")
+	runGit(t, dir, "clone", "-q", "--bare", dir, bare)
+	treeHash := runGit(t, dir, "rev-parse", "HEAD^{tree}")
+	commitHash := runGit(t, dir, "rev-parse", "HEAD")
+
+	for _, tt := range []struct{ gitDir, rev string }{
+		{bare, "v1"},
+		{bare, commitHash[:10]},
+		{filepath.Join(dir, ".git"), "HEAD"},
+		{dir, treeHash},
+	} {
+		fsys, err := Open(tt.gitDir, tt.rev)
+		require.NoError(t, err, tt.rev)
+		_, err = fs.Stat(fsys, "conf/db.yaml")
+		require.NoError(t, err, tt.rev)
+	}
+
+	t.Chdir(filepath.Join(dir, "conf"))
+	_, err := Open("", "HEAD")
+	require.NoError(t, err, "the repository is found from the current directory")
+
+	_, err = Open(bare, "missing")
+	require.ErrorContains(t, err, `resolving revision "missing"`)
+	_, err = Open(t.TempDir(), "HEAD")
+	require.ErrorContains(t, err, "opening git repository")
+}
+
+// Test_OpenConcurrent reads nested files from many goroutines, as the
+// validator's workers do; run it with -race.
+func Test_OpenConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "config", "user.email", "test@test.com")
+	runGit(t, dir, "config", "user.name", "test")
+	var names []string
+	for _, sub := range []string{"a/b", "a/c", "d/e"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, sub), 0755))
+		for _, file := range []string{"one.json", "two.json", "three.json"} {
+			testhelper.WriteFile(t, filepath.Join(dir, sub), file, `{}`)
+			names = append(names, sub+"/"+file)
+		}
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "base")
+
+	fsys, err := Open(dir, "HEAD")
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Go(func() {
+			for _, name := range names {
+				data, err := fs.ReadFile(fsys, name)
+				assert.NoError(t, err, name)
+				assert.Equal(t, "{}", string(data), name)
+				_, err = fs.ReadDir(fsys, path.Dir(name))
+				assert.NoError(t, err, name)
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,277 @@
+// Package lsp implements a Language Server Protocol server that publishes
+// validation findings as editor diagnostics.
+//
+// The server speaks LSP over a byte stream, normally stdin and stdout, and
+// keeps the text of every open document. Documents are validated when they
+// are opened, changed or saved, and every finding in the resulting report is
+// published with textDocument/publishDiagnostics, positioned with the line
+// and column the validator reported. Only full document sync is supported,
+// and only documents with file URIs are validated.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+	"github.com/Boeing/config-file-validator/v2/pkg/tools"
+)
+
+// codeServerNotInitialized is returned for requests sent before initialize.
+const codeServerNotInitialized = -32002
+
+// diagnosticSource names the server in every diagnostic it publishes.
+const diagnosticSource = "config-file-validator"
+
+// ErrExitWithoutShutdown is returned by Serve when the client sends exit
+// without a preceding shutdown request. By LSP convention the server then
+// exits with status 1.
+var ErrExitWithoutShutdown = errors.New("exit received before shutdown")
+
+// ValidateFunc validates content as the file at path. ok is false when path
+// is not a file the validator handles; no diagnostics are published for it.
+type ValidateFunc func(path string, content []byte) (report reporter.Report, ok bool)
+
+// Server is a Language Server Protocol server. Use NewServer to create one
+// and Serve to run it.
+type Server struct {
+	validate    ValidateFunc
+	version     string
+	out         io.Writer
+	docs        map[string]string // URI -> text
+	initialized bool
+	shutdown    bool
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithVersion sets the server version reported to the client on initialize.
+func WithVersion(version string) Option {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+// NewServer returns a Server that validates documents with validate.
+func NewServer(validate ValidateFunc, opts ...Option) *Server {
+	s := &Server{
+		validate: validate,
+		docs:     make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Serve reads messages from r and writes responses and notifications to w
+// until the client sends exit or r is closed. It returns nil after an
+// orderly shutdown and exit, and ErrExitWithoutShutdown if the client exits
+// without shutting the server down first.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	in := bufio.NewReader(r)
+	for {
+		body, err := readMessage(in)
+		if errors.Is(err, io.EOF) {
+			if s.shutdown {
+				return nil
+			}
+			return ErrExitWithoutShutdown
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.reply(json.RawMessage("null"), nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if s.shutdown {
+				return nil
+			}
+			return ErrExitWithoutShutdown
+		}
+		if err := s.handle(req); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a single request or notification. It returns an error
+// only when writing to the client fails.
+func (s *Server) handle(req request) error {
+	isRequest := req.ID != nil
+	if !s.initialized && req.Method != "initialize" {
+		if isRequest {
+			return s.reply(*req.ID, nil, &responseError{Code: codeServerNotInitialized, Message: "server not initialized"})
+		}
+		return nil
+	}
+	if s.shutdown && isRequest {
+		return s.reply(*req.ID, nil, &responseError{Code: codeInvalidRequest, Message: "server is shutting down"})
+	}
+
+	switch req.Method {
+	case "initialize":
+		s.initialized = true
+		return s.reply(*req.ID, initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync: textDocumentSyncOptions{
+					OpenClose: true,
+					Change:    textDocumentSyncFull,
+					Save:      true,
+				},
+			},
+			ServerInfo: serverInfo{Name: "validator", Version: s.version},
+		}, nil)
+	case "shutdown":
+		s.shutdown = true
+		return s.reply(*req.ID, nil, nil)
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		return s.publish(params.TextDocument.URI, &params.TextDocument.Version)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		// With full sync the last change holds the whole document.
+		s.docs[params.TextDocument.URI] = params.ContentChanges[len(params.ContentChanges)-1].Text
+		return s.publish(params.TextDocument.URI, &params.TextDocument.Version)
+	case "textDocument/didSave":
+		var params didSaveParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		if params.Text != nil {
+			s.docs[params.TextDocument.URI] = *params.Text
+		}
+		// Revalidate even when the text is unchanged, since a schema the
+		// document uses may have been saved.
+		return s.publish(params.TextDocument.URI, nil)
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []diagnostic{},
+		})
+	}
+
+	if isRequest {
+		return s.reply(*req.ID, nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method})
+	}
+	return nil
+}
+
+// publish validates the open document at uri and publishes its diagnostics.
+func (s *Server) publish(uri string, version *int) error {
+	text, open := s.docs[uri]
+	if !open {
+		return nil
+	}
+	path, ok := tools.FileURLToPath(uri)
+	if !ok {
+		return nil
+	}
+	report, ok := s.validate(path, []byte(text))
+	if !ok {
+		return nil
+	}
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Version:     version,
+		Diagnostics: diagnostics(report, text),
+	})
+}
+
+func (s *Server) reply(id json.RawMessage, result any, respErr *responseError) error {
+	resp := response{JSONRPC: "2.0", ID: id, Error: respErr}
+	if respErr == nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("encoding result: %w", err)
+		}
+		resp.Result = (*json.RawMessage)(&raw)
+	}
+	return writeMessage(s.out, resp)
+}
+
+func (s *Server) notify(method string, params any) error {
+	return writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// diagnostics converts the findings and warnings in report to LSP
+// diagnostics against the document text.
+func diagnostics(report reporter.Report, text string) []diagnostic {
+	lines := strings.Split(text, "\n")
+	diags := make([]diagnostic, 0, len(report.ValidationErrors)+len(report.Warnings))
+	for i, msg := range report.ValidationErrors {
+		line, col := report.Position(i)
+		// Diagnostics carry the position separately from the message.
+		_, text := reporter.SplitFinding(msg)
+		diags = append(diags, diagnostic{
+			Range:    findingRange(lines, line, col),
+			Severity: diagnosticSeverity(report.Severity(i)),
+			Code:     report.Rule(i),
+			Source:   diagnosticSource,
+			Message:  text,
+		})
+	}
+	for _, warning := range report.Warnings {
+		diags = append(diags, diagnostic{
+			Range:    findingRange(lines, 0, 0),
+			Severity: diagnosticWarning,
+			Source:   diagnosticSource,
+			Message:  warning,
+		})
+	}
+	return diags
+}
+
+func diagnosticSeverity(sev reporter.Severity) int {
+	switch sev {
+	case reporter.SeverityWarning:
+		return diagnosticWarning
+	case reporter.SeverityInfo:
+		return diagnosticInformation
+	default:
+		return diagnosticError
+	}
+}
+
+// findingRange returns the range a finding at the 1-based line and column
+// covers: from the column, or the start of the line when the column is
+// unknown, to the end of the line. Findings without a line are placed on
+// the first line. Columns count characters.
+func findingRange(lines []string, line, col int) lspRange {
+	idx := min(max(line-1, 0), len(lines)-1)
+	content := []rune(strings.TrimSuffix(lines[idx], "\r"))
+	start := min(max(col-1, 0), len(content))
+	return lspRange{
+		Start: position{Line: idx, Character: utf16Len(content[:start])},
+		End:   position{Line: idx, Character: utf16Len(content)},
+	}
+}
+
+func utf16Len(runes []rune) int {
+	return len(utf16.Encode(runes))
+}
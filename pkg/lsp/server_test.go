@@ -0,0 +1,196 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+)
+
+func frame(t *testing.T, msgs ...string) io.Reader {
+	t.Helper()
+	var b bytes.Buffer
+	for _, msg := range msgs {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	return &b
+}
+
+func readAll(t *testing.T, out *bytes.Buffer) []map[string]any {
+	t.Helper()
+	r := bufio.NewReader(out)
+	var msgs []map[string]any
+	for {
+		body, err := readMessage(r)
+		if errors.Is(err, io.EOF) {
+			return msgs
+		}
+		require.NoError(t, err)
+		var msg map[string]any
+		require.NoError(t, json.Unmarshal(body, &msg))
+		msgs = append(msgs, msg)
+	}
+}
+
+const (
+	initialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+	shutdown   = `{"jsonrpc":"2.0","id":9,"method":"shutdown"}`
+	exit       = `{"jsonrpc":"2.0","method":"exit"}`
+)
+
+func failingValidator(path string, content []byte) (reporter.Report, bool) {
+	if !strings.HasSuffix(path, ".json") {
+		return reporter.Report{}, false
+	}
+	if strings.Contains(string(content), "bad") {
+		return reporter.Report{
+			ValidationErrors: []string{"syntax: line 2, column 3: bad value", "schema: name is required"},
+			ErrorLines:       []int{2, 0},
+			ErrorColumns:     []int{3, 0},
+			ErrorRules:       []string{"json/syntax", "schema/required"},
+			ErrorSeverities:  []reporter.Severity{reporter.SeverityError, reporter.SeverityWarning},
+			Warnings:         []string{"schema map ignored"},
+		}, true
+	}
+	return reporter.Report{IsValid: true}, true
+}
+
+func Test_ServePublishesDiagnostics(t *testing.T) {
+	t.Parallel()
+	in := frame(t,
+		initialize,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///project/a.json","languageId":"json","version":1,"text":"{\n  bad\n}"}}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///project/a.json","version":2},"contentChanges":[{"text":"{}"}]}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///project/notes.md","version":1,"text":"bad"}}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"untitled:Untitled-1","version":1,"text":"bad"}}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didClose","params":{"textDocument":{"uri":"file:///project/a.json"}}}`,
+		shutdown,
+		exit,
+	)
+	var out bytes.Buffer
+	require.NoError(t, NewServer(failingValidator, WithVersion("1.2.3")).Serve(in, &out))
+
+	msgs := readAll(t, &out)
+	require.Len(t, msgs, 5)
+
+	result := msgs[0]["result"].(map[string]any)
+	require.Equal(t, map[string]any{"openClose": true, "change": float64(1), "save": true},
+		result["capabilities"].(map[string]any)["textDocumentSync"])
+	require.Equal(t, "1.2.3", result["serverInfo"].(map[string]any)["version"])
+
+	require.Equal(t, "textDocument/publishDiagnostics", msgs[1]["method"])
+	params := msgs[1]["params"].(map[string]any)
+	require.Equal(t, float64(1), params["version"])
+	diags := params["diagnostics"].([]any)
+	require.Len(t, diags, 3)
+	first := diags[0].(map[string]any)
+	require.Equal(t, "bad value", first["message"])
+	require.Equal(t, "json/syntax", first["code"])
+	require.Equal(t, float64(1), first["severity"])
+	require.Equal(t, map[string]any{
+		"start": map[string]any{"line": float64(1), "character": float64(2)},
+		"end":   map[string]any{"line": float64(1), "character": float64(5)},
+	}, first["range"])
+	second := diags[1].(map[string]any)
+	require.Equal(t, "name is required", second["message"])
+	require.Equal(t, float64(2), second["severity"])
+	require.Equal(t, "schema map ignored", diags[2].(map[string]any)["message"])
+
+	require.Empty(t, msgs[2]["params"].(map[string]any)["diagnostics"])
+	require.Equal(t, float64(2), msgs[2]["params"].(map[string]any)["version"])
+
+	require.Equal(t, "file:///project/a.json", msgs[3]["params"].(map[string]any)["uri"])
+	require.Empty(t, msgs[3]["params"].(map[string]any)["diagnostics"])
+
+	require.Equal(t, float64(9), msgs[4]["id"])
+	require.Contains(t, msgs[4], "result")
+	require.Nil(t, msgs[4]["result"])
+}
+
+func Test_ServeSaveRevalidates(t *testing.T) {
+	t.Parallel()
+	calls := 0
+	validate := func(string, []byte) (reporter.Report, bool) {
+		calls++
+		return reporter.Report{IsValid: true}, true
+	}
+	in := frame(t,
+		initialize,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.json","version":1,"text":"{}"}}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didSave","params":{"textDocument":{"uri":"file:///a.json"}}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didSave","params":{"textDocument":{"uri":"file:///closed.json"}}}`,
+		shutdown,
+		exit,
+	)
+	var out bytes.Buffer
+	require.NoError(t, NewServer(validate).Serve(in, &out))
+	require.Equal(t, 2, calls)
+}
+
+func Test_ServeErrors(t *testing.T) {
+	t.Parallel()
+	in := frame(t,
+		`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`,
+		initialize,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{}}`,
+		`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":2}}`,
+		`not json`,
+	)
+	var out bytes.Buffer
+	err := NewServer(failingValidator).Serve(in, &out)
+	require.ErrorIs(t, err, ErrExitWithoutShutdown)
+
+	msgs := readAll(t, &out)
+	require.Len(t, msgs, 4)
+	require.Equal(t, float64(codeServerNotInitialized), msgs[0]["error"].(map[string]any)["code"])
+	require.Equal(t, float64(codeMethodNotFound), msgs[2]["error"].(map[string]any)["code"])
+	require.Nil(t, msgs[3]["id"])
+	require.Equal(t, float64(codeParseError), msgs[3]["error"].(map[string]any)["code"])
+}
+
+func Test_ServeRequestAfterShutdown(t *testing.T) {
+	t.Parallel()
+	in := frame(t, initialize, shutdown, `{"jsonrpc":"2.0","id":3,"method":"initialize","params":{}}`, exit)
+	var out bytes.Buffer
+	require.NoError(t, NewServer(failingValidator).Serve(in, &out))
+	msgs := readAll(t, &out)
+	require.Equal(t, float64(codeInvalidRequest), msgs[2]["error"].(map[string]any)["code"])
+}
+
+func Test_readMessageInvalidLength(t *testing.T) {
+	t.Parallel()
+	_, err := readMessage(bufio.NewReader(strings.NewReader("Content-Length: x\r\n\r\n{}")))
+	require.ErrorContains(t, err, "invalid Content-Length")
+}
+
+func Test_findingRange(t *testing.T) {
+	t.Parallel()
+	lines := strings.Split("a: 1\r\nnamé: 😀x\n", "\n")
+
+	tests := []struct {
+		name      string
+		line, col int
+		want      lspRange
+	}{
+		{"unknownPosition", 0, 0, lspRange{position{0, 0}, position{0, 4}}},
+		{"lineOnly", 2, 0, lspRange{position{1, 0}, position{1, 9}}},
+		{"utf16", 2, 8, lspRange{position{1, 8}, position{1, 9}}},
+		{"pastEndOfLine", 1, 40, lspRange{position{0, 4}, position{0, 4}}},
+		{"pastEndOfFile", 9, 1, lspRange{position{2, 0}, position{2, 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, findingRange(lines, tt.line, tt.col))
+		})
+	}
+}
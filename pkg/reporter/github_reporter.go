@@ -10,9 +10,11 @@ type GitHubReporter struct {
 }
 
 type githubAnnotation struct {
-	message string
-	line    int
-	column  int
+	severity Severity
+	rule     string
+	message  string
+	line     int
+	column   int
 }
 
 func NewGitHubReporter(outputDest string) *GitHubReporter {
@@ -37,7 +39,7 @@ func (gr GitHubReporter) Print(reports []Report) error {
 func buildGitHubReport(reports []Report) string {
 	var b strings.Builder
 	for _, r := range reports {
-		if r.IsValid {
+		if r.IsValid && len(r.ValidationErrors) == 0 {
 			continue
 		}
 		for _, annotation := range githubAnnotations(r) {
@@ -60,23 +62,27 @@ func githubAnnotations(r Report) []githubAnnotation {
 				column = r.ErrorColumns[i]
 			}
 			annotations = append(annotations, githubAnnotation{
-				message: errMsg,
-				line:    line,
-				column:  column,
+				severity: r.Severity(i),
+				rule:     r.Rule(i),
+				message:  errMsg,
+				line:     line,
+				column:   column,
 			})
 		}
 		return annotations
 	}
 
 	return []githubAnnotation{{
-		message: errorMessage(r),
-		line:    r.StartLine,
-		column:  r.StartColumn,
+		severity: r.Severity(0),
+		rule:     r.Rule(0),
+		message:  errorMessage(r),
+		line:     r.StartLine,
+		column:   r.StartColumn,
 	}}
 }
 
 func formatGitHubAnnotation(r Report, annotation githubAnnotation) string {
-	props := make([]string, 0, 3)
+	props := make([]string, 0, 4)
 	if r.FilePath != "" {
 		props = append(props, "file="+escapeGitHubProperty(r.FilePath))
 	}
@@ -86,11 +92,28 @@ func formatGitHubAnnotation(r Report, annotation githubAnnotation) string {
 	if annotation.column > 0 {
 		props = append(props, fmt.Sprintf("col=%d", annotation.column))
 	}
+	if annotation.rule != "" {
+		props = append(props, "title="+escapeGitHubProperty(annotation.rule))
+	}
 
+	command := githubCommand(annotation.severity)
 	if len(props) == 0 {
-		return "::error::" + escapeGitHubMessage(annotation.message)
+		return "::" + command + "::" + escapeGitHubMessage(annotation.message)
+	}
+	return "::" + command + " " + strings.Join(props, ",") + "::" + escapeGitHubMessage(annotation.message)
+}
+
+// githubCommand returns the workflow command that annotates a finding of
+// the given severity.
+func githubCommand(sev Severity) string {
+	switch sev {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "notice"
+	default:
+		return "error"
 	}
-	return "::error " + strings.Join(props, ",") + "::" + escapeGitHubMessage(annotation.message)
 }
 
 func errorMessage(r Report) string {
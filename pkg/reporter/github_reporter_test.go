@@ -198,3 +198,20 @@ func TestGitHubReporter_QuietModeSuppressesStdout(t *testing.T) {
 		t.Fatal("buildGitHubReport should still emit content in quiet mode (only Print suppresses stdout)")
 	}
 }
+
+func TestGitHubReporter_SeverityCommands(t *testing.T) {
+	reports := []Report{
+		{
+			FilePath: "justfile", IsValid: true,
+			ValidationErrors: []string{"syntax: line 1, column 5: unknown setting 'foo'", "syntax: line 2: hint"},
+			ErrorLines:       []int{1, 2},
+			ErrorSeverities:  []Severity{SeverityWarning, SeverityInfo},
+		},
+	}
+	got := buildGitHubReport(reports)
+	want := "::warning file=justfile,line=1::syntax: line 1, column 5: unknown setting 'foo'\n" +
+		"::notice file=justfile,line=2::syntax: line 2: hint\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -17,11 +17,23 @@ func NewJSONReporter(outputDest string) *JSONReporter {
 }
 
 type fileStatus struct {
-	Path     string   `json:"path"`
-	Status   string   `json:"status"`
-	Errors   []string `json:"errors,omitempty"`
-	Notes    []string `json:"notes,omitempty"`
-	Warnings []string `json:"warnings,omitempty"`
+	Path     string    `json:"path"`
+	Status   string    `json:"status"`
+	Errors   []string  `json:"errors,omitempty"`
+	Notes    []string  `json:"notes,omitempty"`
+	Warnings []string  `json:"warnings,omitempty"`
+	Info     []string  `json:"info,omitempty"`
+	Findings []finding `json:"findings,omitempty"`
+}
+
+// finding is one entry of ValidationErrors with its rule, severity and
+// position, for consumers that need more than the formatted message.
+type finding struct {
+	Rule     string   `json:"rule,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
 }
 
 type summary struct {
@@ -157,11 +169,30 @@ func createJSONReport(reports []Report) (reportJSON, error) {
 
 	for _, report := range reports {
 		status := "passed"
-		var errs []string
 		if !report.IsValid {
 			status = "failed"
-			errs = report.ValidationErrors
 		}
+		var errs, warnings, info []string
+		var findings []finding
+		for i, e := range report.ValidationErrors {
+			f := finding{Rule: report.Rule(i), Severity: report.Severity(i), Message: e}
+			if i < len(report.ErrorLines) {
+				f.Line = report.ErrorLines[i]
+			}
+			if i < len(report.ErrorColumns) {
+				f.Column = report.ErrorColumns[i]
+			}
+			findings = append(findings, f)
+			switch report.Severity(i) {
+			case SeverityWarning:
+				warnings = append(warnings, e)
+			case SeverityInfo:
+				info = append(info, e)
+			default:
+				errs = append(errs, e)
+			}
+		}
+		warnings = append(warnings, report.Warnings...)
 
 		// Convert Windows-style file paths.
 		if strings.Contains(report.FilePath, "\\") {
@@ -173,7 +204,9 @@ func createJSONReport(reports []Report) (reportJSON, error) {
 			Status:   status,
 			Errors:   errs,
 			Notes:    report.Notes,
-			Warnings: report.Warnings,
+			Warnings: warnings,
+			Info:     info,
+			Findings: findings,
 		})
 
 		currentPassed := 0
@@ -69,6 +69,7 @@ type Testcase struct {
 	Properties      *[]Property      `xml:"properties>property,omitempty"`
 	TestcaseError   *TestcaseError   `xml:"error,omitempty"`
 	TestcaseFailure *TestcaseFailure `xml:"failure,omitempty"`
+	SystemOut       *SystemOut       `xml:"system-out,omitempty"`
 }
 
 type Skipped struct {
@@ -189,14 +190,22 @@ func (jr JunitReporter) Print(reports []Report) error {
 			r.FilePath = strings.ReplaceAll(r.FilePath, "\\", "/")
 		}
 		tc := Testcase{Name: fmt.Sprintf("%s validation", r.FilePath), File: r.FilePath, ClassName: "config-file-validator"}
+		var escapedErrors, otherFindings []string
+		for i, e := range r.ValidationErrors {
+			e = withRule(e, r.Rule(i))
+			if sev := r.Severity(i); sev != SeverityError {
+				otherFindings = append(otherFindings, fmt.Sprintf("%s: %s", sev, e))
+				continue
+			}
+			escapedErrors = append(escapedErrors, escapeString(e))
+		}
 		if !r.IsValid {
 			testErrors++
-			var escapedErrors []string
-			for _, e := range r.ValidationErrors {
-				escapedErrors = append(escapedErrors, escapeString(e))
-			}
 			tc.TestcaseFailure = &TestcaseFailure{Message: Message{InnerXML: strings.Join(escapedErrors, "\n")}}
 		}
+		if len(otherFindings) > 0 {
+			tc.SystemOut = &SystemOut{TextValue: strings.Join(otherFindings, "\n")}
+		}
 		testcases = append(testcases, tc)
 	}
 	testsuite := Testsuite{Name: "config-file-validator", Testcases: &testcases, Errors: testErrors}
@@ -1,7 +1,52 @@
 package reporter
 
+import (
+	"fmt"
+	"regexp"
+)
+
+// Severity is the severity of a single validation finding.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// ParseSeverity returns the Severity named by s.
+func ParseSeverity(s string) (Severity, error) {
+	switch sev := Severity(s); sev {
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return sev, nil
+	default:
+		return "", fmt.Errorf("unknown severity %q", s)
+	}
+}
+
+// AtLeast reports whether s is as severe as or more severe than min.
+func (s Severity) AtLeast(min Severity) bool {
+	return s.rank() >= min.rank()
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityInfo:
+		return 0
+	case SeverityWarning:
+		return 1
+	default:
+		return 2
+	}
+}
+
 // The Report object stores information about the report
-// and the results of the validation
+// and the results of the validation.
+//
+// ValidationErrors holds every finding for the file. ErrorLines,
+// ErrorColumns, ErrorSeverities and ErrorRules are parallel to it; a finding
+// without a severity is an error. IsValid is false when any finding is an
+// error.
 type Report struct {
 	FileName         string
 	FilePath         string
@@ -16,6 +61,144 @@ type Report struct {
 	StartColumn      int
 	ErrorLines       []int
 	ErrorColumns     []int
+	ErrorSeverities  []Severity
+	ErrorRules       []string
+}
+
+// Severity returns the severity of ValidationErrors[i].
+func (r Report) Severity(i int) Severity {
+	if i < len(r.ErrorSeverities) && r.ErrorSeverities[i] != "" {
+		return r.ErrorSeverities[i]
+	}
+	return SeverityError
+}
+
+// Rule returns the rule ID of ValidationErrors[i], or "" if it is unknown.
+func (r Report) Rule(i int) string {
+	if i < len(r.ErrorRules) {
+		return r.ErrorRules[i]
+	}
+	return ""
+}
+
+// Position returns the 1-based line and column of ValidationErrors[i], or 0
+// when they are unknown. The only finding in a report falls back to the
+// report's StartLine and StartColumn.
+func (r Report) Position(i int) (line, col int) {
+	if i < len(r.ErrorLines) {
+		line = r.ErrorLines[i]
+	}
+	if i < len(r.ErrorColumns) {
+		col = r.ErrorColumns[i]
+	}
+	if line == 0 && len(r.ValidationErrors) == 1 {
+		line, col = r.StartLine, r.StartColumn
+	}
+	return line, col
+}
+
+// findingPrefix matches the "syntax: line N, column M: " prefix the CLI puts
+// on formatted findings.
+var findingPrefix = regexp.MustCompile(`^(syntax|schema): (?:line \d+(?:, column \d+)?: )?`)
+
+// SplitFinding splits a formatted finding such as
+// "schema: line 3, column 5: port: Invalid type" into its kind, "schema",
+// and its message without the kind or position, "port: Invalid type". kind
+// is empty when msg has no kind prefix.
+func SplitFinding(msg string) (kind, text string) {
+	m := findingPrefix.FindStringSubmatchIndex(msg)
+	if m == nil {
+		return "", msg
+	}
+	return msg[m[2]:m[3]], msg[m[1]:]
+}
+
+// withRule appends the rule ID of a finding to its message, as in
+// "syntax: line 1: unexpected EOF [json/syntax]".
+func withRule(msg, rule string) string {
+	if rule == "" {
+		return msg
+	}
+	return msg + " [" + rule + "]"
+}
+
+// HighestSeverity returns the severity of the most severe finding in r,
+// and false if r has none. Report-level Warnings count as warnings.
+func (r Report) HighestSeverity() (Severity, bool) {
+	var highest Severity
+	found := false
+	for i := range r.ValidationErrors {
+		if sev := r.Severity(i); !found || sev.rank() > highest.rank() {
+			highest, found = sev, true
+		}
+	}
+	if len(r.Warnings) > 0 && (!found || highest.rank() < SeverityWarning.rank()) {
+		highest, found = SeverityWarning, true
+	}
+	return highest, found
+}
+
+// DropErrors returns a copy of r without the validation errors for which
+// drop returns true, keeping ErrorLines, ErrorColumns, ErrorSeverities and
+// ErrorRules parallel to ValidationErrors, and the number of errors dropped. A report
+// with no error-severity findings left passes.
+func (r Report) DropErrors(drop func(i int, msg string) bool) (Report, int) {
+	var errs []string
+	var lines, cols []int
+	var sevs []Severity
+	var rules []string
+	dropped := 0
+	for i, msg := range r.ValidationErrors {
+		if drop(i, msg) {
+			dropped++
+			continue
+		}
+		errs = append(errs, msg)
+		if i < len(r.ErrorLines) {
+			lines = append(lines, r.ErrorLines[i])
+		}
+		if i < len(r.ErrorColumns) {
+			cols = append(cols, r.ErrorColumns[i])
+		}
+		if i < len(r.ErrorSeverities) {
+			sevs = append(sevs, r.ErrorSeverities[i])
+		}
+		if i < len(r.ErrorRules) {
+			rules = append(rules, r.ErrorRules[i])
+		}
+	}
+	if dropped == 0 {
+		return r, 0
+	}
+
+	r.ValidationErrors = errs
+	r.ErrorLines = lines
+	r.ErrorColumns = cols
+	r.ErrorSeverities = sevs
+	r.ErrorRules = rules
+	r.StartLine, r.StartColumn = 0, 0
+	if len(lines) > 0 {
+		r.StartLine = lines[0]
+	}
+	if len(cols) > 0 {
+		r.StartColumn = cols[0]
+	}
+	if !r.hasErrors() {
+		r.IsValid = true
+		r.ValidationError = nil
+		r.ErrorType = ""
+	}
+	return r, dropped
+}
+
+// hasErrors reports whether any finding in r has error severity.
+func (r Report) hasErrors() bool {
+	for i := range r.ValidationErrors {
+		if r.Severity(i) == SeverityError {
+			return true
+		}
+	}
+	return false
 }
 
 // Reporter is the interface that wraps the Print method
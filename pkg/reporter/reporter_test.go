@@ -221,6 +221,154 @@ func Test_sarifReportWithRegion(t *testing.T) {
 	assert.NotContains(t, output, `"startLine": 0`)
 }
 
+var mixedSeverityReport = Report{
+	FileName:         "justfile",
+	FilePath:         "/fake/path/justfile",
+	IsValid:          false,
+	ValidationError:  errors.New("undefined variable 'x'"),
+	ValidationErrors: []string{"syntax: line 1: undefined variable 'x'", "syntax: line 2: unknown setting 'foo'", "syntax: line 3: hint"},
+	ErrorLines:       []int{1, 2, 3},
+	ErrorColumns:     []int{0, 0, 0},
+	ErrorSeverities:  []Severity{SeverityError, SeverityWarning, SeverityInfo},
+}
+
+func Test_sarifReportSeverityLevels(t *testing.T) {
+	warningOnly := Report{
+		FilePath:         "/fake/path/other",
+		IsValid:          true,
+		ValidationErrors: []string{"syntax: line 4: unknown setting 'bar'"},
+		ErrorLines:       []int{4},
+		ErrorSeverities:  []Severity{SeverityWarning},
+	}
+	log, err := createSARIFReport([]Report{mixedSeverityReport, warningOnly})
+	require.NoError(t, err)
+
+	var levels []string
+	for _, r := range log.Runs[0].Results {
+		levels = append(levels, r.Kind+"/"+r.Level)
+	}
+	require.Equal(t, []string{"fail/error", "fail/warning", "fail/note", "fail/warning"}, levels)
+}
+
+func Test_jsonReportSeverities(t *testing.T) {
+	report, err := createJSONReport([]Report{mixedSeverityReport})
+	require.NoError(t, err)
+	require.Equal(t, []fileStatus{{
+		Path:     "/fake/path/justfile",
+		Status:   "failed",
+		Errors:   []string{"syntax: line 1: undefined variable 'x'"},
+		Warnings: []string{"syntax: line 2: unknown setting 'foo'"},
+		Info:     []string{"syntax: line 3: hint"},
+		Findings: []finding{
+			{Severity: SeverityError, Message: "syntax: line 1: undefined variable 'x'", Line: 1},
+			{Severity: SeverityWarning, Message: "syntax: line 2: unknown setting 'foo'", Line: 2},
+			{Severity: SeverityInfo, Message: "syntax: line 3: hint", Line: 3},
+		},
+	}}, report.Files)
+}
+
+var ruleReport = Report{
+	FileName:         "config.json",
+	FilePath:         "/fake/path/config.json",
+	IsValid:          false,
+	ValidationError:  errors.New(`duplicate key "a"`),
+	ValidationErrors: []string{`syntax: duplicate key "a"`, "schema: line 2, column 3: port: Invalid type"},
+	ErrorLines:       []int{0, 2},
+	ErrorColumns:     []int{0, 3},
+	ErrorRules:       []string{"json/duplicate-key", "schema/type"},
+}
+
+func Test_reportRules(t *testing.T) {
+	jsonReport, err := createJSONReport([]Report{ruleReport})
+	require.NoError(t, err)
+	require.Equal(t, []finding{
+		{Rule: "json/duplicate-key", Severity: SeverityError, Message: `syntax: duplicate key "a"`},
+		{Rule: "schema/type", Severity: SeverityError, Message: "schema: line 2, column 3: port: Invalid type", Line: 2, Column: 3},
+	}, jsonReport.Files[0].Findings)
+
+	stdout := createStdoutReport([]Report{ruleReport}, 0)
+	assert.Contains(t, stdout.Text, `    error: syntax: duplicate key "a" [json/duplicate-key]`+"\n")
+	assert.Contains(t, stdout.Text, "    error: schema: line 2, column 3: port: Invalid type [schema/type]\n")
+
+	sarif := createValidatorSARIFRun([]Report{ruleReport})
+	require.Len(t, sarif.Results, 2)
+	assert.Equal(t, "json/duplicate-key", sarif.Results[0].RuleID)
+	assert.Equal(t, "schema/type", sarif.Results[1].RuleID)
+
+	github := buildGitHubReport([]Report{ruleReport})
+	assert.Contains(t, github, "::error file=/fake/path/config.json,line=2,col=3,title=schema/type::")
+
+	tmpDir := t.TempDir()
+	require.NoError(t, NewJunitReporter(tmpDir).Print([]Report{ruleReport}))
+	data, err := os.ReadFile(filepath.Join(tmpDir, "result.xml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "port: Invalid type [schema/type]")
+}
+
+func Test_reportFindingParts(t *testing.T) {
+	line, col := ruleReport.Position(1)
+	assert.Equal(t, [2]int{2, 3}, [2]int{line, col})
+	line, col = Report{ValidationErrors: []string{"x"}, StartLine: 4, StartColumn: 1}.Position(0)
+	assert.Equal(t, [2]int{4, 1}, [2]int{line, col})
+
+	tests := []struct{ msg, kind, text string }{
+		{"schema: line 2, column 3: port: Invalid type", "schema", "port: Invalid type"},
+		{"syntax: line 4: unknown setting", "syntax", "unknown setting"},
+		{`syntax: duplicate key "a"`, "syntax", `duplicate key "a"`},
+		{"no schema found", "", "no schema found"},
+	}
+	for _, tt := range tests {
+		kind, text := SplitFinding(tt.msg)
+		assert.Equal(t, tt.kind, kind, tt.msg)
+		assert.Equal(t, tt.text, text, tt.msg)
+	}
+}
+
+func Test_stdoutReportSeverities(t *testing.T) {
+	warningOnly := Report{
+		FilePath:         "/fake/path/other",
+		IsValid:          true,
+		ValidationErrors: []string{"syntax: line 4: unknown setting 'bar'"},
+		ErrorSeverities:  []Severity{SeverityWarning},
+	}
+	report := createStdoutReport([]Report{mixedSeverityReport, warningOnly}, 0)
+	assert.Contains(t, report.Text, "× /fake/path/justfile\n")
+	assert.Contains(t, report.Text, "    error: syntax: line 1: undefined variable 'x'\n")
+	assert.Contains(t, report.Text, "    warning: syntax: line 2: unknown setting 'foo'\n")
+	assert.Contains(t, report.Text, "    info: syntax: line 3: hint\n")
+	assert.Contains(t, report.Text, "✓ /fake/path/other\n    warning: syntax: line 4: unknown setting 'bar'\n")
+	assert.Equal(t, summary{Passed: 1, Failed: 1}, report.Summary)
+}
+
+func Test_junitReportSeverities(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, NewJunitReporter(tmpDir).Print([]Report{mixedSeverityReport}))
+	data, err := os.ReadFile(filepath.Join(tmpDir, "result.xml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<Message>syntax: line 1: undefined variable &#39;x&#39;</Message>")
+	assert.Contains(t, string(data), "<system-out>warning: syntax: line 2: unknown setting &#39;foo&#39;&#xA;info: syntax: line 3: hint</system-out>")
+}
+
+func Test_severityOrder(t *testing.T) {
+	assert.True(t, SeverityError.AtLeast(SeverityWarning))
+	assert.True(t, SeverityWarning.AtLeast(SeverityWarning))
+	assert.False(t, SeverityInfo.AtLeast(SeverityWarning))
+
+	sev, ok := mixedSeverityReport.HighestSeverity()
+	assert.True(t, ok)
+	assert.Equal(t, SeverityError, sev)
+
+	sev, ok = Report{IsValid: true, Warnings: []string{"stale"}}.HighestSeverity()
+	assert.True(t, ok)
+	assert.Equal(t, SeverityWarning, sev)
+
+	_, ok = Report{IsValid: true}.HighestSeverity()
+	assert.False(t, ok)
+
+	_, err := ParseSeverity("fatal")
+	assert.Error(t, err)
+}
+
 func Test_sarifReportToFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	err := NewSARIFReporter(tmpDir).Print([]Report{validReport})
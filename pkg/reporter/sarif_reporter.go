@@ -50,6 +50,7 @@ type driver struct {
 }
 
 type result struct {
+	RuleID    string     `json:"ruleId,omitempty"`
 	Kind      string     `json:"kind"`
 	Level     string     `json:"level"`
 	Message   message    `json:"message"`
@@ -147,7 +148,7 @@ func createValidatorSARIFRun(reports []Report) runs {
 
 		uri := "file:///" + report.FilePath
 
-		if report.IsValid {
+		if report.IsValid && len(report.ValidationErrors) == 0 {
 			validatorRun.Results = append(validatorRun.Results, result{
 				Kind:    "pass",
 				Level:   "none",
@@ -163,8 +164,9 @@ func createValidatorSARIFRun(reports []Report) runs {
 
 		for i, errMsg := range report.ValidationErrors {
 			r := result{
+				RuleID:  report.Rule(i),
 				Kind:    "fail",
-				Level:   "error",
+				Level:   sarifLevel(report.Severity(i)),
 				Message: message{Text: errMsg},
 				Locations: []location{{
 					PhysicalLocation: physicalLocation{
@@ -196,6 +198,18 @@ func createValidatorSARIFRun(reports []Report) runs {
 	return validatorRun
 }
 
+// sarifLevel maps a finding's severity to a SARIF result level.
+func sarifLevel(sev Severity) string {
+	switch sev {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
 func loadMergedSARIFRuns(config SARIFMergeConfig) ([]runs, error) {
 	paths := append([]string{}, config.Files...)
 	if config.Directory != "" {
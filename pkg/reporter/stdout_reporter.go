@@ -113,21 +113,23 @@ func createStdoutReport(reports []Report, indentSize int) reportStdout {
 
 	for _, report := range reports {
 		if !report.IsValid {
-			fmtRed := color.New(color.FgRed)
-			result.Text += fmtRed.Sprintf("%s× %s\n", indent, report.FilePath)
-			for _, e := range report.ValidationErrors {
-				paddedString := padErrorString(e)
-				result.Text += fmtRed.Sprintf("%serror: %v\n", errIndent, paddedString)
-			}
-			for _, n := range report.Notes {
-				paddedString := padErrorString(n)
-				result.Text += color.New(color.FgYellow).Sprintf("%snote: %v\n", errIndent, paddedString)
-			}
+			result.Text += color.New(color.FgRed).Sprintf("%s× %s\n", indent, report.FilePath)
 			result.Summary.Failed++
 		} else {
 			result.Text += color.New(color.FgGreen).Sprintf("%s✓ %s\n", indent, report.FilePath)
 			result.Summary.Passed++
 		}
+		for i, e := range report.ValidationErrors {
+			sev := report.Severity(i)
+			paddedString := withRule(padErrorString(e), report.Rule(i))
+			result.Text += severityColor(sev).Sprintf("%s%s: %v\n", errIndent, sev, paddedString)
+		}
+		if !report.IsValid {
+			for _, n := range report.Notes {
+				paddedString := padErrorString(n)
+				result.Text += color.New(color.FgYellow).Sprintf("%snote: %v\n", errIndent, paddedString)
+			}
+		}
 		for _, w := range report.Warnings {
 			paddedString := padErrorString(w)
 			result.Text += color.New(color.FgYellow).Sprintf("%swarning: %v\n", errIndent, paddedString)
@@ -137,6 +139,17 @@ func createStdoutReport(reports []Report, indentSize int) reportStdout {
 	return result
 }
 
+func severityColor(sev Severity) *color.Color {
+	switch sev {
+	case SeverityWarning:
+		return color.New(color.FgYellow)
+	case SeverityInfo:
+		return color.New(color.FgCyan)
+	default:
+		return color.New(color.FgRed)
+	}
+}
+
 // padErrorString adds padding to every newline in the error
 // string, except the first line and removes any trailing newlines
 // or spaces
@@ -0,0 +1,174 @@
+// Package resultcache stores validation reports on disk so that files which
+// have not changed since a previous run can be reported without being
+// validated again.
+//
+// Entries are addressed by a key derived from everything that can affect a
+// report: the caller hashes the file content, file type, validator options
+// and schema content into the key with Key. Entries are never updated in
+// place; a change to any input produces a new key and the old entry is simply
+// no longer read.
+package resultcache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+	"github.com/Boeing/config-file-validator/v2/pkg/tools"
+)
+
+// formatVersion is mixed into every key. Bump it when the entry format or
+// the meaning of cached reports changes.
+const formatVersion = "2"
+
+// Cache is an on-disk store of validation reports. It is safe for
+// concurrent use by multiple goroutines and processes.
+type Cache struct {
+	dir  string
+	salt string
+}
+
+// DefaultDir returns the default cache directory, alongside the SchemaStore
+// schema cache: $XDG_CACHE_HOME/cfv/results or ~/.cache/cfv/results.
+func DefaultDir() (string, error) {
+	root, err := tools.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, "results"), nil
+}
+
+// Open returns a cache rooted at dir, creating the directory if needed.
+// salt is mixed into every key; callers use it to invalidate all entries
+// when the tool version or configuration changes.
+func Open(dir, salt string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating result cache directory: %w", err)
+	}
+	return &Cache{dir: dir, salt: salt}, nil
+}
+
+// Key hashes parts, together with the cache's salt, into an entry key.
+// Parts are length-prefixed so that ("ab", "c") and ("a", "bc") differ.
+func (c *Cache) Key(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range append([][]byte{[]byte(formatVersion), []byte(c.salt)}, parts...) {
+		var n [8]byte
+		binary.BigEndian.PutUint64(n[:], uint64(len(p)))
+		h.Write(n[:])
+		h.Write(p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// entry is the stored form of a reporter.Report. Report.ValidationError is
+// an arbitrary error and is stored as its message. FileName, FilePath and
+// IsQuiet describe the current run rather than the validation result, so
+// they are not stored.
+type entry struct {
+	IsValid          bool                `json:"isValid"`
+	ValidationError  string              `json:"validationError,omitempty"`
+	ValidationErrors []string            `json:"validationErrors,omitempty"`
+	Notes            []string            `json:"notes,omitempty"`
+	Warnings         []string            `json:"warnings,omitempty"`
+	ErrorType        string              `json:"errorType,omitempty"`
+	StartLine        int                 `json:"startLine,omitempty"`
+	StartColumn      int                 `json:"startColumn,omitempty"`
+	ErrorLines       []int               `json:"errorLines,omitempty"`
+	ErrorColumns     []int               `json:"errorColumns,omitempty"`
+	ErrorSeverities  []reporter.Severity `json:"errorSeverities,omitempty"`
+	ErrorRules       []string            `json:"errorRules,omitempty"`
+}
+
+// Get returns the report stored under key. Missing, unreadable and corrupt
+// entries are all reported as a miss.
+func (c *Cache) Get(key string) (reporter.Report, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return reporter.Report{}, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return reporter.Report{}, false
+	}
+
+	report := reporter.Report{
+		IsValid:          e.IsValid,
+		ValidationErrors: e.ValidationErrors,
+		Notes:            e.Notes,
+		Warnings:         e.Warnings,
+		ErrorType:        e.ErrorType,
+		StartLine:        e.StartLine,
+		StartColumn:      e.StartColumn,
+		ErrorLines:       e.ErrorLines,
+		ErrorColumns:     e.ErrorColumns,
+		ErrorSeverities:  e.ErrorSeverities,
+		ErrorRules:       e.ErrorRules,
+	}
+	if e.ValidationError != "" {
+		report.ValidationError = errors.New(e.ValidationError)
+	}
+	return report, true
+}
+
+// Put stores report under key.
+func (c *Cache) Put(key string, report reporter.Report) error {
+	e := entry{
+		IsValid:          report.IsValid,
+		ValidationErrors: report.ValidationErrors,
+		Notes:            report.Notes,
+		Warnings:         report.Warnings,
+		ErrorType:        report.ErrorType,
+		StartLine:        report.StartLine,
+		StartColumn:      report.StartColumn,
+		ErrorLines:       report.ErrorLines,
+		ErrorColumns:     report.ErrorColumns,
+		ErrorSeverities:  report.ErrorSeverities,
+		ErrorRules:       report.ErrorRules,
+	}
+	if report.ValidationError != nil {
+		e.ValidationError = report.ValidationError.Error()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding cache entry: %w", err)
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	// Write to a temporary file and rename it into place so that concurrent
+	// runs never observe a partially written entry.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating cache file: %w", err)
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+	return nil
+}
+
+// path shards entries by the first two hex digits of the key to keep
+// directories small.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
@@ -0,0 +1,114 @@
+package resultcache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+)
+
+func TestPutGetRoundTrip(t *testing.T) {
+	t.Parallel()
+	c, err := Open(t.TempDir(), "v1")
+	require.NoError(t, err)
+
+	report := reporter.Report{
+		FileName:         "bad.json",
+		FilePath:         "/work/bad.json",
+		IsValid:          false,
+		ValidationError:  errors.New("invalid character '}'"),
+		ValidationErrors: []string{"syntax: line 1, column 9: invalid character '}'"},
+		ErrorType:        "syntax",
+		IsQuiet:          true,
+		StartLine:        1,
+		StartColumn:      9,
+		ErrorLines:       []int{1},
+		ErrorColumns:     []int{9},
+		ErrorRules:       []string{"json/syntax"},
+	}
+	key := c.Key([]byte("bad.json"), []byte(`{"a": 1,}`))
+	require.NoError(t, c.Put(key, report))
+
+	got, ok := c.Get(key)
+	require.True(t, ok)
+	require.EqualError(t, got.ValidationError, "invalid character '}'")
+	require.Equal(t, report.ValidationErrors, got.ValidationErrors)
+	require.Equal(t, "syntax", got.ErrorType)
+	require.Equal(t, []int{1}, got.ErrorLines)
+	require.Equal(t, []int{9}, got.ErrorColumns)
+	require.Equal(t, []string{"json/syntax"}, got.ErrorRules)
+	require.False(t, got.IsValid)
+	require.Empty(t, got.FileName, "file names describe the run, not the result")
+	require.False(t, got.IsQuiet)
+}
+
+func TestGetMiss(t *testing.T) {
+	t.Parallel()
+	c, err := Open(t.TempDir(), "v1")
+	require.NoError(t, err)
+
+	_, ok := c.Get(c.Key([]byte("missing")))
+	require.False(t, ok)
+}
+
+func TestGetCorruptEntry(t *testing.T) {
+	t.Parallel()
+	c, err := Open(t.TempDir(), "v1")
+	require.NoError(t, err)
+
+	key := c.Key([]byte("a.json"))
+	require.NoError(t, c.Put(key, reporter.Report{IsValid: true}))
+	require.NoError(t, os.WriteFile(c.path(key), []byte("{not json"), 0600))
+
+	_, ok := c.Get(key)
+	require.False(t, ok)
+}
+
+func TestKeyDependsOnSaltAndParts(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	v1, err := Open(dir, "v1")
+	require.NoError(t, err)
+	v2, err := Open(dir, "v2")
+	require.NoError(t, err)
+
+	require.Equal(t, v1.Key([]byte("a"), []byte("b")), v1.Key([]byte("a"), []byte("b")))
+	require.NotEqual(t, v1.Key([]byte("a")), v2.Key([]byte("a")))
+	require.NotEqual(t, v1.Key([]byte("ab"), []byte("c")), v1.Key([]byte("a"), []byte("bc")))
+}
+
+func TestConcurrentPut(t *testing.T) {
+	t.Parallel()
+	c, err := Open(t.TempDir(), "v1")
+	require.NoError(t, err)
+	key := c.Key([]byte("shared.json"))
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Go(func() {
+			assert.NoError(t, c.Put(key, reporter.Report{IsValid: true}))
+		})
+	}
+	wg.Wait()
+
+	got, ok := c.Get(key)
+	require.True(t, ok)
+	require.True(t, got.IsValid)
+
+	entries, err := os.ReadDir(filepath.Dir(c.path(key)))
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temporary files must be cleaned up")
+}
+
+func TestDefaultDirUsesXDGCacheHome(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/xdg")
+	dir, err := DefaultDir()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join("/xdg", "cfv", "results"), dir)
+}
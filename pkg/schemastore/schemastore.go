@@ -12,9 +12,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/tools"
 )
 
 const defaultCacheTTL = 24 * time.Hour
@@ -43,12 +46,26 @@ type catalog struct {
 // Store holds a parsed SchemaStore catalog and resolves file paths to schema locations.
 // When backed by a local clone, schemas resolve to local files first.
 // Otherwise, schemas are fetched remotely and cached locally.
+// A Store is safe for concurrent use. Concurrent lookups of the same remote
+// schema share a single fetch; fetches of different schemas do not block
+// each other.
 type Store struct {
 	entries   []catalogEntry
 	basePath  string
 	schemaDir string
 	cacheDir  string
 	cacheTTL  time.Duration
+	offline   bool
+
+	fetchMu  sync.Mutex
+	inflight map[string]*fetchCall
+}
+
+// fetchCall tracks an in-progress fetch of a single schema URL.
+type fetchCall struct {
+	done chan struct{}
+	path string
+	err  error
 }
 
 // Open reads the SchemaStore catalog from bundlePath and returns a Store.
@@ -100,9 +117,17 @@ func newStore(data []byte, bundlePath string) (*Store, error) {
 	return s, nil
 }
 
+// SetOffline stops Resolve from fetching schemas or falling back to their
+// remote URLs, so that resolution never waits on the network. Schemas that
+// are neither in the local clone nor in the cache are not resolved.
+func (s *Store) SetOffline(offline bool) {
+	s.offline = offline
+}
+
 // Lookup matches a file path against the catalog and returns a schema location.
 // Resolution order: local clone → cache → remote URL.
-// Remote schemas are cached locally for subsequent runs.
+// Remote schemas are cached locally for subsequent runs. An offline Store
+// stops after the cache.
 func (s *Store) Resolve(filePath string) (string, bool) {
 	name := filepath.Base(filePath)
 	for _, entry := range s.entries {
@@ -126,8 +151,11 @@ func (s *Store) Resolve(filePath string) (string, bool) {
 			if cached, ok := s.lookupCache(entry.URL); ok {
 				return cached, true
 			}
+			if s.offline {
+				continue
+			}
 			// Fetch and cache
-			if cached, err := s.fetchAndCache(entry.URL); err == nil {
+			if cached, err := s.fetchOnce(entry.URL); err == nil {
 				return cached, true
 			}
 			// Fall back to remote URL (no cache available)
@@ -164,14 +192,11 @@ func matchPattern(pattern, filePath, baseName string) (bool, error) {
 }
 
 func defaultCacheDir() (string, error) {
-	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
-		return filepath.Join(xdg, "cfv", "schemas"), nil
-	}
-	home, err := os.UserHomeDir()
+	root, err := tools.CacheDir()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(home, ".cache", "cfv", "schemas"), nil
+	return filepath.Join(root, "schemas"), nil
 }
 
 // cachePathForURL converts a schema URL to a local cache file path.
@@ -202,6 +227,32 @@ func (s *Store) lookupCache(schemaURL string) (string, bool) {
 	return cachePath, true
 }
 
+// fetchOnce fetches schemaURL into the cache, sharing the result with any
+// concurrent callers asking for the same URL.
+func (s *Store) fetchOnce(schemaURL string) (string, error) {
+	s.fetchMu.Lock()
+	if call, ok := s.inflight[schemaURL]; ok {
+		s.fetchMu.Unlock()
+		<-call.done
+		return call.path, call.err
+	}
+	call := &fetchCall{done: make(chan struct{})}
+	if s.inflight == nil {
+		s.inflight = make(map[string]*fetchCall)
+	}
+	s.inflight[schemaURL] = call
+	s.fetchMu.Unlock()
+
+	call.path, call.err = s.fetchAndCache(schemaURL)
+	close(call.done)
+
+	s.fetchMu.Lock()
+	delete(s.inflight, schemaURL)
+	s.fetchMu.Unlock()
+
+	return call.path, call.err
+}
+
 func (s *Store) fetchAndCache(schemaURL string) (string, error) {
 	cachePath, err := s.cachePathForURL(schemaURL)
 	if err != nil {
@@ -230,13 +281,23 @@ func (s *Store) fetchAndCache(schemaURL string) (string, error) {
 		return "", fmt.Errorf("creating cache directory: %w", err)
 	}
 
-	f, err := os.Create(cachePath)
+	// Write to a temporary file and rename it into place so that other
+	// processes never observe a partially written schema.
+	f, err := os.CreateTemp(filepath.Dir(cachePath), filepath.Base(cachePath)+".*.tmp")
 	if err != nil {
 		return "", fmt.Errorf("creating cache file: %w", err)
 	}
-	defer f.Close()
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath)
 
 	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return "", fmt.Errorf("writing cache file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("writing cache file: %w", err)
+	}
+	if err := os.Rename(tmpPath, cachePath); err != nil {
 		return "", fmt.Errorf("writing cache file: %w", err)
 	}
 
@@ -6,6 +6,8 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -253,6 +255,36 @@ func TestLookupNoCacheDir(t *testing.T) {
 	require.Equal(t, "https://example.com/nonexistent.json", path)
 }
 
+func TestResolveOffline(t *testing.T) {
+	t.Parallel()
+	var fetched atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetched.Store(true)
+		_, _ = w.Write([]byte(`{"type":"object"}`))
+	}))
+	defer srv.Close()
+
+	entries := []catalogEntry{
+		{FileMatch: []string{"package.json"}, URL: "https://www.schemastore.org/package.json"},
+		{FileMatch: []string{"config.json"}, URL: srv.URL + "/config.json"},
+	}
+	dir := setupTestBundle(t, entries)
+	schemaPath := writeSchema(t, dir, "package.json", `{}`)
+
+	store, err := Open(dir)
+	require.NoError(t, err)
+	store.cacheDir = t.TempDir()
+	store.SetOffline(true)
+
+	path, found := store.Resolve("/project/package.json")
+	require.True(t, found)
+	require.Equal(t, schemaPath, path)
+
+	_, found = store.Resolve("/project/config.json")
+	require.False(t, found)
+	require.False(t, fetched.Load())
+}
+
 func TestFetchAndCacheSuccess(t *testing.T) {
 	t.Parallel()
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -370,6 +402,81 @@ func TestFetchAndCacheThenHitCache(t *testing.T) {
 	require.Equal(t, path1, path2)
 }
 
+func TestFetchSharedAcrossConcurrentResolves(t *testing.T) {
+	t.Parallel()
+	var callCount atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		callCount.Add(1)
+		<-release
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"type":"object"}`))
+	}))
+	defer srv.Close()
+
+	store := &Store{
+		entries: []catalogEntry{
+			{FileMatch: []string{"config.json"}, URL: srv.URL + "/schema.json"},
+		},
+		cacheDir: t.TempDir(),
+		cacheTTL: defaultCacheTTL,
+	}
+
+	var wg sync.WaitGroup
+	paths := make([]string, 8)
+	for i := range paths {
+		wg.Go(func() {
+			paths[i], _ = store.Resolve("/project/config.json")
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), callCount.Load())
+	for _, p := range paths {
+		require.Equal(t, paths[0], p)
+	}
+	data, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"object"}`, string(data))
+}
+
+func TestSlowFetchDoesNotBlockOtherSchemas(t *testing.T) {
+	t.Parallel()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.json" {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"type":"object"}`))
+	}))
+	defer srv.Close()
+
+	store := &Store{
+		entries: []catalogEntry{
+			{FileMatch: []string{"slow.json"}, URL: srv.URL + "/slow.json"},
+			{FileMatch: []string{"fast.json"}, URL: srv.URL + "/fast.json"},
+		},
+		cacheDir: t.TempDir(),
+		cacheTTL: defaultCacheTTL,
+	}
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		store.Resolve("/project/slow.json")
+	})
+	time.Sleep(20 * time.Millisecond)
+
+	path, found := store.Resolve("/project/fast.json")
+	require.True(t, found)
+	require.FileExists(t, path)
+
+	close(release)
+	wg.Wait()
+}
+
 func TestFetchAndCacheUnwritableDir(t *testing.T) {
 	t.Parallel()
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
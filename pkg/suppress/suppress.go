@@ -0,0 +1,200 @@
+// Package suppress implements in-file directives that suppress specific
+// validation errors.
+//
+// Directives are written in a comment, in any format that supports them:
+//
+//	# cfv-disable-next-line          suppress errors on the following line
+//	# cfv-disable-line               suppress errors on this line
+//	# cfv-disable                    suppress errors from here until cfv-enable
+//	# cfv-enable                     end every open cfv-disable block
+//	# cfv-disable-file               suppress errors anywhere in the file
+//
+// Each directive may be followed by the kinds of error it applies to, such
+// as "schema" or "syntax", or the rule IDs it applies to, such as
+// "json/duplicate-key" or "schema/*", separated by commas or spaces. Without
+// either it applies to every error except syntax errors, which are only
+// suppressed by a directive that names "syntax" or their exact rule ID, such
+// as "yaml/syntax". A reason can be given after "--" and is recorded with
+// every suppression:
+//
+//	// cfv-disable-next-line schema -- generated fixture
+package suppress
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+	"github.com/Boeing/config-file-validator/v2/pkg/validator"
+)
+
+// directivePattern matches the text following a comment marker.
+var directivePattern = regexp.MustCompile(`^cfv-(disable-next-line|disable-line|disable-file|disable|enable)(?:\s+(.*?))?\s*(?:\*/)?\s*$`)
+
+// rule is one directive, applying to the errors on lines from to to
+// inclusive. A to of -1 means the end of the file; a from of 0 covers errors
+// without a known position.
+type rule struct {
+	name   string
+	line   int
+	kinds  []string
+	reason string
+	from   int
+	to     int
+}
+
+// Directives are the suppression directives found in a file.
+type Directives struct {
+	rules []rule
+}
+
+// Parse collects the directives in content. prefixes are the comment markers
+// of the file's format; a marker only starts a directive at the beginning of
+// a line or after whitespace, so markers inside values such as URLs are not
+// mistaken for comments.
+func Parse(content []byte, prefixes []string) *Directives {
+	d := &Directives{}
+	var open []int // indexes of cfv-disable rules awaiting cfv-enable
+
+	for i, text := range strings.Split(string(content), "\n") {
+		line := i + 1
+		name, args, ok := findDirective(text, prefixes)
+		if !ok {
+			continue
+		}
+		r := rule{name: "cfv-" + name, line: line}
+		args, r.reason, _ = strings.Cut(args, "--")
+		r.reason = strings.TrimSpace(r.reason)
+		r.kinds = strings.FieldsFunc(args, func(c rune) bool {
+			return c == ',' || c == ' ' || c == '\t'
+		})
+
+		switch name {
+		case "disable-next-line":
+			r.from, r.to = line+1, line+1
+		case "disable-line":
+			r.from, r.to = line, line
+		case "disable-file":
+			r.from, r.to = 0, -1
+		case "disable":
+			r.from, r.to = line, -1
+			open = append(open, len(d.rules))
+		case "enable":
+			for _, i := range open {
+				d.rules[i].to = line
+			}
+			open = nil
+			continue
+		}
+		d.rules = append(d.rules, r)
+	}
+	return d
+}
+
+func findDirective(text string, prefixes []string) (name, args string, ok bool) {
+	for _, prefix := range prefixes {
+		for i := 0; ; {
+			idx := strings.Index(text[i:], prefix)
+			if idx < 0 {
+				break
+			}
+			idx += i
+			i = idx + len(prefix)
+			if idx > 0 && text[idx-1] != ' ' && text[idx-1] != '\t' {
+				continue
+			}
+			m := directivePattern.FindStringSubmatch(strings.TrimSpace(text[i:]))
+			if m != nil {
+				return m[1], m[2], true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// match returns the rule suppressing an error of the given kind and rule ID
+// on line, if any. line is 0 for errors without a known position.
+func (d *Directives) match(line int, kind, ruleID string) (rule, bool) {
+	for _, r := range d.rules {
+		if line < r.from || (r.to >= 0 && line > r.to) {
+			continue
+		}
+		if isSyntaxError(kind, ruleID) {
+			if !r.names(kind, ruleID) {
+				continue
+			}
+		} else if len(r.kinds) > 0 && !r.appliesTo(kind, ruleID) {
+			continue
+		}
+		return r, true
+	}
+	return rule{}, false
+}
+
+// appliesTo reports whether one of the kinds listed by the directive names
+// the error kind or matches its rule ID.
+func (r rule) appliesTo(kind, ruleID string) bool {
+	if slices.Contains(r.kinds, kind) {
+		return true
+	}
+	if ruleID == "" {
+		return false
+	}
+	return slices.ContainsFunc(r.kinds, func(k string) bool {
+		return validator.MatchRule(k, ruleID)
+	})
+}
+
+// isSyntaxError reports whether an error is the file failing to parse, as
+// opposed to a finding in a file that parsed, such as a duplicate key.
+func isSyntaxError(kind, ruleID string) bool {
+	if ruleID == "" {
+		return kind == "syntax"
+	}
+	return strings.HasSuffix(ruleID, "/syntax")
+}
+
+// names reports whether the directive lists the error kind or its exact
+// rule ID. A syntax error can hide every other error in the file, so only a
+// directive that names it, not a bare directive or a category such as
+// "json/*", suppresses it.
+func (r rule) names(kind, ruleID string) bool {
+	return slices.Contains(r.kinds, kind) || (ruleID != "" && slices.Contains(r.kinds, ruleID))
+}
+
+// Apply removes the errors of r that a directive suppresses and records a
+// note for each, naming the directive, its line and the suppressed error.
+func (d *Directives) Apply(r reporter.Report) reporter.Report {
+	if len(r.ValidationErrors) == 0 || len(d.rules) == 0 {
+		return r
+	}
+	var notes []string
+	errLines, errRules := r.ErrorLines, r.ErrorRules
+	r, _ = r.DropErrors(func(i int, msg string) bool {
+		line := 0
+		if i < len(errLines) {
+			line = errLines[i]
+		}
+		ruleID := ""
+		if i < len(errRules) {
+			ruleID = errRules[i]
+		}
+		kind, _, _ := strings.Cut(msg, ":")
+		rule, ok := d.match(line, kind, ruleID)
+		if !ok {
+			return false
+		}
+		note := fmt.Sprintf("suppressed by %s on line %d: %s", rule.name, rule.line, strings.TrimSpace(msg))
+		if rule.reason != "" {
+			note += fmt.Sprintf(" (reason: %s)", rule.reason)
+		}
+		notes = append(notes, note)
+		return true
+	})
+	if len(notes) > 0 {
+		r.Notes = append(slices.Clone(r.Notes), notes...)
+	}
+	return r
+}
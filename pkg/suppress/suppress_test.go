@@ -0,0 +1,147 @@
+package suppress
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/reporter"
+)
+
+func failed(lines []int, msgs ...string) reporter.Report {
+	return reporter.Report{
+		IsValid:          false,
+		ValidationError:  errors.New(msgs[0]),
+		ValidationErrors: msgs,
+		ErrorType:        "schema",
+		StartLine:        lines[0],
+		ErrorLines:       lines,
+		ErrorColumns:     make([]int, len(lines)),
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	content := `a: 1
+# cfv-disable-next-line
+b: 2
+c: 3 # cfv-disable-line schema, syntax -- generated
+# cfv-disable
+d: 4
+# cfv-enable
+url: http://example.com//cfv-disable-file
+e: "#cfv-disable-file"
+`
+	d := Parse([]byte(content), []string{"#", "//"})
+	require.Equal(t, []rule{
+		{name: "cfv-disable-next-line", line: 2, kinds: []string{}, from: 3, to: 3},
+		{name: "cfv-disable-line", line: 4, kinds: []string{"schema", "syntax"}, reason: "generated", from: 4, to: 4},
+		{name: "cfv-disable", line: 5, kinds: []string{}, from: 5, to: 7},
+	}, d.rules)
+}
+
+func TestParseBlockComment(t *testing.T) {
+	t.Parallel()
+	d := Parse([]byte("/* cfv-disable-file schema */\n{}"), []string{"//", "/*"})
+	require.Equal(t, []rule{{name: "cfv-disable-file", line: 1, kinds: []string{"schema"}, from: 0, to: -1}}, d.rules)
+}
+
+func TestApplyNextLine(t *testing.T) {
+	t.Parallel()
+	d := Parse([]byte("# cfv-disable-next-line -- fixture\nport: x\nhost: 1\n"), []string{"#"})
+	r := d.Apply(failed([]int{2, 3},
+		"schema: line 2, column 7: port: Invalid type",
+		"schema: line 3, column 7: host: Invalid type"))
+
+	require.False(t, r.IsValid)
+	require.Equal(t, []string{"schema: line 3, column 7: host: Invalid type"}, r.ValidationErrors)
+	require.Equal(t, []int{3}, r.ErrorLines)
+	require.Equal(t, 3, r.StartLine)
+	require.Equal(t, []string{
+		"suppressed by cfv-disable-next-line on line 1: schema: line 2, column 7: port: Invalid type (reason: fixture)",
+	}, r.Notes)
+}
+
+func TestApplyKinds(t *testing.T) {
+	t.Parallel()
+	d := Parse([]byte("# cfv-disable-file syntax\n"), []string{"#"})
+	r := d.Apply(failed([]int{2}, "schema: line 2, column 1: port: Invalid type"))
+	require.False(t, r.IsValid, "a syntax-only directive must not suppress schema errors")
+	require.Empty(t, r.Notes)
+
+	d = Parse([]byte("# cfv-disable-file schema\n"), []string{"#"})
+	r = d.Apply(failed([]int{0, 2}, "schema: (root): name is required", "schema: line 2, column 1: port: Invalid type"))
+	require.True(t, r.IsValid)
+	require.NoError(t, r.ValidationError)
+	require.Empty(t, r.ErrorType)
+	require.Len(t, r.Notes, 2)
+}
+
+func TestApplyBlock(t *testing.T) {
+	t.Parallel()
+	content := "a: 1\n# cfv-disable\nb: 2\n# cfv-enable\nc: 3\n# cfv-disable\nd: 4\n"
+	d := Parse([]byte(content), []string{"#"})
+	r := d.Apply(failed([]int{0, 1, 3, 5, 7},
+		"schema: (root): x is required",
+		"schema: line 1, column 1: a: bad",
+		"schema: line 3, column 1: b: bad",
+		"schema: line 5, column 1: c: bad",
+		"schema: line 7, column 1: d: bad"))
+	require.Equal(t, []string{
+		"schema: (root): x is required",
+		"schema: line 1, column 1: a: bad",
+		"schema: line 5, column 1: c: bad",
+	}, r.ValidationErrors)
+	require.Len(t, r.Notes, 2)
+}
+
+func TestApplyWithoutDirectives(t *testing.T) {
+	t.Parallel()
+	report := failed([]int{1}, "syntax: line 1, column 1: bad")
+	r := Parse([]byte("bad"), []string{"#"}).Apply(report)
+	require.Equal(t, report, r)
+}
+
+func TestApplyRuleIDs(t *testing.T) {
+	t.Parallel()
+	report := failed([]int{2, 3},
+		"schema: line 2, column 1: port: Invalid type",
+		"schema: line 3, column 1: host is required")
+	report.ErrorRules = []string{"schema/type", "schema/required"}
+
+	r := Parse([]byte("# cfv-disable-file schema/required\n"), []string{"#"}).Apply(report)
+	require.Equal(t, []string{"schema: line 2, column 1: port: Invalid type"}, r.ValidationErrors)
+	require.Equal(t, []string{"schema/type"}, r.ErrorRules)
+
+	r = Parse([]byte("# cfv-disable-file schema/*\n"), []string{"#"}).Apply(report)
+	require.True(t, r.IsValid)
+	require.Len(t, r.Notes, 2)
+
+	r = Parse([]byte("# cfv-disable-file json/*\n"), []string{"#"}).Apply(report)
+	require.Len(t, r.ValidationErrors, 2)
+}
+
+func TestApplySyntaxErrors(t *testing.T) {
+	t.Parallel()
+	report := failed([]int{2, 3},
+		"syntax: line 2, column 1: unexpected token",
+		"syntax: line 3, column 1: duplicate key \"a\"")
+	report.ErrorType = "syntax"
+	report.ErrorRules = []string{"json/syntax", "json/duplicate-key"}
+
+	for _, directive := range []string{"// cfv-disable-file", "// cfv-disable-file json/*"} {
+		r := Parse([]byte(directive+"\n"), []string{"//"}).Apply(report)
+		require.Equal(t, []string{"syntax: line 2, column 1: unexpected token"}, r.ValidationErrors, directive)
+		require.Equal(t, []string{"json/syntax"}, r.ErrorRules, directive)
+	}
+
+	for _, directive := range []string{"// cfv-disable-file syntax", "// cfv-disable-file json/syntax, json/duplicate-key"} {
+		r := Parse([]byte(directive+"\n"), []string{"//"}).Apply(report)
+		require.True(t, r.IsValid, directive)
+		require.Len(t, r.Notes, 2, directive)
+	}
+
+	r := Parse([]byte("# cfv-disable-next-line\nbad\n"), []string{"#"}).Apply(failed([]int{2}, "syntax: line 2, column 1: bad"))
+	require.False(t, r.IsValid, "a bare directive must not suppress a syntax error without a rule ID")
+}
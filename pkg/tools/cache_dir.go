@@ -0,0 +1,19 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// CacheDir returns the root directory for files cached by the validator:
+// $XDG_CACHE_HOME/cfv, or ~/.cache/cfv when XDG_CACHE_HOME is not set.
+func CacheDir() (string, error) {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "cfv"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".cache", "cfv"), nil
+}
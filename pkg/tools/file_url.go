@@ -29,3 +29,20 @@ func pathToFileURL(path, volume string) string {
 	}
 	return (&url.URL{Scheme: "file", Path: path}).String()
 }
+
+// FileURLToPath converts a file URL produced by FileURL back to a
+// filesystem path. It reports false for URLs with any other scheme.
+func FileURLToPath(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	path := u.Path
+	switch {
+	case u.Host != "":
+		path = "//" + u.Host + path
+	case len(path) >= 3 && path[0] == '/' && path[2] == ':':
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), true
+}
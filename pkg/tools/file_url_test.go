@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"path/filepath"
 	"testing"
 )
 
@@ -47,3 +48,30 @@ func TestPathToFileURL(t *testing.T) {
 		})
 	}
 }
+
+func TestFileURLToPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		url    string
+		want   string
+		wantOK bool
+	}{
+		{name: "unix", url: "file:///tmp/schema%20file%231.json", want: "/tmp/schema file#1.json", wantOK: true},
+		{name: "drive letter", url: "file:///C:/work/schema.json", want: filepath.FromSlash("C:/work/schema.json"), wantOK: true},
+		{name: "UNC path", url: "file://server/share/schema.json", want: filepath.FromSlash("//server/share/schema.json"), wantOK: true},
+		{name: "https", url: "https://example.com/schema.json", wantOK: false},
+		{name: "relative", url: "schema.json", wantOK: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := FileURLToPath(test.url)
+			if ok != test.wantOK || got != test.want {
+				t.Fatalf("FileURLToPath(%q) = %q, %v, want %q, %v", test.url, got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package validator
+
+func (YAMLValidator) CommentPrefixes() []string     { return []string{"#"} }
+func (TomlValidator) CommentPrefixes() []string     { return []string{"#"} }
+func (IniValidator) CommentPrefixes() []string      { return []string{"#", ";"} }
+func (HclValidator) CommentPrefixes() []string      { return []string{"#", "//", "/*"} }
+func (JSONCValidator) CommentPrefixes() []string    { return []string{"//", "/*"} }
+func (PropValidator) CommentPrefixes() []string     { return []string{"#", "!"} }
+func (EnvValidator) CommentPrefixes() []string      { return []string{"#"} }
+func (JustfileValidator) CommentPrefixes() []string { return []string{"#"} }
+func (HoconValidator) CommentPrefixes() []string    { return []string{"#", "//"} }
+func (CueValidator) CommentPrefixes() []string      { return []string{"//"} }
+func (KdlValidator) CommentPrefixes() []string      { return []string{"//", "/*"} }
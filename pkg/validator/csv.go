@@ -31,6 +31,9 @@ func (v CsvValidator) ValidateSyntax(b []byte) (bool, error) {
 	}
 	csvReader.LazyQuotes = v.LazyQuotes
 
+	// A ParseError leaves the reader at the start of the next record, so
+	// keep reading to report every malformed record.
+	var items []*ValidationError
 	for {
 		_, err := csvReader.Read()
 		if errors.Is(err, io.EOF) {
@@ -39,16 +42,19 @@ func (v CsvValidator) ValidateSyntax(b []byte) (bool, error) {
 
 		if err != nil {
 			var pe *csv.ParseError
-			if errors.As(err, &pe) {
-				return false, &ValidationError{
-					Err:    err,
-					Line:   pe.Line,
-					Column: pe.Column,
-				}
+			if !errors.As(err, &pe) {
+				return false, err
 			}
-			return false, err
+			items = append(items, &ValidationError{
+				Err:    err,
+				Line:   pe.Line,
+				Column: pe.Column,
+			})
 		}
 	}
+	if len(items) > 0 {
+		return false, syntaxErrors(items)
+	}
 
 	return true, nil
 }
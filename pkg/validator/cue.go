@@ -1,33 +1,163 @@
 package validator
 
 import (
-	"errors"
+	"fmt"
+	"os"
+	"strings"
 
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
 	cueerrors "cuelang.org/go/cue/errors"
 	"cuelang.org/go/cue/parser"
 )
 
+// CueValidator validates CUE files. The parser recovers from syntax errors
+// and reports up to ten of them, each on a different line.
 type CueValidator struct{}
 
 var _ Validator = CueValidator{}
 
+// ValidateCUE satisfies the CUESchemaValidator interface.
+func (CueValidator) ValidateCUE(b []byte, schemaPath string) (bool, error) {
+	return ValidateCUE(b, schemaPath)
+}
+
 func (CueValidator) ValidateSyntax(b []byte) (bool, error) {
 	_, err := parser.ParseFile("input.cue", b)
 	if err == nil {
 		return true, nil
 	}
 
-	var cerr cueerrors.Error
-	if errors.As(err, &cerr) && cerr != nil {
+	var items []*ValidationError
+	for _, cerr := range cueerrors.Errors(err) {
 		pos := cerr.Position()
-		if pos.IsValid() {
-			return false, &ValidationError{
-				Err:    err,
-				Line:   pos.Line(),
-				Column: pos.Column(),
-			}
+		if !pos.IsValid() {
+			continue
 		}
+		items = append(items, &ValidationError{
+			Err:    cerr,
+			Line:   pos.Line(),
+			Column: pos.Column(),
+		})
+	}
+	if len(items) > 0 {
+		return false, syntaxErrors(items)
 	}
 
 	return false, err
 }
+
+// cueDocumentName is the file name CUE errors give the validated document.
+const cueDocumentName = "document.cue"
+
+// IsCUESchema reports whether schemaPath names a CUE schema: a .cue file,
+// optionally followed by "#" and the name of a definition in it, as in
+// "schemas/app.cue#Config".
+func IsCUESchema(schemaPath string) bool {
+	file, _ := splitCUESchema(schemaPath)
+	return strings.HasSuffix(file, ".cue")
+}
+
+func splitCUESchema(schemaPath string) (file, definition string) {
+	if i := strings.LastIndex(schemaPath, ".cue#"); i >= 0 {
+		return schemaPath[:i+len(".cue")], schemaPath[i+len(".cue#"):]
+	}
+	return schemaPath, ""
+}
+
+// ValidateCUE validates a CUE or JSON document against a CUE schema, as
+// "cue vet" does: the document is unified with the schema, or with the
+// definition named after "#" in schemaPath, and every value must then be
+// concrete. Definitions are closed, so fields the definition does not
+// declare are violations too.
+func ValidateCUE(b []byte, schemaPath string) (bool, error) {
+	return validateCUE(b, schemaPath, func(err cueerrors.Error, _ []string) SchemaErrorPosition {
+		return cueDocumentPosition(err)
+	})
+}
+
+// ValidateCUEWithPositions validates a JSON document converted from another
+// format against a CUE schema, like ValidateCUE, and annotates errors with
+// the source positions of their paths in posMap instead of positions in
+// the JSON document.
+func ValidateCUEWithPositions(b []byte, schemaPath string, posMap map[string]SourcePosition) (bool, error) {
+	return validateCUE(b, schemaPath, func(_ cueerrors.Error, path []string) SchemaErrorPosition {
+		return lookupPosition(posMap, strings.Join(append([]string{"(root)"}, path...), "."))
+	})
+}
+
+// validateCUE validates b against the schema at schemaPath and locates each
+// error with locate, which receives the error and its path.
+func validateCUE(b []byte, schemaPath string, locate func(err cueerrors.Error, path []string) SchemaErrorPosition) (bool, error) {
+	file, definition := splitCUESchema(schemaPath)
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return false, fmt.Errorf("schema compilation error: %w", err)
+	}
+
+	ctx := cuecontext.New()
+	schema := ctx.CompileBytes(src, cue.Filename(file))
+	if err := schema.Err(); err != nil {
+		return false, fmt.Errorf("schema compilation error: %w", err)
+	}
+	if definition != "" {
+		schema = schema.LookupPath(cue.MakePath(cue.Def(definition)))
+		if !schema.Exists() {
+			return false, fmt.Errorf("schema compilation error: %s has no definition #%s", file, definition)
+		}
+	}
+
+	doc := ctx.CompileBytes(b, cue.Filename(cueDocumentName))
+	if err := doc.Err(); err != nil {
+		return false, fmt.Errorf("cue parse error: %w", err)
+	}
+
+	err = schema.Unify(doc).Validate(cue.Concrete(true), cue.All())
+	if err == nil {
+		return true, nil
+	}
+	var msgs, rules []string
+	var positions []SchemaErrorPosition
+	for _, cerr := range cueerrors.Errors(err) {
+		path := cueErrorPath(cerr, definition)
+		msgs = append(msgs, cueErrorMessage(cerr, path))
+		rules = append(rules, RuleCUESchema)
+		positions = append(positions, locate(cerr, path))
+	}
+	if len(msgs) == 0 {
+		return false, fmt.Errorf("schema validation failed: %w", err)
+	}
+	return false, &SchemaErrors{Prefix: "schema validation failed: ", Items: msgs, Positions: positions, Rules: rules}
+}
+
+// cueErrorPath returns the path of err relative to the definition the
+// document was validated against.
+func cueErrorPath(err cueerrors.Error, definition string) []string {
+	path := err.Path()
+	if definition != "" && len(path) > 0 && path[0] == "#"+definition {
+		path = path[1:]
+	}
+	return path
+}
+
+// cueErrorMessage formats an error as "path: message", without the file
+// positions CUE appends.
+func cueErrorMessage(err cueerrors.Error, path []string) string {
+	format, args := err.Msg()
+	msg := fmt.Sprintf(format, args...)
+	if path := strings.Join(path, "."); path != "" {
+		msg = path + ": " + msg
+	}
+	return msg
+}
+
+// cueDocumentPosition returns the first position of err in the validated
+// document, or the zero position when err only points into the schema.
+func cueDocumentPosition(err cueerrors.Error) SchemaErrorPosition {
+	for _, pos := range append(err.InputPositions(), err.Position()) {
+		if pos.IsValid() && pos.Filename() == cueDocumentName {
+			return SchemaErrorPosition{Line: pos.Line(), Column: pos.Column()}
+		}
+	}
+	return SchemaErrorPosition{}
+}
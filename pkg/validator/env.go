@@ -1,8 +1,12 @@
 package validator
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"errors"
+	"regexp"
+	"strings"
 
 	"github.com/hashicorp/go-envparse"
 )
@@ -12,19 +16,70 @@ type EnvValidator struct{}
 var _ Validator = EnvValidator{}
 
 // Validate implements the Validator interface by attempting to
-// parse a byte array of a env file using envparse package
+// parse a byte array of a env file using envparse package. After an
+// error, parsing resumes on the line that follows it, so every error the
+// parser finds is reported.
 func (EnvValidator) ValidateSyntax(b []byte) (bool, error) {
-	r := bytes.NewReader(b)
-	_, err := envparse.Parse(r)
+	var items []*ValidationError
+	rest, offset := b, 0
+	for {
+		_, err := envparse.Parse(bytes.NewReader(rest))
+		if err == nil {
+			break
+		}
+		var pe *envparse.ParseError
+		if !errors.As(err, &pe) {
+			return false, err
+		}
+		if errors.Is(pe.Err, bufio.ErrTooLong) {
+			// The parser gave up after the line before the long one.
+			items = append(items, &ValidationError{Err: pe.Err, Line: offset + pe.Line + 1})
+			break
+		}
+		items = append(items, &ValidationError{Err: pe.Err, Line: offset + pe.Line})
+		rest, offset = skipLines(rest, pe.Line), offset+pe.Line
+	}
+	if len(items) == 0 {
+		return true, nil
+	}
+	return false, syntaxErrors(items)
+}
+
+// skipLines returns b without its first n lines.
+func skipLines(b []byte, n int) []byte {
+	for range n {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			return nil
+		}
+		b = b[i+1:]
+	}
+	return b
+}
+
+// MarshalToJSON converts an env file to a flat JSON object of string
+// values, keyed by variable name, for schema validation. Values are
+// unquoted and unescaped, and a repeated variable keeps its last value.
+func (EnvValidator) MarshalToJSON(b []byte) ([]byte, error) {
+	vars, err := envparse.Parse(bytes.NewReader(b))
 	if err != nil {
-		var customError *envparse.ParseError
-		if errors.As(err, &customError) {
-			return false, &ValidationError{
-				Err:  customError.Err,
-				Line: customError.Line,
-			}
+		return nil, err
+	}
+	return json.Marshal(vars)
+}
+
+var envKeyRe = regexp.MustCompile(`^(?:export\s+)?([A-Za-z_][A-Za-z0-9_.]*)\s*=`)
+
+// PositionMap locates the variables of the file in b. A repeated variable
+// is located at its last occurrence, whose value MarshalToJSON keeps.
+func (EnvValidator) PositionMap(b []byte) map[string]SourcePosition {
+	positions := map[string]SourcePosition{"(root)": {Line: 1, Column: 1}}
+	for i, line := range strings.Split(string(b), "\n") {
+		content := strings.TrimLeft(line, " \t")
+		if m := envKeyRe.FindStringSubmatchIndex(content); m != nil {
+			column := len(line) - len(content) + m[2] + 1
+			positions["(root)."+content[m[2]:m[3]]] = SourcePosition{Line: i + 1, Column: column}
 		}
-		return false, err
 	}
-	return true, nil
+	return positions
 }
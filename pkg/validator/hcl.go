@@ -1,10 +1,17 @@
 package validator
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // HclValidator is used to validate a byte slice that is intended to represent a
@@ -15,35 +22,223 @@ var _ Validator = HclValidator{}
 
 // Validate checks if the provided byte slice represents a valid .hcl file.
 //
-// The hcl parser uses FIFO to determine which error to display to the user. For
-// more information, see the documentation at:
+// The hcl parser recovers from most errors, so a file can produce several
+// diagnostics. For more information, see the documentation at:
 //
-// https://pkg.go.dev/github.com/hashicorp/hcl/v2#Diagnostics.Error
+// https://pkg.go.dev/github.com/hashicorp/hcl/v2#Diagnostics
 //
-// If the hcl.Diagnostics slice contains more than one error, the wrapped
-// error returned by this function will include them as "and {count} other
-// diagnostic(s)" in the error message.
+// Each diagnostic is returned as its own positioned error; when there is
+// more than one they are wrapped in a *SyntaxErrors.
 func (HclValidator) ValidateSyntax(b []byte) (bool, error) {
 	_, diags := hclparse.NewParser().ParseHCL(b, "")
 	if diags == nil {
 		return true, nil
 	}
 
-	subject := diags[0].Subject
-	row := subject.Start.Line
-	col := subject.Start.Column
+	items := make([]*ValidationError, 0, len(diags))
+	for _, diag := range diags {
+		msg := diag.Summary
+		if diag.Detail != "" {
+			msg += ": " + diag.Detail
+		}
+		ve := &ValidationError{Err: errors.New(strings.TrimSpace(msg))}
+		if diag.Subject != nil {
+			ve.Line = diag.Subject.Start.Line
+			ve.Column = diag.Subject.Start.Column
+		}
+		items = append(items, ve)
+	}
+
+	return false, syntaxErrors(items)
+}
 
-	msg := diags[0].Summary
-	if diags[0].Detail != "" {
-		msg += ": " + diags[0].Detail
+// MarshalToJSON converts an HCL file to a JSON object for schema
+// validation. Attributes become properties holding their values. An
+// attribute that needs variables or functions to evaluate, such as
+// var.region or "${var.name}-logs", holds its source text instead, without
+// the quotes of a quoted string. Blocks follow
+// HCL's JSON syntax: a block becomes a property named by its type, with
+// one nested object per label, ending in its body, so resource "a" "b" {}
+// becomes {"resource": {"a": {"b": {}}}}. Blocks repeated with the same
+// type and labels become an array of bodies.
+func (HclValidator) MarshalToJSON(b []byte) ([]byte, error) {
+	file, diags := hclparse.NewParser().ParseHCL(b, "")
+	if diags.HasErrors() {
+		return nil, diags
 	}
-	if len(diags) > 1 {
-		msg += fmt.Sprintf(" (and %d other diagnostic(s))", len(diags)-1)
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, errors.New("unsupported HCL body")
 	}
+	doc, err := hclBodyToJSON(body, b)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
 
-	return false, &ValidationError{
-		Err:    fmt.Errorf("%s", strings.TrimSpace(msg)),
-		Line:   row,
-		Column: col,
+func hclBodyToJSON(body *hclsyntax.Body, src []byte) (map[string]any, error) {
+	obj := make(map[string]any, len(body.Attributes)+len(body.Blocks))
+	for name, attr := range body.Attributes {
+		obj[name] = hclExprToJSON(attr.Expr, src)
 	}
+	for _, block := range body.Blocks {
+		content, err := hclBodyToJSON(block.Body, src)
+		if err != nil {
+			return nil, err
+		}
+		parent, key := obj, block.Type
+		for _, label := range block.Labels {
+			child, isObject := parent[key].(map[string]any)
+			if !isObject {
+				if _, exists := parent[key]; exists {
+					return nil, hclBlockConflict(block)
+				}
+				child = make(map[string]any)
+				parent[key] = child
+			}
+			parent, key = child, label
+		}
+		existing, exists := parent[key]
+		switch existing := existing.(type) {
+		case map[string]any:
+			parent[key] = []any{existing, content}
+		case []any:
+			parent[key] = append(existing, content)
+		default:
+			if exists {
+				return nil, hclBlockConflict(block)
+			}
+			parent[key] = content
+		}
+	}
+	return obj, nil
+}
+
+func hclBlockConflict(block *hclsyntax.Block) error {
+	return fmt.Errorf("line %d: block %s conflicts with an attribute of the same name", block.TypeRange.Start.Line, block.Type)
+}
+
+func hclExprToJSON(expr hclsyntax.Expression, src []byte) any {
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() || !val.IsWhollyKnown() {
+		text := string(bytes.TrimSpace(expr.Range().SliceBytes(src)))
+		switch expr.(type) {
+		case *hclsyntax.TemplateExpr, *hclsyntax.TemplateWrapExpr:
+			if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' {
+				return text[1 : len(text)-1]
+			}
+		}
+		return text
+	}
+	return ctyToJSON(val)
+}
+
+func ctyToJSON(val cty.Value) any {
+	if val.IsNull() {
+		return nil
+	}
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		return val.AsString()
+	case ty == cty.Number:
+		return json.Number(val.AsBigFloat().Text('f', -1))
+	case ty == cty.Bool:
+		return val.True()
+	case ty.IsListType(), ty.IsSetType(), ty.IsTupleType():
+		items := make([]any, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, item := it.Element()
+			items = append(items, ctyToJSON(item))
+		}
+		return items
+	case ty.IsMapType(), ty.IsObjectType():
+		obj := make(map[string]any, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			key, item := it.Element()
+			obj[key.AsString()] = ctyToJSON(item)
+		}
+		return obj
+	default:
+		return nil
+	}
+}
+
+// PositionMap locates the attributes, blocks, object keys and tuple items
+// of the file in b, following the paths of MarshalToJSON.
+func (HclValidator) PositionMap(b []byte) map[string]SourcePosition {
+	file, diags := hclparse.NewParser().ParseHCL(b, "")
+	if diags.HasErrors() {
+		return nil
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+	positions := map[string]SourcePosition{"(root)": {Line: 1, Column: 1}}
+	walkHCLBody(body, "(root)", positions)
+	return positions
+}
+
+func walkHCLBody(body *hclsyntax.Body, path string, positions map[string]SourcePosition) {
+	for name, attr := range body.Attributes {
+		attrPath := path + "." + name
+		positions[attrPath] = hclPosition(attr.NameRange.Start)
+		walkHCLExpr(attr.Expr, attrPath, positions)
+	}
+
+	blockPaths := make([]string, len(body.Blocks))
+	count := make(map[string]int)
+	for i, block := range body.Blocks {
+		blockPaths[i] = path + "." + strings.Join(append([]string{block.Type}, block.Labels...), ".")
+		count[blockPaths[i]]++
+	}
+	index := make(map[string]int)
+	for i, block := range body.Blocks {
+		blockPath := path + "." + block.Type
+		setHCLPosition(positions, blockPath, block.TypeRange.Start)
+		for j, label := range block.Labels {
+			blockPath += "." + label
+			setHCLPosition(positions, blockPath, block.LabelRanges[j].Start)
+		}
+		// Repeated blocks become an array of bodies.
+		if count[blockPaths[i]] > 1 {
+			blockPath += "." + strconv.Itoa(index[blockPaths[i]])
+			index[blockPaths[i]]++
+			positions[blockPath] = hclPosition(block.TypeRange.Start)
+		}
+		walkHCLBody(block.Body, blockPath, positions)
+	}
+}
+
+func walkHCLExpr(expr hclsyntax.Expression, path string, positions map[string]SourcePosition) {
+	switch expr := expr.(type) {
+	case *hclsyntax.ObjectConsExpr:
+		for _, item := range expr.Items {
+			key, diags := item.KeyExpr.Value(nil)
+			if diags.HasErrors() || !key.IsKnown() || key.IsNull() || key.Type() != cty.String {
+				continue
+			}
+			itemPath := path + "." + key.AsString()
+			positions[itemPath] = hclPosition(item.KeyExpr.Range().Start)
+			walkHCLExpr(item.ValueExpr, itemPath, positions)
+		}
+	case *hclsyntax.TupleConsExpr:
+		for i, item := range expr.Exprs {
+			itemPath := path + "." + strconv.Itoa(i)
+			positions[itemPath] = hclPosition(item.Range().Start)
+			walkHCLExpr(item, itemPath, positions)
+		}
+	}
+}
+
+func setHCLPosition(positions map[string]SourcePosition, path string, pos hcl.Pos) {
+	if _, ok := positions[path]; !ok {
+		positions[path] = hclPosition(pos)
+	}
+}
+
+func hclPosition(pos hcl.Pos) SourcePosition {
+	return SourcePosition{Line: pos.Line, Column: pos.Column}
 }
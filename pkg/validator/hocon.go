@@ -1,7 +1,11 @@
 package validator
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"maps"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -38,3 +42,96 @@ func (HoconValidator) ValidateSyntax(b []byte) (bool, error) {
 
 	return true, nil
 }
+
+// MarshalToJSON converts a HOCON document to JSON for schema validation,
+// with substitutions resolved. Durations become strings such as "1m30s",
+// and a field whose optional substitution (${?VAR}) is unset is omitted.
+func (HoconValidator) MarshalToJSON(b []byte) ([]byte, error) {
+	config, err := hocon.ParseString(string(b))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(hoconToJSON(config.GetRoot()))
+}
+
+func hoconToJSON(v hocon.Value) any {
+	switch v := v.(type) {
+	case hocon.Object:
+		obj := make(map[string]any, len(v))
+		for key, val := range v {
+			if val != nil {
+				obj[key] = hoconToJSON(val)
+			}
+		}
+		return obj
+	case hocon.Array:
+		arr := make([]any, 0, len(v))
+		for _, val := range v {
+			if val != nil {
+				arr = append(arr, hoconToJSON(val))
+			}
+		}
+		return arr
+	case hocon.String:
+		return strings.Trim(string(v), `"`)
+	case hocon.Int:
+		return int(v)
+	case hocon.Float32:
+		return float32(v)
+	case hocon.Float64:
+		return float64(v)
+	case hocon.Boolean:
+		return bool(v)
+	case hocon.Null:
+		return nil
+	case hocon.Duration:
+		return v.String()
+	case nil:
+		return nil
+	}
+	if v.Type() == hocon.ConcatenationType {
+		return hoconConcatenationToJSON(v)
+	}
+	return v.String()
+}
+
+// hoconConcatenationToJSON joins the values of a concatenation such as
+// "${host}:${port}". The parser does not export the concatenation type, so
+// its values are read through reflection. A concatenation of objects or
+// of arrays merges them.
+func hoconConcatenationToJSON(v hocon.Value) any {
+	rv := reflect.ValueOf(v)
+	var parts []any
+	for i := range rv.Len() {
+		if part, ok := rv.Index(i).Interface().(hocon.Value); ok && part != nil {
+			parts = append(parts, hoconToJSON(part))
+		}
+	}
+
+	var merged map[string]any
+	var joined []any
+	var sb strings.Builder
+	for _, part := range parts {
+		switch part := part.(type) {
+		case map[string]any:
+			if merged == nil {
+				merged = make(map[string]any)
+			}
+			maps.Copy(merged, part)
+		case []any:
+			joined = append(joined, part...)
+		case string:
+			sb.WriteString(part)
+		default:
+			fmt.Fprint(&sb, part)
+		}
+	}
+	switch {
+	case merged != nil:
+		return merged
+	case joined != nil:
+		return joined
+	default:
+		return sb.String()
+	}
+}
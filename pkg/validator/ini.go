@@ -1,7 +1,9 @@
 package validator
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"gopkg.in/ini.v1"
 )
@@ -27,7 +29,7 @@ func (v IniValidator) ValidateSyntax(b []byte) (bool, error) {
 
 	if v.ForbidDuplicateKeys {
 		if err := checkINIDuplicateKeys(f); err != nil {
-			return false, err
+			return false, &ValidationError{Err: err, Rule: RuleINIDuplicateKey}
 		}
 	}
 
@@ -49,3 +51,63 @@ func checkINIDuplicateKeys(f *ini.File) error {
 	}
 	return nil
 }
+
+// MarshalToJSON converts an INI file to a JSON object for schema
+// validation. Keys outside any section become top-level properties, and
+// each section becomes an object property holding its keys. Values are
+// strings, since INI has no types, and a repeated key keeps its last
+// value.
+func (IniValidator) MarshalToJSON(b []byte) ([]byte, error) {
+	f, err := ini.LoadSources(ini.LoadOptions{}, b)
+	if err != nil {
+		return nil, err
+	}
+	doc := make(map[string]any)
+	for _, section := range f.Sections() {
+		keys := make(map[string]any, len(section.Keys()))
+		for _, key := range section.Keys() {
+			keys[key.Name()] = key.Value()
+		}
+		if section.Name() == ini.DefaultSection {
+			for name, value := range keys {
+				doc[name] = value
+			}
+			continue
+		}
+		if _, exists := doc[section.Name()]; exists {
+			return nil, fmt.Errorf("section [%s] conflicts with the key of the same name", section.Name())
+		}
+		doc[section.Name()] = keys
+	}
+	return json.Marshal(doc)
+}
+
+// PositionMap locates the sections and keys of the file in b. A repeated
+// key is located at its last occurrence, whose value MarshalToJSON keeps.
+func (IniValidator) PositionMap(b []byte) map[string]SourcePosition {
+	positions := map[string]SourcePosition{"(root)": {Line: 1, Column: 1}}
+	section := "(root)"
+	for i, line := range strings.Split(string(b), "\n") {
+		content := strings.TrimSpace(line)
+		column := strings.Index(line, content) + 1
+		switch {
+		case content == "", content[0] == ';', content[0] == '#':
+		case content[0] == '[':
+			name, _, _ := strings.Cut(content[1:], "]")
+			name = strings.TrimSpace(name)
+			section = "(root)"
+			if name != ini.DefaultSection {
+				section += "." + name
+				if _, ok := positions[section]; !ok {
+					positions[section] = SourcePosition{Line: i + 1, Column: column}
+				}
+			}
+		default:
+			if end := strings.IndexAny(content, "=:"); end > 0 {
+				key := strings.TrimSpace(content[:end])
+				positions[section+"."+key] = SourcePosition{Line: i + 1, Column: column}
+			}
+		}
+	}
+	return positions
+}
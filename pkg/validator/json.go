@@ -35,7 +35,7 @@ func (v JSONValidator) ValidateSyntax(b []byte) (bool, error) {
 
 	if v.ForbidDuplicateKeys {
 		if err := checkJSONDuplicateKeys(b); err != nil {
-			return false, err
+			return false, &ValidationError{Err: err, Rule: RuleJSONDuplicateKey}
 		}
 	}
 
@@ -54,6 +54,11 @@ func (JSONValidator) MarshalToJSON(b []byte) ([]byte, error) {
 	return b, nil
 }
 
+// PositionMap locates the keys of the document in b.
+func (JSONValidator) PositionMap(b []byte) map[string]SourcePosition {
+	return buildJSONPositionMap(b)
+}
+
 func (JSONValidator) ValidateSchema(b []byte, filePath string) (bool, error) {
 	var raw any
 	if err := json.Unmarshal(b, &raw); err != nil {
@@ -45,6 +45,17 @@ func (JSONCValidator) MarshalToJSON(b []byte) ([]byte, error) {
 	return standardized, nil
 }
 
+// PositionMap locates the keys of the document in b. Standardizing replaces
+// comments and trailing commas with spaces, so offsets in the standard JSON
+// are offsets in b.
+func (JSONCValidator) PositionMap(b []byte) map[string]SourcePosition {
+	standardized, err := hujson.Standardize(b)
+	if err != nil {
+		return nil
+	}
+	return buildJSONPositionMap(standardized)
+}
+
 func (JSONCValidator) ValidateSchema(b []byte, filePath string) (bool, error) {
 	standardized, err := hujson.Standardize(b)
 	if err != nil {
@@ -82,7 +93,7 @@ func (JSONCValidator) ValidateSchema(b []byte, filePath string) (bool, error) {
 		return false, err
 	}
 
-	return JSONSchemaValidate(schemaURL, cleanDoc)
+	return JSONSchemaValidateWithPositions(schemaURL, cleanDoc, buildJSONPositionMap(standardized))
 }
 
 // parseHujsonError extracts line and column from hujson error messages.
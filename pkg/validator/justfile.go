@@ -6,6 +6,9 @@ import (
 	"github.com/Boeing/config-file-validator/v2/pkg/validator/justfile"
 )
 
+// JustfileValidator validates justfiles. The parser stops at the first
+// syntax error, but every error the analyzer finds in a file that parses is
+// reported.
 type JustfileValidator struct{}
 
 var _ Validator = JustfileValidator{}
@@ -24,16 +27,52 @@ func (JustfileValidator) ValidateSyntax(b []byte) (bool, error) {
 		return false, err
 	}
 
-	diags := jf.Validate()
-	for _, d := range diags {
-		if d.Severity == justfile.SeverityError {
-			return false, &ValidationError{
-				Err:    errors.New(d.Message),
-				Line:   d.Pos.Line,
-				Column: d.Pos.Column,
-			}
+	var items []*ValidationError
+	for _, d := range jf.Validate() {
+		if d.Severity != justfile.SeverityError {
+			continue
 		}
+		items = append(items, &ValidationError{
+			Err:    errors.New(d.Message),
+			Line:   d.Pos.Line,
+			Column: d.Pos.Column,
+			Rule:   justfileRule(d.Code),
+		})
+	}
+	if len(items) > 0 {
+		return false, syntaxErrors(items)
 	}
 
 	return true, nil
 }
+
+// Diagnostics returns the analyzer's warnings, which ValidateSyntax does not
+// treat as failures.
+func (JustfileValidator) Diagnostics(b []byte) []Diagnostic {
+	jf, err := justfile.Parse(b)
+	if err != nil {
+		return nil
+	}
+	var diags []Diagnostic
+	for _, d := range jf.Validate() {
+		if d.Severity != justfile.SeverityWarning {
+			continue
+		}
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Message:  d.Message,
+			Line:     d.Pos.Line,
+			Column:   d.Pos.Column,
+			Rule:     justfileRule(d.Code),
+		})
+	}
+	return diags
+}
+
+// justfileRule returns the rule ID for an analyzer diagnostic code.
+func justfileRule(code string) string {
+	if code == "" {
+		return SyntaxRule("justfile")
+	}
+	return "justfile/" + code
+}
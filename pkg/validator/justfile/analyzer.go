@@ -47,21 +47,21 @@ func (a *analyzer) analyze(jf *Justfile) {
 func (a *analyzer) collectDefinitions(jf *Justfile) {
 	for _, r := range jf.Recipes {
 		if prev, ok := a.recipes[r.Name]; ok && !a.allowDuplicateRecipes {
-			a.error(r.Pos, "recipe '%s' is defined multiple times (first defined at %s)", r.Name, prev)
+			a.error(r.Pos, "duplicate-recipe", "recipe '%s' is defined multiple times (first defined at %s)", r.Name, prev)
 		}
 		a.recipes[r.Name] = r.Pos
 	}
 
 	for _, v := range jf.Assignments {
 		if prev, ok := a.variables[v.Name]; ok && !a.allowDuplicateVariables {
-			a.error(v.Pos, "variable '%s' is defined multiple times (first defined at %s)", v.Name, prev)
+			a.error(v.Pos, "duplicate-variable", "variable '%s' is defined multiple times (first defined at %s)", v.Name, prev)
 		}
 		a.variables[v.Name] = v.Pos
 	}
 
 	for _, al := range jf.Aliases {
 		if prev, ok := a.aliases[al.Name]; ok {
-			a.error(al.Pos, "alias '%s' is defined multiple times (first defined at %s)", al.Name, prev)
+			a.error(al.Pos, "duplicate-alias", "alias '%s' is defined multiple times (first defined at %s)", al.Name, prev)
 		}
 		a.aliases[al.Name] = al.Pos
 	}
@@ -85,7 +85,7 @@ func (a *analyzer) checkRecipes(jf *Justfile) {
 func (a *analyzer) checkDependencies(r *Recipe) {
 	for _, dep := range r.Dependencies {
 		if _, ok := a.recipes[dep.Name]; !ok {
-			a.error(dep.Pos, "recipe '%s' depends on undefined recipe '%s'", r.Name, dep.Name)
+			a.error(dep.Pos, "undefined-recipe", "recipe '%s' depends on undefined recipe '%s'", r.Name, dep.Name)
 		}
 	}
 }
@@ -95,12 +95,12 @@ func (a *analyzer) checkParameters(r *Recipe) {
 	foundVariadic := false
 	for _, p := range r.Parameters {
 		if seen[p.Name] {
-			a.error(p.Pos, "recipe '%s' has duplicate parameter '%s'", r.Name, p.Name)
+			a.error(p.Pos, "duplicate-parameter", "recipe '%s' has duplicate parameter '%s'", r.Name, p.Name)
 		}
 		seen[p.Name] = true
 
 		if foundVariadic {
-			a.error(p.Pos, "recipe '%s' has parameters after variadic parameter", r.Name)
+			a.error(p.Pos, "parameter-after-variadic", "recipe '%s' has parameters after variadic parameter", r.Name)
 		}
 		if p.Variadic != "" {
 			foundVariadic = true
@@ -128,7 +128,7 @@ func (a *analyzer) checkCircularDeps(jf *Justfile) {
 		for _, dep := range depGraph[name] {
 			if visit(dep) {
 				if pos, ok := a.recipes[name]; ok {
-					a.error(pos, "recipe '%s' has a circular dependency", name)
+					a.error(pos, "circular-dependency", "recipe '%s' has a circular dependency", name)
 				}
 				return true
 			}
@@ -159,7 +159,7 @@ func (a *analyzer) buildDepGraph(jf *Justfile, graph map[string][]string) {
 func (a *analyzer) checkAliases(jf *Justfile) {
 	for _, al := range jf.Aliases {
 		if _, ok := a.recipes[al.Target]; !ok {
-			a.error(al.Pos, "alias '%s' targets undefined recipe '%s'", al.Name, al.Target)
+			a.error(al.Pos, "undefined-alias-target", "alias '%s' targets undefined recipe '%s'", al.Name, al.Target)
 		}
 	}
 }
@@ -176,10 +176,10 @@ func (a *analyzer) checkSettings(jf *Justfile) {
 	seen := make(map[string]bool)
 	for _, s := range jf.Settings {
 		if !knownSettings[s.Name] {
-			a.warn(s.Pos, "unknown setting '%s'", s.Name)
+			a.warn(s.Pos, "unknown-setting", "unknown setting '%s'", s.Name)
 		}
 		if seen[s.Name] {
-			a.error(s.Pos, "setting '%s' is set multiple times", s.Name)
+			a.error(s.Pos, "duplicate-setting", "setting '%s' is set multiple times", s.Name)
 		}
 		seen[s.Name] = true
 	}
@@ -292,11 +292,11 @@ func (a *analyzer) checkExprVarRefs(expr Expression, locals map[string]bool) {
 			if _, ok := a.variables[v.Name]; ok {
 				return
 			}
-			a.error(v.Pos, "undefined variable '%s'", v.Name)
+			a.error(v.Pos, "undefined-variable", "undefined variable '%s'", v.Name)
 		case *FunctionCall:
 			if !builtinFunctions[v.Name] {
 				if _, ok := a.variables[v.Name]; !ok {
-					a.error(v.Pos, "undefined function '%s'", v.Name)
+					a.error(v.Pos, "undefined-function", "undefined function '%s'", v.Name)
 				}
 			}
 		default:
@@ -313,7 +313,7 @@ func (a *analyzer) checkExportUnexportConflicts(jf *Justfile) {
 
 	for name, unexportPos := range unexported {
 		if exportPos, ok := exported[name]; ok {
-			a.error(unexportPos, "variable '%s' is both exported (at %s) and unexported", name, exportPos)
+			a.error(unexportPos, "export-conflict", "variable '%s' is both exported (at %s) and unexported", name, exportPos)
 		}
 	}
 }
@@ -342,19 +342,21 @@ func (a *analyzer) collectUnexports(jf *Justfile, unexported map[string]Position
 	}
 }
 
-func (a *analyzer) error(pos Position, format string, args ...any) {
+func (a *analyzer) error(pos Position, code, format string, args ...any) {
 	a.diagnostics = append(a.diagnostics, Diagnostic{
 		Pos:      pos,
 		Severity: SeverityError,
+		Code:     code,
 		Message:  fmt.Sprintf(format, args...),
 		File:     a.file,
 	})
 }
 
-func (a *analyzer) warn(pos Position, format string, args ...any) {
+func (a *analyzer) warn(pos Position, code, format string, args ...any) {
 	a.diagnostics = append(a.diagnostics, Diagnostic{
 		Pos:      pos,
 		Severity: SeverityWarning,
+		Code:     code,
 		Message:  fmt.Sprintf(format, args...),
 		File:     a.file,
 	})
@@ -39,9 +39,12 @@ func (s Severity) String() string {
 	}
 }
 
+// Diagnostic is a problem found by the analyzer. Code is a short, stable
+// identifier of the kind of problem, such as "undefined-variable".
 type Diagnostic struct {
 	Pos      Position
 	Severity Severity
+	Code     string
 	Message  string
 	File     string
 }
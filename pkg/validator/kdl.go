@@ -2,13 +2,17 @@ package validator
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
 
 	kdl "github.com/sblinch/kdl-go"
+	"github.com/sblinch/kdl-go/document"
 )
 
 // KdlValidator validates KDL Document Language files via the sblinch/kdl-go
-// parser. It implements ValidateSyntax only — KDL has no schema system, so
-// it does not implement SchemaValidator.
+// parser. KDL has no schema system of its own, so documents are validated
+// against JSON Schemas through the projection described at MarshalToJSON.
 //
 // See https://kdl.dev/ for the KDL spec.
 type KdlValidator struct{}
@@ -21,3 +25,73 @@ func (KdlValidator) ValidateSyntax(b []byte) (bool, error) {
 	}
 	return true, nil
 }
+
+// kdlNode is the JSON projection of a KDL node.
+type kdlNode struct {
+	Args     []any                `json:"args,omitempty"`
+	Props    map[string]any       `json:"props,omitempty"`
+	Children map[string][]kdlNode `json:"children,omitempty"`
+}
+
+// MarshalToJSON projects a KDL document onto JSON for schema validation.
+// A document, like the children of a node, becomes an object that maps
+// each node name to the list of nodes with that name, in document order.
+// Each node is an object with "args", the list of its arguments, "props",
+// an object of its properties, and "children"; the keys of a node without
+// arguments, properties or children are omitted. Type annotations are
+// dropped. For example,
+//
+//	server "web" port=8080 {
+//	    tls enabled=#true
+//	}
+//
+// becomes
+//
+//	{"server": [{"args": ["web"], "props": {"port": 8080},
+//	  "children": {"tls": [{"props": {"enabled": true}}]}}]}
+func (KdlValidator) MarshalToJSON(b []byte) ([]byte, error) {
+	doc, err := kdl.Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	nodes := kdlNodesToJSON(doc.Nodes)
+	if nodes == nil {
+		nodes = map[string][]kdlNode{}
+	}
+	return json.Marshal(nodes)
+}
+
+func kdlNodesToJSON(nodes []*document.Node) map[string][]kdlNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	out := make(map[string][]kdlNode)
+	for _, n := range nodes {
+		node := kdlNode{Children: kdlNodesToJSON(n.Children)}
+		for _, arg := range n.Arguments {
+			node.Args = append(node.Args, kdlValueToJSON(arg))
+		}
+		if props := n.Properties.Unordered(); len(props) > 0 {
+			node.Props = make(map[string]any, len(props))
+			for name, val := range props {
+				node.Props[name] = kdlValueToJSON(val)
+			}
+		}
+		name := fmt.Sprint(n.Name.ResolvedValue())
+		out[name] = append(out[name], node)
+	}
+	return out
+}
+
+func kdlValueToJSON(v *document.Value) any {
+	switch val := v.ResolvedValue().(type) {
+	case string, bool, int64, float64, nil:
+		return val
+	case *big.Int:
+		return json.Number(val.String())
+	case *big.Float:
+		return json.Number(val.Text('g', -1))
+	default:
+		return v.ValueString()
+	}
+}
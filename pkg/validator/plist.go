@@ -2,10 +2,14 @@ package validator
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"howett.net/plist"
 )
@@ -38,3 +42,118 @@ func (PlistValidator) ValidateSyntax(b []byte) (bool, error) {
 	}
 	return true, nil
 }
+
+// MarshalToJSON converts a property list to JSON for schema validation.
+// Dictionaries become objects and arrays become arrays. Dates become RFC
+// 3339 strings in UTC and data becomes base64 strings, as JSON has no
+// types for them.
+func (PlistValidator) MarshalToJSON(b []byte) ([]byte, error) {
+	var output any
+	if err := plist.NewDecoder(bytes.NewReader(b)).Decode(&output); err != nil {
+		return nil, err
+	}
+	return json.Marshal(plistToJSON(output))
+}
+
+func plistToJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		obj := make(map[string]any, len(v))
+		for key, val := range v {
+			obj[key] = plistToJSON(val)
+		}
+		return obj
+	case []any:
+		arr := make([]any, len(v))
+		for i, val := range v {
+			arr[i] = plistToJSON(val)
+		}
+		return arr
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case plist.UID:
+		return uint64(v)
+	default:
+		return v
+	}
+}
+
+// PositionMap locates the dictionary keys and array items of an XML
+// property list in b. Binary and OpenStep property lists are not located.
+func (PlistValidator) PositionMap(b []byte) map[string]SourcePosition {
+	if !bytes.HasPrefix(bytes.TrimSpace(b), []byte("<")) {
+		return nil
+	}
+	w := plistPositions{dec: xml.NewDecoder(bytes.NewReader(b)), positions: make(map[string]SourcePosition)}
+	for {
+		start, pos, ok := w.nextStart()
+		if !ok {
+			return w.positions
+		}
+		if start.Name.Local != "plist" {
+			w.positions["(root)"] = pos
+			w.value(start, "(root)")
+			return w.positions
+		}
+	}
+}
+
+type plistPositions struct {
+	dec       *xml.Decoder
+	positions map[string]SourcePosition
+}
+
+// nextStart returns the next start element and its position, or false at
+// the end of the enclosing element or of the input.
+func (w *plistPositions) nextStart() (xml.StartElement, SourcePosition, bool) {
+	for {
+		line, column := w.dec.InputPos()
+		tok, err := w.dec.Token()
+		if err != nil {
+			return xml.StartElement{}, SourcePosition{}, false
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			return tok, SourcePosition{Line: line, Column: column}, true
+		case xml.EndElement:
+			return xml.StartElement{}, SourcePosition{}, false
+		}
+	}
+}
+
+func (w *plistPositions) value(start xml.StartElement, path string) {
+	switch start.Name.Local {
+	case "dict":
+		for {
+			keyStart, pos, ok := w.nextStart()
+			if !ok {
+				return
+			}
+			var key string
+			if keyStart.Name.Local != "key" || w.dec.DecodeElement(&key, &keyStart) != nil {
+				_ = w.dec.Skip()
+				continue
+			}
+			valueStart, _, ok := w.nextStart()
+			if !ok {
+				return
+			}
+			w.positions[path+"."+key] = pos
+			w.value(valueStart, path+"."+key)
+		}
+	case "array":
+		for i := 0; ; i++ {
+			itemStart, pos, ok := w.nextStart()
+			if !ok {
+				return
+			}
+			itemPath := path + "." + strconv.Itoa(i)
+			w.positions[itemPath] = pos
+			w.value(itemStart, itemPath)
+		}
+	default:
+		_ = w.dec.Skip()
+	}
+}
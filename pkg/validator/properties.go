@@ -1,20 +1,144 @@
 package validator
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/magiconair/properties"
 )
 
-type PropValidator struct{}
+// PropValidator validates Java properties files. When NestedKeys is true,
+// MarshalToJSON nests dotted keys into objects.
+type PropValidator struct {
+	NestedKeys bool
+}
 
 var _ Validator = PropValidator{}
 
 // Validate implements the Validator interface by attempting to
 // parse a byte array of properties
 func (PropValidator) ValidateSyntax(b []byte) (bool, error) {
-	l := &properties.Loader{Encoding: properties.UTF8}
-	_, err := l.LoadBytes(b)
+	_, err := loadProperties(b)
 	if err != nil {
 		return false, err
 	}
 	return true, nil
 }
+
+func loadProperties(b []byte) (*properties.Properties, error) {
+	l := &properties.Loader{Encoding: properties.UTF8}
+	return l.LoadBytes(b)
+}
+
+// MarshalToJSON converts a properties file to a JSON object of string
+// values for schema validation, after ${key} expansion. Keys are kept as
+// they are, so server.port becomes {"server.port": "8080"}, unless
+// NestedKeys is set, which splits them on dots into nested objects:
+// {"server": {"port": "8080"}}. With NestedKeys, a key that is both a
+// value and a prefix of other keys, such as server and server.port, is an
+// error.
+func (v PropValidator) MarshalToJSON(b []byte) ([]byte, error) {
+	p, err := loadProperties(b)
+	if err != nil {
+		return nil, err
+	}
+	doc := make(map[string]any, p.Len())
+	for _, key := range p.Keys() {
+		value, _ := p.Get(key)
+		if !v.NestedKeys {
+			doc[key] = value
+			continue
+		}
+		if err := setNestedProperty(doc, key, value); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(doc)
+}
+
+func setNestedProperty(doc map[string]any, key, value string) error {
+	parts := strings.Split(key, ".")
+	obj := doc
+	for i, part := range parts[:len(parts)-1] {
+		switch child := obj[part].(type) {
+		case nil:
+			next := make(map[string]any)
+			obj[part] = next
+			obj = next
+		case map[string]any:
+			obj = child
+		default:
+			return fmt.Errorf("property %q conflicts with property %q", key, strings.Join(parts[:i+1], "."))
+		}
+	}
+	last := parts[len(parts)-1]
+	if _, exists := obj[last]; exists {
+		return fmt.Errorf("property %q conflicts with the properties under it", key)
+	}
+	obj[last] = value
+	return nil
+}
+
+// PositionMap locates the properties of the file in b. A dotted key is
+// located under both its flat and its nested path, which are the same
+// "(root).server.port", and each prefix of it, such as "(root).server",
+// at its first occurrence for NestedKeys.
+func (PropValidator) PositionMap(b []byte) map[string]SourcePosition {
+	positions := map[string]SourcePosition{"(root)": {Line: 1, Column: 1}}
+	lines := strings.Split(string(b), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		content := strings.TrimLeft(line, " \t\f")
+		pos := SourcePosition{Line: i + 1, Column: len(line) - len(content) + 1}
+		// Skip the continuation lines of a value ending in a backslash.
+		for continued := line; propertyContinues(continued) && i+1 < len(lines); {
+			i++
+			continued = strings.TrimRight(lines[i], "\r")
+		}
+		if content == "" || content[0] == '#' || content[0] == '!' {
+			continue
+		}
+		key := propertyKey(content)
+		if key == "" {
+			continue
+		}
+		path := "(root)"
+		parts := strings.Split(key, ".")
+		for j, part := range parts {
+			path += "." + part
+			if _, ok := positions[path]; !ok || j == len(parts)-1 {
+				positions[path] = pos
+			}
+		}
+	}
+	return positions
+}
+
+// propertyContinues reports whether line ends in an odd number of
+// backslashes, which continue its value on the next line.
+func propertyContinues(line string) bool {
+	line = strings.TrimRight(line, "\r")
+	n := len(line) - len(strings.TrimRight(line, "\\"))
+	return n%2 == 1
+}
+
+// propertyKey returns the unescaped key of a property line: everything up to
+// the first unescaped '=', ':' or whitespace.
+func propertyKey(content string) string {
+	var key strings.Builder
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; c {
+		case '\\':
+			if i+1 < len(content) {
+				i++
+				key.WriteByte(content[i])
+			}
+		case '=', ':', ' ', '\t', '\f', '\r':
+			return key.String()
+		default:
+			key.WriteByte(c)
+		}
+	}
+	return key.String()
+}
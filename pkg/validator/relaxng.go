@@ -0,0 +1,589 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	relaxNGNamespace = "http://relaxng.org/ns/structure/1.0"
+	xsdDatatypes     = "http://www.w3.org/2001/XMLSchema-datatypes" //nolint:revive // datatype library URI; DevSkim: ignore DS137138
+)
+
+var relaxNGSchemaCache schemaCache[*relaxNGSchema]
+
+// relaxNGSchema is a compiled RELAX NG schema in the XML syntax.
+type relaxNGSchema struct {
+	start rngPattern
+}
+
+// IsRelaxNGSchema reports whether a --schema-map schema is a RELAX NG
+// schema in the XML syntax, named by its .rng extension.
+func IsRelaxNGSchema(schemaPath string) bool {
+	return strings.EqualFold(filepath.Ext(schemaPath), ".rng")
+}
+
+// ValidateRelaxNG validates XML bytes against the RELAX NG schema at
+// schemaPath. Exported for use by the CLI when applying external schemas.
+// Compiled schemas are cached per path and shared across calls.
+func ValidateRelaxNG(b []byte, schemaPath string) (bool, error) {
+	schema, err := relaxNGSchemaCache.get(schemaPath, func() (*relaxNGSchema, error) {
+		return compileRelaxNG(schemaPath)
+	})
+	if err != nil {
+		return false, fmt.Errorf("schema compilation error: %w", err)
+	}
+	return schema.validate(b)
+}
+
+// ValidateRelaxNG satisfies the RelaxNGSchemaValidator interface.
+func (XMLValidator) ValidateRelaxNG(b []byte, schemaPath string) (bool, error) {
+	return ValidateRelaxNG(b, schemaPath)
+}
+
+// rngNode is an element of a RELAX NG schema document, with the ns and
+// datatypeLibrary it inherits.
+type rngNode struct {
+	name            string
+	attrs           map[string]string
+	children        []*rngNode
+	text            string
+	ns              string
+	datatypeLibrary string
+	namespaces      map[string]string
+	location        string
+	line            int
+}
+
+func (n *rngNode) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", n.location, n.line, fmt.Sprintf(format, args...))
+}
+
+// patternChildren returns the children of n that are patterns, skipping
+// name classes and the other elements a parent reads itself.
+func (n *rngNode) patternChildren() []*rngNode {
+	var children []*rngNode
+	for _, c := range n.children {
+		switch c.name {
+		case "name", "anyName", "nsName", "param", "except":
+		default:
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// parseRelaxNG reads the RELAX NG schema at location. ns and
+// datatypeLibrary are the values the root inherits from an include or
+// externalRef.
+func parseRelaxNG(location, ns, datatypeLibrary string) (*rngNode, error) {
+	data, err := readXMLSchema(location, false)
+	if err != nil {
+		return nil, err
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var root *rngNode
+	var stack []*rngNode
+	foreign := 0
+	for {
+		line, _ := decoder.InputPos()
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", location, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if foreign > 0 || t.Name.Space != relaxNGNamespace {
+				foreign++
+				continue
+			}
+			n := &rngNode{name: t.Name.Local, attrs: make(map[string]string), location: location, line: line, ns: ns, datatypeLibrary: datatypeLibrary}
+			n.namespaces = map[string]string{"xml": "http://www.w3.org/XML/1998/namespace"}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				n.ns, n.datatypeLibrary, n.namespaces = parent.ns, parent.datatypeLibrary, parent.namespaces
+			}
+			cloned := false
+			for _, a := range t.Attr {
+				switch a.Name.Space {
+				case "xmlns":
+					if !cloned {
+						n.namespaces = maps.Clone(n.namespaces)
+						cloned = true
+					}
+					n.namespaces[a.Name.Local] = a.Value
+				case "":
+					n.attrs[a.Name.Local] = strings.TrimSpace(a.Value)
+				default:
+				}
+			}
+			if v, ok := n.attrs["ns"]; ok {
+				n.ns = v
+			}
+			if v, ok := n.attrs["datatypeLibrary"]; ok {
+				n.datatypeLibrary = v
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if foreign > 0 {
+				foreign--
+				continue
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if foreign == 0 && len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		default:
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("%s: not a RELAX NG schema", location)
+	}
+	return root, nil
+}
+
+// rngGrammar is the scope of a grammar element: its definitions, with the
+// start pattern under the empty name, and the grammar a parentRef reaches.
+type rngGrammar struct {
+	defines map[string]*rngDefine
+	parent  *rngGrammar
+}
+
+// rngDefine collects the define elements, or start elements, that share a
+// name, and the pattern they compile to.
+type rngDefine struct {
+	nodes     []*rngNode
+	combine   string
+	pattern   rngPattern
+	compiling bool
+}
+
+// rngCompiler turns the schema documents into patterns. Element content is
+// compiled after the enclosing definition, so that references may recurse
+// through elements but not around them.
+type rngCompiler struct {
+	pending   []rngPendingElement
+	including map[string]bool
+}
+
+type rngPendingElement struct {
+	element *rngPat
+	grammar *rngGrammar
+	content []*rngNode
+}
+
+// compileRelaxNG compiles the RELAX NG schema at schemaPath.
+func compileRelaxNG(schemaPath string) (*relaxNGSchema, error) {
+	root, err := parseRelaxNG(schemaPath, "", "")
+	if err != nil {
+		return nil, err
+	}
+	c := &rngCompiler{including: map[string]bool{schemaPath: true}}
+	start, err := c.pattern(nil, root)
+	if err != nil {
+		return nil, err
+	}
+	for len(c.pending) > 0 {
+		el := c.pending[0]
+		c.pending = c.pending[1:]
+		content, err := c.group(el.grammar, el.content)
+		if err != nil {
+			return nil, err
+		}
+		el.element.p1 = content
+	}
+	return &relaxNGSchema{start: start}, nil
+}
+
+// pattern compiles a pattern element.
+func (c *rngCompiler) pattern(g *rngGrammar, n *rngNode) (rngPattern, error) {
+	switch n.name {
+	case "element":
+		nc, content, err := c.named(n, false)
+		if err != nil {
+			return nil, err
+		}
+		el := &rngPat{kind: rngElement, nc: nc}
+		c.pending = append(c.pending, rngPendingElement{element: el, grammar: g, content: content})
+		return el, nil
+	case "attribute":
+		nc, content, err := c.named(n, true)
+		if err != nil {
+			return nil, err
+		}
+		value := rngTextPattern
+		if len(content) > 0 {
+			if value, err = c.group(g, content); err != nil {
+				return nil, err
+			}
+		}
+		return &rngPat{kind: rngAttribute, nc: nc, p1: value}, nil
+	case "group", "interleave", "choice", "optional", "zeroOrMore", "oneOrMore", "mixed", "list":
+		children := n.patternChildren()
+		if len(children) == 0 {
+			return nil, n.errorf("%s must contain a pattern", n.name)
+		}
+		var p rngPattern
+		for i, child := range children {
+			cp, err := c.pattern(g, child)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case i == 0:
+				p = cp
+			case n.name == "interleave":
+				p = rngInterleave(p, cp)
+			case n.name == "choice":
+				p = rngChoice(p, cp)
+			default:
+				p = rngGroup(p, cp)
+			}
+		}
+		switch n.name {
+		case "optional":
+			p = rngChoice(p, rngEmptyPattern)
+		case "zeroOrMore":
+			p = rngChoice(rngOneOrMore(p), rngEmptyPattern)
+		case "oneOrMore":
+			p = rngOneOrMore(p)
+		case "mixed":
+			p = rngInterleave(p, rngTextPattern)
+		case "list":
+			p = &rngPat{kind: rngList, p1: p}
+		default:
+		}
+		return p, nil
+	case "empty":
+		return rngEmptyPattern, nil
+	case "notAllowed":
+		return rngNotAllowedPattern, nil
+	case "text":
+		return rngTextPattern, nil
+	case "data":
+		return c.data(g, n)
+	case "value":
+		library, typ := n.datatypeLibrary, n.attrs["type"]
+		if _, ok := n.attrs["type"]; !ok {
+			library, typ = "", "token"
+		}
+		dt, err := rngDatatypeFor(library, typ, nil)
+		if err != nil {
+			return nil, n.errorf("%v", err)
+		}
+		if !dt.allows(n.text) {
+			return nil, n.errorf("value %q is not a valid %s", n.text, typ)
+		}
+		return &rngPat{kind: rngValue, dt: dt, value: n.text}, nil
+	case "ref", "parentRef":
+		if n.name == "parentRef" {
+			if g == nil {
+				return nil, n.errorf("parentRef outside a nested grammar")
+			}
+			g = g.parent
+		}
+		return c.ref(g, n, n.attrs["name"])
+	case "externalRef":
+		location := resolveXSDReference(n.attrs["href"], n.location)
+		if c.including[location] {
+			return nil, n.errorf("%s refers to itself", location)
+		}
+		root, err := parseRelaxNG(location, n.ns, "")
+		if err != nil {
+			return nil, err
+		}
+		c.including[location] = true
+		defer delete(c.including, location)
+		return c.pattern(g, root)
+	case "grammar":
+		grammar := &rngGrammar{defines: make(map[string]*rngDefine), parent: g}
+		if err := c.collect(grammar, n, nil); err != nil {
+			return nil, err
+		}
+		if _, ok := grammar.defines[""]; !ok {
+			return nil, n.errorf("grammar has no start pattern")
+		}
+		return c.ref(grammar, n, "")
+	default:
+		return nil, n.errorf("unexpected %s element", n.name)
+	}
+}
+
+// group compiles a sequence of patterns.
+func (c *rngCompiler) group(g *rngGrammar, nodes []*rngNode) (rngPattern, error) {
+	p := rngEmptyPattern
+	for _, n := range nodes {
+		cp, err := c.pattern(g, n)
+		if err != nil {
+			return nil, err
+		}
+		p = rngGroup(p, cp)
+	}
+	return p, nil
+}
+
+// named returns the name class of an element or attribute pattern and the
+// elements of its content.
+func (c *rngCompiler) named(n *rngNode, attribute bool) (*rngNameClass, []*rngNode, error) {
+	if name, ok := n.attrs["name"]; ok {
+		ns := n.ns
+		if _, own := n.attrs["ns"]; attribute && !own {
+			ns = ""
+		}
+		nc, err := rngQName(n, name, ns)
+		return nc, n.children, err
+	}
+	if len(n.children) == 0 {
+		return nil, nil, n.errorf("%s must have a name", n.name)
+	}
+	nc, err := c.nameClass(n.children[0])
+	return nc, n.children[1:], err
+}
+
+// nameClass compiles a name class element.
+func (c *rngCompiler) nameClass(n *rngNode) (*rngNameClass, error) {
+	switch n.name {
+	case "name":
+		return rngQName(n, strings.TrimSpace(n.text), n.ns)
+	case "anyName", "nsName":
+		nc := &rngNameClass{kind: rngAnyName}
+		if n.name == "nsName" {
+			nc = &rngNameClass{kind: rngNsName, ns: n.ns}
+		}
+		for _, child := range n.children {
+			if child.name != "except" {
+				return nil, child.errorf("unexpected %s element in %s", child.name, n.name)
+			}
+			except, err := c.nameClassChoice(child)
+			if err != nil {
+				return nil, err
+			}
+			nc.except = except
+		}
+		return nc, nil
+	case "choice":
+		return c.nameClassChoice(n)
+	default:
+		return nil, n.errorf("unexpected %s element in a name class", n.name)
+	}
+}
+
+func (c *rngCompiler) nameClassChoice(n *rngNode) (*rngNameClass, error) {
+	var nc *rngNameClass
+	for _, child := range n.children {
+		cn, err := c.nameClass(child)
+		if err != nil {
+			return nil, err
+		}
+		if nc == nil {
+			nc = cn
+		} else {
+			nc = &rngNameClass{kind: rngNameChoice, c1: nc, c2: cn}
+		}
+	}
+	if nc == nil {
+		return nil, n.errorf("%s must contain a name class", n.name)
+	}
+	return nc, nil
+}
+
+// rngQName resolves a QName in a schema, giving an unprefixed name the
+// namespace ns.
+func rngQName(n *rngNode, qname, ns string) (*rngNameClass, error) {
+	if prefix, local, ok := strings.Cut(qname, ":"); ok {
+		uri, known := n.namespaces[prefix]
+		if !known {
+			return nil, n.errorf("undeclared namespace prefix %q", prefix)
+		}
+		return &rngNameClass{kind: rngName, ns: uri, local: local}, nil
+	}
+	return &rngNameClass{kind: rngName, ns: ns, local: qname}, nil
+}
+
+// data compiles a data element, its parameters and except clause.
+func (c *rngCompiler) data(g *rngGrammar, n *rngNode) (rngPattern, error) {
+	var params []rngParam
+	var except rngPattern
+	for _, child := range n.children {
+		switch child.name {
+		case "param":
+			params = append(params, rngParam{name: child.attrs["name"], value: child.text})
+		case "except":
+			children := child.patternChildren()
+			if len(children) == 0 {
+				return nil, child.errorf("except must contain a pattern")
+			}
+			except = rngNotAllowedPattern
+			for _, ec := range children {
+				p, err := c.pattern(g, ec)
+				if err != nil {
+					return nil, err
+				}
+				except = rngChoice(except, p)
+			}
+		default:
+			return nil, child.errorf("unexpected %s element in data", child.name)
+		}
+	}
+	dt, err := rngDatatypeFor(n.datatypeLibrary, n.attrs["type"], params)
+	if err != nil {
+		return nil, n.errorf("%v", err)
+	}
+	return &rngPat{kind: rngData, dt: dt, p1: except}, nil
+}
+
+// ref compiles the definition name refers to in g.
+func (c *rngCompiler) ref(g *rngGrammar, n *rngNode, name string) (rngPattern, error) {
+	if g == nil {
+		return nil, n.errorf("%s outside a grammar", n.name)
+	}
+	d, ok := g.defines[name]
+	if !ok {
+		return nil, n.errorf("reference to undefined pattern %q", name)
+	}
+	if d.pattern != nil {
+		return d.pattern, nil
+	}
+	if d.compiling {
+		return nil, n.errorf("pattern %q refers to itself outside an element", name)
+	}
+	d.compiling = true
+	defer func() { d.compiling = false }()
+
+	var p rngPattern
+	for _, node := range d.nodes {
+		np, err := c.group(g, node.patternChildren())
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case p == nil:
+			p = np
+		case d.combine == "interleave":
+			p = rngInterleave(p, np)
+		default:
+			p = rngChoice(p, np)
+		}
+	}
+	d.pattern = p
+	return p, nil
+}
+
+// collect adds the definitions of a grammar, div or include element to g,
+// skipping those an enclosing include overrides.
+func (c *rngCompiler) collect(g *rngGrammar, n *rngNode, overridden map[string]bool) error {
+	for _, child := range n.children {
+		switch child.name {
+		case "start", "define":
+			name := child.attrs["name"]
+			if child.name == "start" {
+				name = ""
+			}
+			if overridden[name] {
+				continue
+			}
+			if err := g.add(name, child); err != nil {
+				return err
+			}
+		case "div":
+			if err := c.collect(g, child, overridden); err != nil {
+				return err
+			}
+		case "include":
+			if err := c.include(g, child, overridden); err != nil {
+				return err
+			}
+		default:
+			return child.errorf("unexpected %s element in a grammar", child.name)
+		}
+	}
+	return nil
+}
+
+// include adds the definitions of an included grammar to g, replaced by
+// those the include element itself contains.
+func (c *rngCompiler) include(g *rngGrammar, n *rngNode, overridden map[string]bool) error {
+	location := resolveXSDReference(n.attrs["href"], n.location)
+	if c.including[location] {
+		return n.errorf("%s includes itself", location)
+	}
+	root, err := parseRelaxNG(location, n.ns, "")
+	if err != nil {
+		return err
+	}
+	if root.name != "grammar" {
+		return n.errorf("included schema %s is not a grammar", location)
+	}
+
+	skip := maps.Clone(overridden)
+	if skip == nil {
+		skip = make(map[string]bool)
+	}
+	var overrides func(*rngNode)
+	overrides = func(parent *rngNode) {
+		for _, child := range parent.children {
+			switch child.name {
+			case "start":
+				skip[""] = true
+			case "define":
+				skip[child.attrs["name"]] = true
+			case "div":
+				overrides(child)
+			default:
+			}
+		}
+	}
+	overrides(n)
+
+	c.including[location] = true
+	defer delete(c.including, location)
+	if err := c.collect(g, root, skip); err != nil {
+		return err
+	}
+	return c.collect(g, n, overridden)
+}
+
+// add records a define or start element, checking its combine attribute.
+func (g *rngGrammar) add(name string, n *rngNode) error {
+	d, ok := g.defines[name]
+	if !ok {
+		d = &rngDefine{}
+		g.defines[name] = d
+	}
+	combine := n.attrs["combine"]
+	switch {
+	case combine != "" && combine != "choice" && combine != "interleave":
+		return n.errorf("invalid combine %q", combine)
+	case combine != "" && d.combine != "" && combine != d.combine:
+		return n.errorf("pattern %q is combined by both choice and interleave", name)
+	case combine == "" && slices.ContainsFunc(d.nodes, func(other *rngNode) bool { return other.attrs["combine"] == "" }):
+		if name == "" {
+			return n.errorf("grammar has more than one start without combine")
+		}
+		return n.errorf("pattern %q is defined more than once without combine", name)
+	default:
+	}
+	if combine != "" {
+		d.combine = combine
+	}
+	d.nodes = append(d.nodes, n)
+	return nil
+}
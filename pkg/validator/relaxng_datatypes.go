@@ -0,0 +1,471 @@
+package validator
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// rngDatatype is a datatype of data and value patterns.
+type rngDatatype interface {
+	allows(s string) bool
+	equal(a, b string) bool
+}
+
+// rngParam is a param of a data pattern, an XSD facet.
+type rngParam struct {
+	name  string
+	value string
+}
+
+// rngDatatypeFor returns the datatype named typ in a datatype library: the
+// built-in library, with string and token, or the XML Schema datatypes.
+func rngDatatypeFor(library, typ string, params []rngParam) (rngDatatype, error) {
+	switch library {
+	case "":
+		if typ != "string" && typ != "token" {
+			return nil, fmt.Errorf("unknown datatype %q", typ)
+		}
+		if len(params) > 0 {
+			return nil, fmt.Errorf("datatype %q does not take parameters", typ)
+		}
+		return rngBuiltinDatatype{token: typ == "token"}, nil
+	case xsdDatatypes:
+		return newXSDDatatype(typ, params)
+	default:
+		return nil, fmt.Errorf("unsupported datatype library %q", library)
+	}
+}
+
+// rngBuiltinDatatype is the string or token datatype of RELAX NG itself.
+type rngBuiltinDatatype struct {
+	token bool
+}
+
+func (rngBuiltinDatatype) allows(string) bool { return true }
+
+func (dt rngBuiltinDatatype) equal(a, b string) bool {
+	if dt.token {
+		return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+	}
+	return a == b
+}
+
+type xsdValueKind int
+
+const (
+	xsdStringKind xsdValueKind = iota
+	xsdListKind
+	xsdBooleanKind
+	xsdDecimalKind
+	xsdFloatKind
+	xsdDurationKind
+	xsdDateTimeKind
+	xsdDateKind
+	xsdTimeKind
+	xsdCalendarKind
+	xsdHexBinaryKind
+	xsdBase64BinaryKind
+)
+
+type xsdWhitespace int
+
+const (
+	xsdPreserve xsdWhitespace = iota
+	xsdReplace
+	xsdCollapse
+)
+
+// xsdBuiltin describes a built-in XML Schema datatype: how its values are
+// compared, how whitespace is normalized, its lexical space and, for the
+// integer types, its range.
+type xsdBuiltin struct {
+	kind     xsdValueKind
+	ws       xsdWhitespace
+	lexical  *regexp.Regexp
+	min, max string
+}
+
+const (
+	xsdNameStartChars = `\p{L}\p{Nl}_:`
+	xsdNameChars      = xsdNameStartChars + `\p{N}\p{Mn}\p{Mc}.\-\x{B7}`
+	xsdTimezone       = `(?:Z|[+-](?:(?:0\d|1[0-3]):[0-5]\d|14:00))?`
+	xsdYear           = `-?(?:[1-9]\d{4,}|\d{4})`
+	xsdMonth          = `(?:0[1-9]|1[0-2])`
+	xsdDay            = `(?:0[1-9]|[12]\d|3[01])`
+	xsdClock          = `(?:(?:[01]\d|2[0-3]):[0-5]\d:[0-5]\d(?:\.\d+)?|24:00:00(?:\.0+)?)`
+)
+
+func xsdLexical(re string) *regexp.Regexp {
+	return regexp.MustCompile(`^(?:` + re + `)$`)
+}
+
+var (
+	xsdNameRe    = xsdLexical(`[` + xsdNameStartChars + `][` + xsdNameChars + `]*`)
+	xsdNCNameRe  = xsdLexical(`[` + strings.Replace(xsdNameStartChars, ":", "", 1) + `][` + strings.Replace(xsdNameChars, ":", "", 1) + `]*`)
+	xsdNMTokenRe = xsdLexical(`[` + xsdNameChars + `]+`)
+	xsdIntegerRe = xsdLexical(`[+-]?\d+`)
+	xsdQNameRe   = xsdLexical(`(?:[^:\s]+:)?[^:\s]+`)
+)
+
+var xsdBuiltins = map[string]xsdBuiltin{
+	"string":             {kind: xsdStringKind, ws: xsdPreserve},
+	"normalizedString":   {kind: xsdStringKind, ws: xsdReplace},
+	"token":              {kind: xsdStringKind, ws: xsdCollapse},
+	"language":           {kind: xsdStringKind, ws: xsdCollapse, lexical: xsdLexical(`[a-zA-Z]{1,8}(?:-[a-zA-Z0-9]{1,8})*`)},
+	"Name":               {kind: xsdStringKind, ws: xsdCollapse, lexical: xsdNameRe},
+	"NCName":             {kind: xsdStringKind, ws: xsdCollapse, lexical: xsdNCNameRe},
+	"ID":                 {kind: xsdStringKind, ws: xsdCollapse, lexical: xsdNCNameRe},
+	"IDREF":              {kind: xsdStringKind, ws: xsdCollapse, lexical: xsdNCNameRe},
+	"ENTITY":             {kind: xsdStringKind, ws: xsdCollapse, lexical: xsdNCNameRe},
+	"NMTOKEN":            {kind: xsdStringKind, ws: xsdCollapse, lexical: xsdNMTokenRe},
+	"NMTOKENS":           {kind: xsdListKind, ws: xsdCollapse, lexical: xsdNMTokenRe},
+	"IDREFS":             {kind: xsdListKind, ws: xsdCollapse, lexical: xsdNCNameRe},
+	"ENTITIES":           {kind: xsdListKind, ws: xsdCollapse, lexical: xsdNCNameRe},
+	"QName":              {kind: xsdStringKind, ws: xsdCollapse, lexical: xsdQNameRe},
+	"NOTATION":           {kind: xsdStringKind, ws: xsdCollapse, lexical: xsdQNameRe},
+	"anyURI":             {kind: xsdStringKind, ws: xsdCollapse},
+	"boolean":            {kind: xsdBooleanKind, ws: xsdCollapse, lexical: xsdLexical(`true|false|1|0`)},
+	"decimal":            {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdLexical(`[+-]?(?:\d+(?:\.\d*)?|\.\d+)`)},
+	"integer":            {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe},
+	"nonPositiveInteger": {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, max: "0"},
+	"negativeInteger":    {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, max: "-1"},
+	"nonNegativeInteger": {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, min: "0"},
+	"positiveInteger":    {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, min: "1"},
+	"long":               {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, min: "-9223372036854775808", max: "9223372036854775807"},
+	"int":                {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, min: "-2147483648", max: "2147483647"},
+	"short":              {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, min: "-32768", max: "32767"},
+	"byte":               {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, min: "-128", max: "127"},
+	"unsignedLong":       {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, min: "0", max: "18446744073709551615"},
+	"unsignedInt":        {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, min: "0", max: "4294967295"},
+	"unsignedShort":      {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, min: "0", max: "65535"},
+	"unsignedByte":       {kind: xsdDecimalKind, ws: xsdCollapse, lexical: xsdIntegerRe, min: "0", max: "255"},
+	"float":              {kind: xsdFloatKind, ws: xsdCollapse, lexical: xsdLexical(`[+-]?(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?|[+-]?INF|NaN`)},
+	"double":             {kind: xsdFloatKind, ws: xsdCollapse, lexical: xsdLexical(`[+-]?(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?|[+-]?INF|NaN`)},
+	"duration":           {kind: xsdDurationKind, ws: xsdCollapse, lexical: xsdLexical(`-?P(?:\d+Y)?(?:\d+M)?(?:\d+D)?(?:T(?:\d+H)?(?:\d+M)?(?:\d+(?:\.\d+)?S)?)?`)},
+	"dateTime":           {kind: xsdDateTimeKind, ws: xsdCollapse, lexical: xsdLexical(xsdYear + `-` + xsdMonth + `-` + xsdDay + `T` + xsdClock + xsdTimezone)},
+	"date":               {kind: xsdDateKind, ws: xsdCollapse, lexical: xsdLexical(xsdYear + `-` + xsdMonth + `-` + xsdDay + xsdTimezone)},
+	"time":               {kind: xsdTimeKind, ws: xsdCollapse, lexical: xsdLexical(xsdClock + xsdTimezone)},
+	"gYear":              {kind: xsdCalendarKind, ws: xsdCollapse, lexical: xsdLexical(xsdYear + xsdTimezone)},
+	"gYearMonth":         {kind: xsdCalendarKind, ws: xsdCollapse, lexical: xsdLexical(xsdYear + `-` + xsdMonth + xsdTimezone)},
+	"gMonth":             {kind: xsdCalendarKind, ws: xsdCollapse, lexical: xsdLexical(`--` + xsdMonth + xsdTimezone)},
+	"gDay":               {kind: xsdCalendarKind, ws: xsdCollapse, lexical: xsdLexical(`---` + xsdDay + xsdTimezone)},
+	"gMonthDay":          {kind: xsdCalendarKind, ws: xsdCollapse, lexical: xsdLexical(`--` + xsdMonth + `-` + xsdDay + xsdTimezone)},
+	"hexBinary":          {kind: xsdHexBinaryKind, ws: xsdCollapse, lexical: xsdLexical(`(?:[0-9a-fA-F]{2})*`)},
+	"base64Binary":       {kind: xsdBase64BinaryKind, ws: xsdCollapse},
+}
+
+// xsdDatatype is an XML Schema datatype restricted by the params of a data
+// pattern.
+type xsdDatatype struct {
+	name string
+	xsdBuiltin
+	length, minLength, maxLength int
+	totalDigits, fractionDigits  int
+	patterns                     []*regexp.Regexp
+	bounds                       []xsdBound
+}
+
+// xsdBound is a minInclusive, minExclusive, maxInclusive or maxExclusive
+// facet: a value compares to it with one of the results in allowed.
+type xsdBound struct {
+	value   string
+	allowed []int
+}
+
+func newXSDDatatype(name string, params []rngParam) (*xsdDatatype, error) {
+	builtin, ok := xsdBuiltins[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown XML Schema datatype %q", name)
+	}
+	dt := &xsdDatatype{name: name, xsdBuiltin: builtin, length: -1, minLength: -1, maxLength: -1, totalDigits: -1, fractionDigits: -1}
+	for _, param := range params {
+		if err := dt.addParam(param); err != nil {
+			return nil, err
+		}
+	}
+	return dt, nil
+}
+
+func (dt *xsdDatatype) addParam(param rngParam) error {
+	switch param.name {
+	case "length", "minLength", "maxLength", "totalDigits", "fractionDigits":
+		n, err := strconv.Atoi(strings.TrimSpace(param.value))
+		if err != nil || n < 0 {
+			return fmt.Errorf("parameter %q of %s must be a non-negative integer, got %q", param.name, dt.name, param.value)
+		}
+		if (param.name == "totalDigits" || param.name == "fractionDigits") && dt.kind != xsdDecimalKind {
+			return fmt.Errorf("parameter %q does not apply to %s", param.name, dt.name)
+		}
+		switch param.name {
+		case "length":
+			dt.length = n
+		case "minLength":
+			dt.minLength = n
+		case "maxLength":
+			dt.maxLength = n
+		case "totalDigits":
+			dt.totalDigits = n
+		default:
+			dt.fractionDigits = n
+		}
+	case "pattern":
+		re, err := xsdPattern(param.value)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", param.value, err)
+		}
+		dt.patterns = append(dt.patterns, re)
+	case "minInclusive", "minExclusive", "maxInclusive", "maxExclusive":
+		value := dt.normalize(param.value)
+		if !dt.lexicallyValid(value) {
+			return fmt.Errorf("parameter %q of %s has invalid value %q", param.name, dt.name, param.value)
+		}
+		if _, ok := dt.compare(value, value); !ok {
+			return fmt.Errorf("parameter %q does not apply to %s", param.name, dt.name)
+		}
+		allowed := map[string][]int{
+			"minInclusive": {0, 1},
+			"minExclusive": {1},
+			"maxInclusive": {-1, 0},
+			"maxExclusive": {-1},
+		}[param.name]
+		dt.bounds = append(dt.bounds, xsdBound{value: value, allowed: allowed})
+	default:
+		return fmt.Errorf("unsupported parameter %q for %s", param.name, dt.name)
+	}
+	return nil
+}
+
+func (dt *xsdDatatype) normalize(s string) string {
+	switch dt.ws {
+	case xsdReplace:
+		return strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(s)
+	case xsdCollapse:
+		return strings.Join(strings.Fields(s), " ")
+	default:
+		return s
+	}
+}
+
+func (dt *xsdDatatype) lexicallyValid(s string) bool {
+	switch dt.kind {
+	case xsdListKind:
+		items := strings.Fields(s)
+		for _, item := range items {
+			if !dt.lexical.MatchString(item) {
+				return false
+			}
+		}
+		return len(items) > 0
+	case xsdDurationKind:
+		return dt.lexical.MatchString(s) && !strings.HasSuffix(s, "P") && !strings.HasSuffix(s, "T")
+	case xsdBase64BinaryKind:
+		_, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(s, " ", ""))
+		return err == nil
+	default:
+		return dt.lexical == nil || dt.lexical.MatchString(s)
+	}
+}
+
+func (dt *xsdDatatype) allows(s string) bool {
+	s = dt.normalize(s)
+	if !dt.lexicallyValid(s) {
+		return false
+	}
+	for _, re := range dt.patterns {
+		if !re.MatchString(s) {
+			return false
+		}
+	}
+	if dt.min != "" || dt.max != "" {
+		if c, _ := dt.compare(s, dt.min); dt.min != "" && c < 0 {
+			return false
+		}
+		if c, _ := dt.compare(s, dt.max); dt.max != "" && c > 0 {
+			return false
+		}
+	}
+	for _, bound := range dt.bounds {
+		c, ok := dt.compare(s, bound.value)
+		if !ok || !slices.Contains(bound.allowed, c) {
+			return false
+		}
+	}
+	if dt.length >= 0 || dt.minLength >= 0 || dt.maxLength >= 0 {
+		n := dt.valueLength(s)
+		if (dt.length >= 0 && n != dt.length) || (dt.minLength >= 0 && n < dt.minLength) || (dt.maxLength >= 0 && n > dt.maxLength) {
+			return false
+		}
+	}
+	if dt.totalDigits >= 0 || dt.fractionDigits >= 0 {
+		total, fraction := decimalDigits(s)
+		if (dt.totalDigits >= 0 && total > dt.totalDigits) || (dt.fractionDigits >= 0 && fraction > dt.fractionDigits) {
+			return false
+		}
+	}
+	return true
+}
+
+func (dt *xsdDatatype) equal(a, b string) bool {
+	a, b = dt.normalize(a), dt.normalize(b)
+	switch dt.kind {
+	case xsdBooleanKind:
+		return (a == "true" || a == "1") == (b == "true" || b == "1")
+	case xsdDecimalKind, xsdFloatKind, xsdDateTimeKind, xsdDateKind, xsdTimeKind:
+		c, ok := dt.compare(a, b)
+		return ok && c == 0
+	default:
+		return a == b
+	}
+}
+
+// compare orders two normalized values of an ordered type. It reports
+// false when the type is not ordered or a value cannot be compared.
+func (dt *xsdDatatype) compare(a, b string) (int, bool) {
+	switch dt.kind {
+	case xsdDecimalKind:
+		x, okA := new(big.Rat).SetString(a)
+		y, okB := new(big.Rat).SetString(b)
+		if !okA || !okB {
+			return 0, false
+		}
+		return x.Cmp(y), true
+	case xsdFloatKind:
+		x, errA := parseXSDFloat(a)
+		y, errB := parseXSDFloat(b)
+		if errA != nil || errB != nil || math.IsNaN(x) || math.IsNaN(y) {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	case xsdDateTimeKind, xsdDateKind, xsdTimeKind:
+		x, okA := parseXSDTime(dt.kind, a)
+		y, okB := parseXSDTime(dt.kind, b)
+		if !okA || !okB {
+			return 0, false
+		}
+		return x.Compare(y), true
+	default:
+		return 0, false
+	}
+}
+
+// valueLength is the length the length facets constrain: characters,
+// octets for binary types or items for list types.
+func (dt *xsdDatatype) valueLength(s string) int {
+	switch dt.kind {
+	case xsdListKind:
+		return len(strings.Fields(s))
+	case xsdHexBinaryKind:
+		return len(s) / 2
+	case xsdBase64BinaryKind:
+		b, _ := base64.StdEncoding.DecodeString(strings.ReplaceAll(s, " ", ""))
+		return len(b)
+	default:
+		return utf8.RuneCountInString(s)
+	}
+}
+
+func parseXSDFloat(s string) (float64, error) {
+	switch s {
+	case "INF", "+INF":
+		return math.Inf(1), nil
+	case "-INF":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	default:
+		return strconv.ParseFloat(s, 64)
+	}
+}
+
+// parseXSDTime parses a dateTime, date or time value. A value without a
+// timezone is taken to be in UTC.
+func parseXSDTime(kind xsdValueKind, s string) (time.Time, bool) {
+	layout := "2006-01-02T15:04:05.999999999Z07:00"
+	switch kind {
+	case xsdDateKind:
+		layout = "2006-01-02Z07:00"
+	case xsdTimeKind:
+		layout = "15:04:05.999999999Z07:00"
+	default:
+	}
+	if !strings.HasSuffix(s, "Z") && !xsdHasOffset(s) {
+		s += "Z"
+	}
+	t, err := time.Parse(layout, s)
+	return t, err == nil
+}
+
+func xsdHasOffset(s string) bool {
+	return len(s) > 6 && (s[len(s)-6] == '+' || s[len(s)-6] == '-') && s[len(s)-3] == ':'
+}
+
+// decimalDigits counts the significant digits of a decimal, and those
+// after its decimal point.
+func decimalDigits(s string) (total, fraction int) {
+	s = strings.TrimLeft(s, "+-")
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	intPart = strings.TrimLeft(intPart, "0")
+	fracPart = strings.TrimRight(fracPart, "0")
+	return len(intPart) + len(fracPart), len(fracPart)
+}
+
+// xsdPattern translates an XML Schema regular expression into an anchored
+// Go regular expression. XML Schema expressions always match the whole
+// value, have no anchors and add the \i and \c name character escapes.
+func xsdPattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	runes := []rune(pattern)
+	depth := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			class := map[rune]string{'i': xsdNameStartChars, 'I': xsdNameStartChars, 'c': xsdNameChars, 'C': xsdNameChars}[runes[i]]
+			switch {
+			case class == "":
+				b.WriteRune('\\')
+				b.WriteRune(runes[i])
+			case runes[i] == 'I' || runes[i] == 'C':
+				if depth > 0 {
+					return nil, fmt.Errorf(`\%c is not supported in a character class`, runes[i])
+				}
+				b.WriteString("[^" + class + "]")
+			case depth > 0:
+				b.WriteString(class)
+			default:
+				b.WriteString("[" + class + "]")
+			}
+		case r == '[':
+			if depth > 0 {
+				return nil, fmt.Errorf("character class subtraction is not supported")
+			}
+			depth++
+			b.WriteRune(r)
+		case r == ']' && depth > 0:
+			depth--
+			b.WriteRune(r)
+		case (r == '^' || r == '$') && depth == 0:
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return regexp.Compile(`^(?:` + b.String() + `)$`)
+}
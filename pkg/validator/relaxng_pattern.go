@@ -0,0 +1,434 @@
+package validator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RELAX NG patterns are validated by derivatives, following James Clark's
+// "An algorithm for RELAX NG validation": each event of the document turns
+// the pattern into the pattern the rest of the document must match.
+
+type rngKind int
+
+const (
+	rngEmpty rngKind = iota
+	rngNotAllowed
+	rngText
+	rngChoiceKind
+	rngInterleaveKind
+	rngGroupKind
+	rngOneOrMoreKind
+	rngList
+	rngData
+	rngValue
+	rngAttribute
+	rngElement
+	rngAfterKind
+)
+
+// rngPat is a pattern. p1 and p2 are the operands of binary patterns, the
+// content of unary ones, elements and attributes, and the except clause of
+// data.
+type rngPat struct {
+	kind  rngKind
+	p1    *rngPat
+	p2    *rngPat
+	nc    *rngNameClass
+	dt    rngDatatype
+	value string
+}
+
+type rngPattern = *rngPat
+
+var (
+	rngEmptyPattern      = &rngPat{kind: rngEmpty}
+	rngNotAllowedPattern = &rngPat{kind: rngNotAllowed}
+	rngTextPattern       = &rngPat{kind: rngText}
+)
+
+type rngNameKind int
+
+const (
+	rngName rngNameKind = iota
+	rngAnyName
+	rngNsName
+	rngNameChoice
+)
+
+// rngNameClass is a set of element or attribute names.
+type rngNameClass struct {
+	kind   rngNameKind
+	ns     string
+	local  string
+	except *rngNameClass
+	c1     *rngNameClass
+	c2     *rngNameClass
+}
+
+func (nc *rngNameClass) contains(name xml.Name) bool {
+	if nc == nil {
+		return false
+	}
+	switch nc.kind {
+	case rngName:
+		return nc.ns == name.Space && nc.local == name.Local
+	case rngAnyName:
+		return !nc.except.contains(name)
+	case rngNsName:
+		return nc.ns == name.Space && !nc.except.contains(name)
+	default:
+		return nc.c1.contains(name) || nc.c2.contains(name)
+	}
+}
+
+// names describes the names in the class for error messages.
+func (nc *rngNameClass) names() []string {
+	switch nc.kind {
+	case rngName:
+		return []string{fmt.Sprintf("%q", nc.local)}
+	case rngAnyName:
+		return []string{"any element"}
+	case rngNsName:
+		return []string{fmt.Sprintf("any element in %q", nc.ns)}
+	default:
+		return append(nc.c1.names(), nc.c2.names()...)
+	}
+}
+
+func rngChoice(p1, p2 rngPattern) rngPattern {
+	switch {
+	case p1.kind == rngNotAllowed:
+		return p2
+	case p2.kind == rngNotAllowed:
+		return p1
+	case rngEqual(p1, p2):
+		return p1
+	default:
+		return &rngPat{kind: rngChoiceKind, p1: p1, p2: p2}
+	}
+}
+
+func rngGroup(p1, p2 rngPattern) rngPattern {
+	switch {
+	case p1.kind == rngNotAllowed || p2.kind == rngNotAllowed:
+		return rngNotAllowedPattern
+	case p1.kind == rngEmpty:
+		return p2
+	case p2.kind == rngEmpty:
+		return p1
+	default:
+		return &rngPat{kind: rngGroupKind, p1: p1, p2: p2}
+	}
+}
+
+func rngInterleave(p1, p2 rngPattern) rngPattern {
+	switch {
+	case p1.kind == rngNotAllowed || p2.kind == rngNotAllowed:
+		return rngNotAllowedPattern
+	case p1.kind == rngEmpty:
+		return p2
+	case p2.kind == rngEmpty:
+		return p1
+	default:
+		return &rngPat{kind: rngInterleaveKind, p1: p1, p2: p2}
+	}
+}
+
+func rngOneOrMore(p rngPattern) rngPattern {
+	if p.kind == rngNotAllowed || p.kind == rngEmpty {
+		return p
+	}
+	return &rngPat{kind: rngOneOrMoreKind, p1: p}
+}
+
+func rngAfter(p1, p2 rngPattern) rngPattern {
+	if p1.kind == rngNotAllowed || p2.kind == rngNotAllowed {
+		return rngNotAllowedPattern
+	}
+	return &rngPat{kind: rngAfterKind, p1: p1, p2: p2}
+}
+
+// rngEqual reports whether two patterns are the same. Elements are only
+// equal to themselves, which keeps the comparison finite.
+func rngEqual(p1, p2 rngPattern) bool {
+	if p1 == p2 {
+		return true
+	}
+	if p1 == nil || p2 == nil || p1.kind != p2.kind {
+		return false
+	}
+	switch p1.kind {
+	case rngEmpty, rngNotAllowed, rngText:
+		return true
+	case rngElement:
+		return false
+	case rngData, rngValue:
+		return p1.dt == p2.dt && p1.value == p2.value && rngEqual(p1.p1, p2.p1)
+	case rngAttribute:
+		return p1.nc == p2.nc && rngEqual(p1.p1, p2.p1)
+	default:
+		return rngEqual(p1.p1, p2.p1) && rngEqual(p1.p2, p2.p2)
+	}
+}
+
+func rngNullable(p rngPattern) bool {
+	switch p.kind {
+	case rngEmpty, rngText:
+		return true
+	case rngChoiceKind:
+		return rngNullable(p.p1) || rngNullable(p.p2)
+	case rngGroupKind, rngInterleaveKind:
+		return rngNullable(p.p1) && rngNullable(p.p2)
+	case rngOneOrMoreKind:
+		return rngNullable(p.p1)
+	default:
+		return false
+	}
+}
+
+// rngApplyAfter applies f to the second operand of each after pattern.
+func rngApplyAfter(p rngPattern, f func(rngPattern) rngPattern) rngPattern {
+	switch p.kind {
+	case rngAfterKind:
+		return rngAfter(p.p1, f(p.p2))
+	case rngChoiceKind:
+		return rngChoice(rngApplyAfter(p.p1, f), rngApplyAfter(p.p2, f))
+	default:
+		return rngNotAllowedPattern
+	}
+}
+
+func rngStartTagOpenDeriv(p rngPattern, name xml.Name) rngPattern {
+	switch p.kind {
+	case rngChoiceKind:
+		return rngChoice(rngStartTagOpenDeriv(p.p1, name), rngStartTagOpenDeriv(p.p2, name))
+	case rngElement:
+		if p.nc.contains(name) {
+			return rngAfter(p.p1, rngEmptyPattern)
+		}
+		return rngNotAllowedPattern
+	case rngInterleaveKind:
+		return rngChoice(
+			rngApplyAfter(rngStartTagOpenDeriv(p.p1, name), func(x rngPattern) rngPattern { return rngInterleave(x, p.p2) }),
+			rngApplyAfter(rngStartTagOpenDeriv(p.p2, name), func(x rngPattern) rngPattern { return rngInterleave(p.p1, x) }),
+		)
+	case rngOneOrMoreKind:
+		return rngApplyAfter(rngStartTagOpenDeriv(p.p1, name), func(x rngPattern) rngPattern {
+			return rngGroup(x, rngChoice(p, rngEmptyPattern))
+		})
+	case rngGroupKind:
+		x := rngApplyAfter(rngStartTagOpenDeriv(p.p1, name), func(x rngPattern) rngPattern { return rngGroup(x, p.p2) })
+		if rngNullable(p.p1) {
+			return rngChoice(x, rngStartTagOpenDeriv(p.p2, name))
+		}
+		return x
+	case rngAfterKind:
+		return rngApplyAfter(rngStartTagOpenDeriv(p.p1, name), func(x rngPattern) rngPattern { return rngAfter(x, p.p2) })
+	default:
+		return rngNotAllowedPattern
+	}
+}
+
+func rngAttDeriv(p rngPattern, name xml.Name, value string) rngPattern {
+	switch p.kind {
+	case rngAfterKind:
+		return rngAfter(rngAttDeriv(p.p1, name, value), p.p2)
+	case rngChoiceKind:
+		return rngChoice(rngAttDeriv(p.p1, name, value), rngAttDeriv(p.p2, name, value))
+	case rngGroupKind:
+		return rngChoice(rngGroup(rngAttDeriv(p.p1, name, value), p.p2), rngGroup(p.p1, rngAttDeriv(p.p2, name, value)))
+	case rngInterleaveKind:
+		return rngChoice(rngInterleave(rngAttDeriv(p.p1, name, value), p.p2), rngInterleave(p.p1, rngAttDeriv(p.p2, name, value)))
+	case rngOneOrMoreKind:
+		return rngGroup(rngAttDeriv(p.p1, name, value), rngChoice(p, rngEmptyPattern))
+	case rngAttribute:
+		if p.nc.contains(name) && rngValueMatch(p.p1, value) {
+			return rngEmptyPattern
+		}
+		return rngNotAllowedPattern
+	default:
+		return rngNotAllowedPattern
+	}
+}
+
+func rngValueMatch(p rngPattern, value string) bool {
+	return (rngNullable(p) && isXMLWhitespace(value)) || rngNullable(rngTextDeriv(p, value))
+}
+
+// rngStartTagCloseDeriv ends the attributes of an element. When recovering
+// from an error, attributes that are still expected are dropped instead.
+func rngStartTagCloseDeriv(p rngPattern, recovering bool) rngPattern {
+	switch p.kind {
+	case rngAfterKind:
+		return rngAfter(rngStartTagCloseDeriv(p.p1, recovering), p.p2)
+	case rngChoiceKind:
+		return rngChoice(rngStartTagCloseDeriv(p.p1, recovering), rngStartTagCloseDeriv(p.p2, recovering))
+	case rngGroupKind:
+		return rngGroup(rngStartTagCloseDeriv(p.p1, recovering), rngStartTagCloseDeriv(p.p2, recovering))
+	case rngInterleaveKind:
+		return rngInterleave(rngStartTagCloseDeriv(p.p1, recovering), rngStartTagCloseDeriv(p.p2, recovering))
+	case rngOneOrMoreKind:
+		return rngOneOrMore(rngStartTagCloseDeriv(p.p1, recovering))
+	case rngAttribute:
+		if recovering {
+			return rngEmptyPattern
+		}
+		return rngNotAllowedPattern
+	default:
+		return p
+	}
+}
+
+func rngTextDeriv(p rngPattern, s string) rngPattern {
+	switch p.kind {
+	case rngChoiceKind:
+		return rngChoice(rngTextDeriv(p.p1, s), rngTextDeriv(p.p2, s))
+	case rngInterleaveKind:
+		return rngChoice(rngInterleave(rngTextDeriv(p.p1, s), p.p2), rngInterleave(p.p1, rngTextDeriv(p.p2, s)))
+	case rngGroupKind:
+		x := rngGroup(rngTextDeriv(p.p1, s), p.p2)
+		if rngNullable(p.p1) {
+			return rngChoice(x, rngTextDeriv(p.p2, s))
+		}
+		return x
+	case rngAfterKind:
+		return rngAfter(rngTextDeriv(p.p1, s), p.p2)
+	case rngOneOrMoreKind:
+		return rngGroup(rngTextDeriv(p.p1, s), rngChoice(p, rngEmptyPattern))
+	case rngText:
+		return p
+	case rngValue:
+		if p.dt.allows(s) && p.dt.equal(s, p.value) {
+			return rngEmptyPattern
+		}
+		return rngNotAllowedPattern
+	case rngData:
+		if p.dt.allows(s) && (p.p1 == nil || !rngNullable(rngTextDeriv(p.p1, s))) {
+			return rngEmptyPattern
+		}
+		return rngNotAllowedPattern
+	case rngList:
+		items := p.p1
+		for _, item := range strings.Fields(s) {
+			items = rngTextDeriv(items, item)
+		}
+		if rngNullable(items) {
+			return rngEmptyPattern
+		}
+		return rngNotAllowedPattern
+	default:
+		return rngNotAllowedPattern
+	}
+}
+
+func rngEndTagDeriv(p rngPattern) rngPattern {
+	switch p.kind {
+	case rngChoiceKind:
+		return rngChoice(rngEndTagDeriv(p.p1), rngEndTagDeriv(p.p2))
+	case rngAfterKind:
+		if rngNullable(p.p1) {
+			return p.p2
+		}
+		return rngNotAllowedPattern
+	default:
+		return rngNotAllowedPattern
+	}
+}
+
+// rngAfterRest is what follows an element whose content is in error: the
+// second operand of each after pattern.
+func rngAfterRest(p rngPattern) rngPattern {
+	switch p.kind {
+	case rngChoiceKind:
+		return rngChoice(rngAfterRest(p.p1), rngAfterRest(p.p2))
+	case rngAfterKind:
+		return p.p2
+	default:
+		return rngNotAllowedPattern
+	}
+}
+
+// rngExpectedElements lists the names of the elements p allows next.
+func rngExpectedElements(p rngPattern) []string {
+	var names []string
+	var walk func(rngPattern)
+	walk = func(p rngPattern) {
+		switch p.kind {
+		case rngChoiceKind, rngInterleaveKind:
+			walk(p.p1)
+			walk(p.p2)
+		case rngGroupKind:
+			walk(p.p1)
+			if rngNullable(p.p1) {
+				walk(p.p2)
+			}
+		case rngOneOrMoreKind, rngAfterKind:
+			walk(p.p1)
+		case rngElement:
+			for _, name := range p.nc.names() {
+				if !slices.Contains(names, name) {
+					names = append(names, name)
+				}
+			}
+		default:
+		}
+	}
+	walk(p)
+	return names
+}
+
+// rngAttributeNamed reports whether p has an attribute pattern for name.
+func rngAttributeNamed(p rngPattern, name xml.Name) bool {
+	switch p.kind {
+	case rngChoiceKind, rngInterleaveKind, rngGroupKind:
+		return rngAttributeNamed(p.p1, name) || rngAttributeNamed(p.p2, name)
+	case rngOneOrMoreKind, rngAfterKind:
+		return rngAttributeNamed(p.p1, name)
+	case rngAttribute:
+		return p.nc.contains(name)
+	default:
+		return false
+	}
+}
+
+// rngRequiredAttributes lists attributes p cannot do without, for error
+// messages; a choice only contributes when both branches need one.
+func rngRequiredAttributes(p rngPattern) []string {
+	switch p.kind {
+	case rngGroupKind, rngInterleaveKind:
+		return append(rngRequiredAttributes(p.p1), rngRequiredAttributes(p.p2)...)
+	case rngChoiceKind:
+		a, b := rngRequiredAttributes(p.p1), rngRequiredAttributes(p.p2)
+		if len(a) == 0 || len(b) == 0 {
+			return nil
+		}
+		return a
+	case rngOneOrMoreKind, rngAfterKind:
+		return rngRequiredAttributes(p.p1)
+	case rngAttribute:
+		return p.nc.names()
+	default:
+		return nil
+	}
+}
+
+// rngHasData reports whether p expects a typed value as text.
+func rngHasData(p rngPattern) bool {
+	switch p.kind {
+	case rngChoiceKind, rngInterleaveKind, rngGroupKind:
+		return rngHasData(p.p1) || rngHasData(p.p2)
+	case rngOneOrMoreKind, rngAfterKind:
+		return rngHasData(p.p1)
+	case rngData, rngValue, rngList:
+		return true
+	default:
+		return false
+	}
+}
+
+func isXMLWhitespace(s string) bool {
+	return strings.Trim(s, " \t\r\n") == ""
+}
@@ -0,0 +1,210 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// rngOpenElement is an element of the document being validated.
+type rngOpenElement struct {
+	name      string
+	text      strings.Builder
+	textLine  int
+	textCol   int
+	hasChild  bool
+	hasErrors bool
+}
+
+// rngValidation holds the state of validating one document.
+type rngValidation struct {
+	pattern   rngPattern
+	stack     []*rngOpenElement
+	msgs      []string
+	positions []SchemaErrorPosition
+}
+
+func (v *rngValidation) errorf(line, col int, format string, args ...any) {
+	v.msgs = append(v.msgs, fmt.Sprintf(format, args...))
+	v.positions = append(v.positions, SchemaErrorPosition{Line: line, Column: col})
+}
+
+// validate validates XML bytes against the schema, reporting every error
+// with its position. After an error the validator carries on as if the
+// offending element, attribute or text were absent.
+func (s *relaxNGSchema) validate(b []byte) (bool, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(b))
+	v := &rngValidation{pattern: s.start}
+	skip := 0
+	for {
+		line, col := decoder.InputPos()
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return false, fmt.Errorf("xml parse error: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 {
+				skip++
+				continue
+			}
+			if !v.startElement(t, line, col) {
+				skip = 1
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			v.endElement(line, col)
+		case xml.CharData:
+			if skip == 0 && len(v.stack) > 0 {
+				el := v.stack[len(v.stack)-1]
+				if el.text.Len() == 0 {
+					el.textLine, el.textCol = line, col
+				}
+				el.text.Write(t)
+			}
+		default:
+		}
+	}
+	if len(v.msgs) == 0 && !rngNullable(v.pattern) {
+		line, col := decoder.InputPos()
+		v.errorf(line, col, "document is incomplete")
+	}
+	if len(v.msgs) > 0 {
+		rules := make([]string, len(v.msgs))
+		for i := range rules {
+			rules[i] = RuleXMLRelaxNG
+		}
+		return false, &SchemaErrors{Prefix: "schema validation failed: ", Items: v.msgs, Positions: v.positions, Rules: rules}
+	}
+	return true, nil
+}
+
+// startElement matches a start tag and its attributes. It returns false
+// when the element is not allowed, so that its subtree is skipped.
+func (v *rngValidation) startElement(t xml.StartElement, line, col int) bool {
+	if len(v.stack) > 0 {
+		parent := v.stack[len(v.stack)-1]
+		v.flushText(parent, false, line, col)
+		parent.hasChild = true
+	}
+
+	p := rngStartTagOpenDeriv(v.pattern, t.Name)
+	if p.kind == rngNotAllowed {
+		expected := rngExpectedElements(v.pattern)
+		switch len(expected) {
+		case 0:
+			v.errorf(line, col, "element %q is not allowed here", t.Name.Local)
+		case 1:
+			v.errorf(line, col, "element %q is not allowed here; expected %s", t.Name.Local, expected[0])
+		default:
+			v.errorf(line, col, "element %q is not allowed here; expected one of %s", t.Name.Local, strings.Join(expected, ", "))
+		}
+		if len(v.stack) > 0 {
+			v.stack[len(v.stack)-1].hasErrors = true
+		}
+		return false
+	}
+
+	el := &rngOpenElement{name: t.Name.Local}
+	var invalid []string
+	for _, attr := range t.Attr {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") {
+			continue
+		}
+		next := rngAttDeriv(p, attr.Name, attr.Value)
+		if next.kind != rngNotAllowed {
+			p = next
+			continue
+		}
+		el.hasErrors = true
+		if rngAttributeNamed(p, attr.Name) {
+			invalid = append(invalid, fmt.Sprintf("%q", attr.Name.Local))
+			v.errorf(line, col, "attribute %q of element %q has invalid value %q", attr.Name.Local, t.Name.Local, attr.Value)
+		} else {
+			v.errorf(line, col, "attribute %q is not allowed on element %q", attr.Name.Local, t.Name.Local)
+		}
+	}
+
+	closed := rngStartTagCloseDeriv(p, false)
+	if closed.kind == rngNotAllowed {
+		// An attribute already reported as invalid is not also missing.
+		missing := slices.DeleteFunc(rngRequiredAttributes(p), func(name string) bool { return slices.Contains(invalid, name) })
+		switch {
+		case len(missing) > 0:
+			v.errorf(line, col, "element %q is missing required attribute %s", t.Name.Local, missing[0])
+		case !el.hasErrors:
+			v.errorf(line, col, "element %q has invalid attributes", t.Name.Local)
+		default:
+		}
+		el.hasErrors = true
+		closed = rngStartTagCloseDeriv(p, true)
+	}
+	v.pattern = closed
+	v.stack = append(v.stack, el)
+	return true
+}
+
+// endElement matches the content left to the end tag of an element.
+func (v *rngValidation) endElement(line, col int) {
+	el := v.stack[len(v.stack)-1]
+	v.stack = v.stack[:len(v.stack)-1]
+	v.flushText(el, !el.hasChild, line, col)
+
+	p := rngEndTagDeriv(v.pattern)
+	if p.kind == rngNotAllowed {
+		if !el.hasErrors {
+			switch expected := rngExpectedElements(v.pattern); {
+			case !el.hasChild && len(expected) == 0 && rngHasData(v.pattern):
+				v.errorf(line, col, "element %q has invalid value %q", el.name, "")
+			case len(expected) == 1:
+				v.errorf(line, col, "element %q is incomplete; expected %s", el.name, expected[0])
+			case len(expected) > 1:
+				v.errorf(line, col, "element %q is incomplete; expected one of %s", el.name, strings.Join(expected, ", "))
+			default:
+				v.errorf(line, col, "element %q is incomplete", el.name)
+			}
+		}
+		p = rngAfterRest(v.pattern)
+	}
+	v.pattern = p
+}
+
+// flushText matches the text collected since the last tag. Whitespace
+// between child elements is ignored; in an element without children, the
+// text, even when empty, is the element's value. line and col locate the
+// value when it is empty.
+func (v *rngValidation) flushText(el *rngOpenElement, whole bool, line, col int) {
+	s := el.text.String()
+	el.text.Reset()
+	if !whole && isXMLWhitespace(s) {
+		return
+	}
+	if s != "" {
+		line, col = el.textLine, el.textCol
+	}
+
+	p := rngTextDeriv(v.pattern, s)
+	if whole && isXMLWhitespace(s) {
+		p = rngChoice(v.pattern, p)
+	}
+	if p.kind != rngNotAllowed {
+		v.pattern = p
+		return
+	}
+	el.hasErrors = true
+	if whole && rngHasData(v.pattern) {
+		v.errorf(line, col, "element %q has invalid value %q", el.name, strings.TrimSpace(s))
+	} else {
+		v.errorf(line, col, "text is not allowed in element %q", el.name)
+	}
+}
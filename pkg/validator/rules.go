@@ -0,0 +1,204 @@
+package validator
+
+import (
+	"slices"
+	"strings"
+)
+
+// Rule describes one kind of finding. ID is stable across releases, so it
+// can be used to disable the rule or to track findings in other tools; it
+// has the form "<category>/<name>", such as "json/syntax" or
+// "schema/required".
+type Rule struct {
+	ID          string
+	Description string
+}
+
+// Rule IDs attached to findings by the validators in this package and by
+// the CLI. Syntax errors default to "<file type>/syntax".
+const (
+	RuleJSONDuplicateKey = "json/duplicate-key"
+	RuleINIDuplicateKey  = "ini/duplicate-key"
+	RuleCUESchema        = "cue/schema"
+	RuleXMLDTD           = "xml/dtd"
+	RuleXMLRelaxNG       = "xml/relaxng"
+	RuleXMLXSD           = "xml/xsd"
+	RuleSARIFSchema      = "sarif/schema"
+	RuleSchemaInvalid    = "schema/invalid"
+	RuleSchemaMissing    = "schema/missing"
+	RuleSchemaNotAllowed = "schema/not-allowed"
+	RuleSchemaNoSupport  = "schema/unsupported"
+	RuleBrokenSymlink    = "fs/broken-symlink"
+	RuleBadArchive       = "fs/unreadable-archive"
+	RuleUnknownTypeHint  = "hint/unknown-type"
+)
+
+// Rules is the catalogue of every rule ID a finding can carry, sorted by ID.
+var Rules = sortedRules([]Rule{
+	{"csv/syntax", "The file is not valid CSV."},
+	{RuleCUESchema, "The document does not conform to its CUE schema."},
+	{"cue/syntax", "The file is not valid CUE."},
+	{"editorconfig/syntax", "The file is not a valid EditorConfig file."},
+	{"env/syntax", "The file is not a valid dotenv file."},
+	{"hcl/syntax", "The file is not valid HCL."},
+	{"hocon/syntax", "The file is not valid HOCON."},
+	{"ini/syntax", "The file is not valid INI."},
+	{RuleINIDuplicateKey, "A key appears more than once in the same section."},
+	{"json/syntax", "The file is not valid JSON."},
+	{RuleJSONDuplicateKey, "A key appears more than once in the same object."},
+	{"jsonc/syntax", "The file is not valid JSON with comments."},
+	{"justfile/syntax", "The file is not a valid justfile."},
+	{"justfile/circular-dependency", "A recipe depends on itself, directly or indirectly."},
+	{"justfile/duplicate-alias", "An alias is defined more than once."},
+	{"justfile/duplicate-parameter", "A recipe declares the same parameter twice."},
+	{"justfile/duplicate-recipe", "A recipe is defined more than once."},
+	{"justfile/duplicate-setting", "A setting is set more than once."},
+	{"justfile/duplicate-variable", "A variable is defined more than once."},
+	{"justfile/export-conflict", "A variable is both exported and unexported."},
+	{"justfile/parameter-after-variadic", "A recipe declares parameters after its variadic parameter."},
+	{"justfile/undefined-alias-target", "An alias targets a recipe that does not exist."},
+	{"justfile/undefined-function", "A function that does not exist is called."},
+	{"justfile/undefined-recipe", "A recipe depends on a recipe that does not exist."},
+	{"justfile/undefined-variable", "A variable that is not defined is referenced."},
+	{"justfile/unknown-setting", "A setting is not one just recognises."},
+	{"kdl/syntax", "The file is not valid KDL."},
+	{"plist/syntax", "The file is not a valid property list."},
+	{"properties/syntax", "The file is not a valid Java properties file."},
+	{"sarif/syntax", "The file is not a SARIF log of a supported version."},
+	{RuleSARIFSchema, "The SARIF log does not conform to the SARIF specification."},
+	{"toml/syntax", "The file is not valid TOML."},
+	{"toon/syntax", "The file is not valid TOON."},
+	{"xml/syntax", "The file is not well-formed XML."},
+	{RuleXMLDTD, "The document is not valid against its DOCTYPE."},
+	{RuleXMLRelaxNG, "The document is not valid against its RELAX NG schema."},
+	{RuleXMLXSD, "The document is not valid against its XML Schema."},
+	{"yaml/syntax", "The file is not valid YAML."},
+	{"schema/additional-items", "An array has more items than the schema allows."},
+	{"schema/additional-properties", "An object has a property the schema does not allow."},
+	{"schema/all-of", "A value does not match every schema in allOf."},
+	{"schema/any-of", "A value matches none of the schemas in anyOf."},
+	{"schema/const", "A value differs from the schema's const."},
+	{"schema/contains", "An array has no item matching contains."},
+	{"schema/dependencies", "A property is present without the properties it depends on."},
+	{"schema/dependent-required", "A property is present without the properties it requires."},
+	{"schema/else", "A value does not match the schema's else branch."},
+	{"schema/enum", "A value is not one of the schema's enum values."},
+	{"schema/exclusive-maximum", "A number is not below the exclusive maximum."},
+	{"schema/exclusive-minimum", "A number is not above the exclusive minimum."},
+	{"schema/format", "A string does not match its format."},
+	{RuleSchemaInvalid, "The document does not conform to its schema, or the schema cannot be used."},
+	{"schema/max-contains", "An array has too many items matching contains."},
+	{"schema/max-items", "An array has more items than maxItems."},
+	{"schema/max-length", "A string is longer than maxLength."},
+	{"schema/max-properties", "An object has more properties than maxProperties."},
+	{"schema/maximum", "A number is above the maximum."},
+	{"schema/min-contains", "An array has too few items matching contains."},
+	{"schema/min-items", "An array has fewer items than minItems."},
+	{"schema/min-length", "A string is shorter than minLength."},
+	{"schema/min-properties", "An object has fewer properties than minProperties."},
+	{"schema/minimum", "A number is below the minimum."},
+	{RuleSchemaMissing, "The file declares no schema and --require-schema is set."},
+	{"schema/multiple-of", "A number is not a multiple of multipleOf."},
+	{"schema/not", "A value matches the schema in not."},
+	{RuleSchemaNotAllowed, "A value is present where the schema allows none."},
+	{"schema/one-of", "A value does not match exactly one schema in oneOf."},
+	{"schema/pattern", "A string does not match its pattern."},
+	{"schema/pattern-properties", "A property does not match its patternProperties schema."},
+	{"schema/property-names", "A property name does not match propertyNames."},
+	{"schema/required", "A required property is missing."},
+	{"schema/then", "A value does not match the schema's then branch."},
+	{"schema/type", "A value has the wrong type."},
+	{"schema/unevaluated-items", "An array has items no subschema evaluated."},
+	{"schema/unevaluated-properties", "An object has properties no subschema evaluated."},
+	{"schema/unique-items", "An array has duplicate items."},
+	{RuleSchemaNoSupport, "A --schema-map schema matched a file type without schema support."},
+	{RuleBrokenSymlink, "A symlink points to a file that does not exist."},
+	{RuleBadArchive, "An archive is corrupt or has a member over the size limit."},
+	{RuleUnknownTypeHint, "A modeline names a file type the validator does not know."},
+})
+
+func sortedRules(rules []Rule) []Rule {
+	slices.SortFunc(rules, func(a, b Rule) int { return strings.Compare(a.ID, b.ID) })
+	return rules
+}
+
+// LookupRule returns the catalogue entry for id.
+func LookupRule(id string) (Rule, bool) {
+	i, ok := slices.BinarySearchFunc(Rules, id, func(r Rule, id string) int { return strings.Compare(r.ID, id) })
+	if !ok {
+		return Rule{}, false
+	}
+	return Rules[i], true
+}
+
+// MatchRule reports whether the rule ID id matches pattern, which is either
+// an exact ID or a category followed by "/*", such as "schema/*".
+func MatchRule(pattern, id string) bool {
+	if category, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(id, category+"/")
+	}
+	return pattern == id
+}
+
+// SyntaxRule returns the rule ID of a syntax error in a file of the named
+// type.
+func SyntaxRule(fileType string) string {
+	return fileType + "/syntax"
+}
+
+// schemaKeywordRule returns the rule ID for a violation of a JSON Schema
+// keyword such as "minLength", or RuleSchemaInvalid if the keyword has no
+// rule of its own.
+func schemaKeywordRule(keyword string) string {
+	var b strings.Builder
+	b.WriteString("schema/")
+	for i, c := range keyword {
+		if c >= 'A' && c <= 'Z' {
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			c += 'a' - 'A'
+		}
+		b.WriteRune(c)
+	}
+	id := b.String()
+	if _, ok := LookupRule(id); !ok {
+		return RuleSchemaInvalid
+	}
+	return id
+}
+
+// legacySchemaKeywords maps gojsonschema error types to the keyword that
+// raised them.
+var legacySchemaKeywords = map[string]string{
+	"required":                        "required",
+	"invalid_type":                    "type",
+	"number_any_of":                   "anyOf",
+	"number_one_of":                   "oneOf",
+	"number_all_of":                   "allOf",
+	"number_not":                      "not",
+	"missing_dependency":              "dependencies",
+	"const":                           "const",
+	"enum":                            "enum",
+	"array_no_additional_items":       "additionalItems",
+	"array_min_items":                 "minItems",
+	"array_max_items":                 "maxItems",
+	"unique":                          "uniqueItems",
+	"contains":                        "contains",
+	"array_min_properties":            "minProperties",
+	"array_max_properties":            "maxProperties",
+	"additional_property_not_allowed": "additionalProperties",
+	"invalid_property_pattern":        "patternProperties",
+	"invalid_property_name":           "propertyNames",
+	"string_gte":                      "minLength",
+	"string_lte":                      "maxLength",
+	"does_not_match_pattern":          "pattern",
+	"multiple_of":                     "multipleOf",
+	"number_gte":                      "minimum",
+	"number_gt":                       "exclusiveMinimum",
+	"number_lte":                      "maximum",
+	"number_lt":                       "exclusiveMaximum",
+	"condition_then":                  "then",
+	"condition_else":                  "else",
+	"format":                          "format",
+}
@@ -42,7 +42,11 @@ func (SarifValidator) ValidateSchema(b []byte, _ string) (bool, error) {
 				}
 			}
 			if len(items) > 0 {
-				return false, &SchemaErrors{Prefix: "schema validation failed: ", Items: items}
+				rules := make([]string, len(items))
+				for i := range rules {
+					rules[i] = RuleSARIFSchema
+				}
+				return false, &SchemaErrors{Prefix: "schema validation failed: ", Items: items, Rules: rules}
 			}
 		}
 		return false, err
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"path/filepath"
+	"strings"
 
 	"github.com/xeipuuv/gojsonschema"
 
@@ -22,39 +23,131 @@ func JSONSchemaValidate(schemaURL string, docJSON []byte) (bool, error) {
 
 // JSONSchemaValidateWithPositions validates docJSON against schemaURL and
 // annotates errors with source positions from posMap. The map keys are
-// gojsonschema context strings like "(root).name".
+// gojsonschema context strings like "(root).name". An error at a path
+// missing from posMap takes the position of its closest ancestor.
 func JSONSchemaValidateWithPositions(schemaURL string, docJSON []byte, posMap map[string]SourcePosition) (bool, error) {
 	return validateJSONSchema(schemaURL, docJSON, posMap)
 }
 
 func validateJSONSchema(schemaURL string, docJSON []byte, posMap map[string]SourcePosition) (bool, error) {
-	schemaLoader := gojsonschema.NewReferenceLoader(schemaURL)
-	documentLoader := gojsonschema.NewBytesLoader(docJSON)
+	schema, err := jsonSchemaCache.get(schemaURL, func() (jsonSchema, error) {
+		return compileJSONSchema(schemaURL)
+	})
+	if err != nil {
+		return false, fmt.Errorf("schema validation error: %w", err)
+	}
 
-	result, err := gojsonschema.Validate(schemaLoader, documentLoader)
+	schemaErrs, err := schema.validate(docJSON)
 	if err != nil {
 		return false, fmt.Errorf("schema validation error: %w", err)
 	}
 
-	if !result.Valid() {
-		var errs []string
+	if len(schemaErrs) > 0 {
+		var errs, rules []string
 		var positions []SchemaErrorPosition
-		for _, desc := range result.Errors() {
-			errs = append(errs, desc.String())
-			var pos SchemaErrorPosition
-			if posMap != nil {
-				if sp, ok := posMap[desc.Context().String()]; ok {
-					pos = SchemaErrorPosition(sp)
-				}
-			}
-			positions = append(positions, pos)
+		for _, se := range schemaErrs {
+			errs = append(errs, se.message)
+			rules = append(rules, se.rule)
+			positions = append(positions, lookupPosition(posMap, se.context))
 		}
-		return false, &SchemaErrors{Prefix: "schema validation failed: ", Items: errs, Positions: positions}
+		return false, &SchemaErrors{Prefix: "schema validation failed: ", Items: errs, Positions: positions, Rules: rules}
 	}
 
 	return true, nil
 }
 
+// lookupPosition returns the position of path in posMap, or of its closest
+// ancestor when the map has no entry for it, such as an item of an inline
+// array.
+func lookupPosition(posMap map[string]SourcePosition, path string) SchemaErrorPosition {
+	if posMap == nil {
+		return SchemaErrorPosition{}
+	}
+	for {
+		if sp, ok := posMap[path]; ok {
+			return SchemaErrorPosition(sp)
+		}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			return SchemaErrorPosition{}
+		}
+		path = path[:i]
+	}
+}
+
+// jsonSchema is a compiled JSON Schema, independent of the engine that
+// compiled it.
+type jsonSchema interface {
+	validate(docJSON []byte) ([]jsonSchemaError, error)
+}
+
+// jsonSchemaError is a single schema violation. context is a gojsonschema
+// style instance path such as "(root).server.port", which is the key format
+// used by the source position maps. rule is the ID of the violated rule.
+type jsonSchemaError struct {
+	context string
+	message string
+	rule    string
+}
+
+// compileJSONSchema loads the schema at schemaURL and compiles it with the
+// engine matching its declared dialect. Draft 2019-09 and 2020-12 schemas
+// use santhosh-tekuri/jsonschema; everything else uses gojsonschema, which
+// implements drafts 4, 6 and 7. Schemas handled by gojsonschema are rejected
+// if they use keywords that only exist in later drafts, because gojsonschema
+// would silently ignore them.
+func compileJSONSchema(schemaURL string) (jsonSchema, error) {
+	doc, err := gojsonschema.NewReferenceLoader(schemaURL).LoadJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	if draft := declaredDraft(doc); draft != nil {
+		return compileModernJSONSchema(schemaURL, doc, draft)
+	}
+
+	if err := checkLegacyKeywords(doc); err != nil {
+		return nil, err
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewReferenceLoader(schemaURL))
+	if err != nil {
+		return nil, err
+	}
+	return legacyJSONSchema{schema}, nil
+}
+
+// legacyJSONSchema validates with gojsonschema (drafts 4, 6 and 7).
+type legacyJSONSchema struct {
+	schema *gojsonschema.Schema
+}
+
+func (s legacyJSONSchema) validate(docJSON []byte) ([]jsonSchemaError, error) {
+	result, err := s.schema.Validate(gojsonschema.NewBytesLoader(docJSON))
+	if err != nil {
+		return nil, err
+	}
+	var errs []jsonSchemaError
+	for _, desc := range result.Errors() {
+		errs = append(errs, jsonSchemaError{
+			context: desc.Context().String(),
+			message: desc.String(),
+			rule:    legacySchemaRule(desc.Type()),
+		})
+	}
+	return errs, nil
+}
+
+// legacySchemaRule returns the rule ID for a gojsonschema error type.
+func legacySchemaRule(errType string) string {
+	if errType == "false" {
+		return RuleSchemaNotAllowed
+	}
+	if keyword, ok := legacySchemaKeywords[errType]; ok {
+		return schemaKeywordRule(keyword)
+	}
+	return RuleSchemaInvalid
+}
+
 func resolveSchemaURL(schemaURL, filePath string) string {
 	if filepath.IsAbs(schemaURL) {
 		return tools.FileURL(schemaURL)
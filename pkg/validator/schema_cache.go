@@ -0,0 +1,93 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/lestrrat-go/helium/xsd"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/tools"
+)
+
+// Compiled schemas are shared by every validator in the process, so a schema
+// referenced by thousands of documents is loaded and compiled only once.
+var (
+	jsonSchemaCache schemaCache[jsonSchema]
+	xsdSchemaCache  schemaCache[*xsd.Schema]
+)
+
+// schemaCache maps a resolved schema location to its compiled form. It is
+// safe for concurrent use: callers asking for the same schema while it is
+// being compiled wait for that compilation instead of starting their own.
+// Failed compilations are not cached, so a transient error (e.g. a network
+// failure fetching a remote schema) is retried on the next lookup. Only the
+// latest version of each location is kept, so editing a schema does not
+// leave its earlier compilations behind.
+type schemaCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]*schemaCacheEntry[T]
+	keys    map[string]string // location -> key of its current entry
+}
+
+type schemaCacheEntry[T any] struct {
+	once   sync.Once
+	schema T
+	err    error
+}
+
+func (c *schemaCache[T]) get(location string, compile func() (T, error)) (T, error) {
+	key := schemaCacheKey(location)
+
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]*schemaCacheEntry[T])
+		c.keys = make(map[string]string)
+	}
+	if prev, ok := c.keys[location]; ok && prev != key {
+		delete(c.entries, prev)
+	}
+	c.keys[location] = key
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &schemaCacheEntry[T]{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.schema, entry.err = compile()
+	})
+
+	if entry.err != nil {
+		c.mu.Lock()
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+	}
+	return entry.schema, entry.err
+}
+
+// reset drops every cached schema.
+func (c *schemaCache[T]) reset() {
+	c.mu.Lock()
+	c.entries = nil
+	c.keys = nil
+	c.mu.Unlock()
+}
+
+// schemaCacheKey returns the cache key for a schema location. Local schema
+// files include their size and modification time so that an edited schema
+// (for example while running with --watch) is recompiled.
+func schemaCacheKey(location string) string {
+	path := location
+	if p, ok := tools.FileURLToPath(location); ok {
+		path = p
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return location
+	}
+	return fmt.Sprintf("%s\x00%d\x00%d", location, info.Size(), info.ModTime().UnixNano())
+}
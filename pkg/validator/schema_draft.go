@@ -0,0 +1,199 @@
+package validator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"github.com/xeipuuv/gojsonschema"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+var schemaMessagePrinter = message.NewPrinter(language.English)
+
+// declaredDraft returns the 2019-09 or 2020-12 draft named by the schema's
+// $schema keyword, or nil if the schema declares an older dialect or none.
+func declaredDraft(doc any) *jsonschema.Draft {
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return nil
+	}
+	uri, _ := obj["$schema"].(string)
+	uri = strings.TrimSuffix(uri, "#")
+	if u, ok := strings.CutPrefix(uri, "http://"); ok {
+		uri = u
+	} else {
+		uri = strings.TrimPrefix(uri, "https://")
+	}
+	switch uri {
+	case "json-schema.org/draft/2020-12/schema":
+		return jsonschema.Draft2020
+	case "json-schema.org/draft/2019-09/schema":
+		return jsonschema.Draft2019
+	default:
+		return nil
+	}
+}
+
+// compileModernJSONSchema compiles a draft 2019-09 or 2020-12 schema. doc is
+// the already loaded root document; referenced documents are loaded through
+// gojsonschema's loader so local paths, file URLs and remote URLs resolve the
+// same way they do for older drafts.
+func compileModernJSONSchema(schemaURL string, doc any, draft *jsonschema.Draft) (jsonSchema, error) {
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(draft)
+	c.AssertFormat()
+	c.UseLoader(referenceLoader{})
+	if err := c.AddResource(schemaURL, doc); err != nil {
+		return nil, err
+	}
+	schema, err := c.Compile(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+	return modernJSONSchema{schema}, nil
+}
+
+// referenceLoader loads schema documents with gojsonschema's reference loader.
+type referenceLoader struct{}
+
+func (referenceLoader) Load(url string) (any, error) {
+	return gojsonschema.NewReferenceLoader(url).LoadJSON()
+}
+
+// modernJSONSchema validates with santhosh-tekuri/jsonschema (drafts 2019-09
+// and 2020-12).
+type modernJSONSchema struct {
+	schema *jsonschema.Schema
+}
+
+func (s modernJSONSchema) validate(docJSON []byte) ([]jsonSchemaError, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(docJSON))
+	if err != nil {
+		return nil, err
+	}
+	err = s.schema.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return nil, err
+	}
+	var errs []jsonSchemaError
+	collectValidationErrors(verr, &errs)
+	return errs, nil
+}
+
+// collectValidationErrors flattens the leaves of a validation error tree into
+// errs, formatted like gojsonschema's "field: description" messages.
+func collectValidationErrors(verr *jsonschema.ValidationError, errs *[]jsonSchemaError) {
+	if len(verr.Causes) > 0 {
+		for _, cause := range verr.Causes {
+			collectValidationErrors(cause, errs)
+		}
+		return
+	}
+	context := strings.Join(append([]string{"(root)"}, verr.InstanceLocation...), ".")
+	field := "(root)"
+	if len(verr.InstanceLocation) > 0 {
+		field = strings.Join(verr.InstanceLocation, ".")
+	}
+	msg := verr.ErrorKind.LocalizedString(schemaMessagePrinter)
+	rule := RuleSchemaInvalid
+	if path := verr.ErrorKind.KeywordPath(); len(path) > 0 {
+		rule = schemaKeywordRule(path[0])
+	}
+	switch verr.ErrorKind.(type) {
+	case *kind.FalseSchema:
+		// Raised for "additionalProperties": false, "items": false,
+		// "unevaluatedProperties": false and similar.
+		msg = "not allowed by the schema"
+		rule = RuleSchemaNotAllowed
+	case *kind.Not:
+		rule = schemaKeywordRule("not")
+	case *kind.Dependency:
+		rule = schemaKeywordRule("dependencies")
+	default:
+	}
+	*errs = append(*errs, jsonSchemaError{
+		context: context,
+		message: field + ": " + msg,
+		rule:    rule,
+	})
+}
+
+// newerDraftKeywords are keywords introduced in draft 2019-09 or 2020-12.
+// gojsonschema ignores them, so a schema relying on them would pass every
+// document without complaint.
+var newerDraftKeywords = []string{
+	"prefixItems",
+	"unevaluatedProperties",
+	"unevaluatedItems",
+	"dependentRequired",
+	"dependentSchemas",
+	"minContains",
+	"maxContains",
+	"$dynamicRef",
+	"$dynamicAnchor",
+	"$recursiveRef",
+	"$recursiveAnchor",
+}
+
+// checkLegacyKeywords returns an error if a schema that will be validated as
+// draft 4, 6 or 7 uses keywords from a later draft.
+func checkLegacyKeywords(doc any) error {
+	if kw, path, ok := findNewerDraftKeyword(doc, "#"); ok {
+		return fmt.Errorf(
+			"keyword %q at %s requires JSON Schema draft 2019-09 or later; declare \"$schema\": \"https://json-schema.org/draft/2020-12/schema\" to use it",
+			kw, path)
+	}
+	return nil
+}
+
+func findNewerDraftKeyword(schema any, path string) (string, string, bool) {
+	obj, ok := schema.(map[string]any)
+	if !ok {
+		return "", "", false
+	}
+	for _, kw := range newerDraftKeywords {
+		if _, ok := obj[kw]; ok {
+			return kw, path, true
+		}
+	}
+
+	// Only descend into keywords whose values are schemas, so that property
+	// names or enum values that happen to match a keyword are not reported.
+	for _, kw := range []string{"properties", "patternProperties", "definitions", "$defs", "dependencies"} {
+		m, ok := obj[kw].(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, name := range slices.Sorted(maps.Keys(m)) {
+			if kw, p, ok := findNewerDraftKeyword(m[name], path+"/"+kw+"/"+name); ok {
+				return kw, p, true
+			}
+		}
+	}
+	for _, kw := range []string{"items", "allOf", "anyOf", "oneOf"} {
+		if arr, ok := obj[kw].([]any); ok {
+			for i, sub := range arr {
+				if kw, p, ok := findNewerDraftKeyword(sub, fmt.Sprintf("%s/%s/%d", path, kw, i)); ok {
+					return kw, p, true
+				}
+			}
+		}
+	}
+	for _, kw := range []string{"items", "not", "if", "then", "else", "additionalProperties", "additionalItems", "contains", "propertyNames"} {
+		if kw, p, ok := findNewerDraftKeyword(obj[kw], path+"/"+kw); ok {
+			return kw, p, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,106 @@
+package validator
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/tailscale/hujson"
+	"github.com/toon-format/toon-go"
+)
+
+func (JSONValidator) SchemaLocation(b []byte, filePath string) string {
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return ""
+	}
+	return declaredSchemaURL(doc, filePath)
+}
+
+func (JSONCValidator) SchemaLocation(b []byte, filePath string) string {
+	standardized, err := hujson.Standardize(b)
+	if err != nil {
+		return ""
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(standardized, &doc); err != nil {
+		return ""
+	}
+	return declaredSchemaURL(doc, filePath)
+}
+
+func (TomlValidator) SchemaLocation(b []byte, filePath string) string {
+	var doc map[string]any
+	if err := toml.Unmarshal(b, &doc); err != nil {
+		return ""
+	}
+	return declaredSchemaURL(doc, filePath)
+}
+
+func (ToonValidator) SchemaLocation(b []byte, filePath string) string {
+	raw, err := toon.Decode(b)
+	if err != nil {
+		return ""
+	}
+	doc, _ := raw.(map[string]any)
+	return declaredSchemaURL(doc, filePath)
+}
+
+func (YAMLValidator) SchemaLocation(b []byte, filePath string) string {
+	schemaURL := extractYAMLSchemaComment(b)
+	if schemaURL == "" {
+		return ""
+	}
+	return resolveSchemaURL(schemaURL, filePath)
+}
+
+// SchemaLocation separates the locations of a document that names several
+// schemas with spaces; SchemaLocations lists them.
+func (v XMLValidator) SchemaLocation(b []byte, filePath string) string {
+	return strings.Join(v.SchemaLocations(b, filePath), " ")
+}
+
+// SchemaLocations returns the schemas ValidateSchema would read for the
+// document. The external DTD counts when DTD is set.
+func (v XMLValidator) SchemaLocations(b []byte, filePath string) []string {
+	hints, err := extractXSDLocations(b)
+	if err != nil {
+		return nil
+	}
+	catalog, err := v.catalog()
+	if err != nil {
+		return nil
+	}
+	var locations []string
+	if doctype, ok := findDoctype(b); ok && v.DTD && doctype.external() {
+		if loc := dtdLocation(catalog, doctype.systemID, doctype.publicID, filePath); loc != "" {
+			locations = append(locations, loc)
+		}
+	}
+	for _, hint := range v.resolveXSDLocations(hints, filePath, catalog) {
+		locations = append(locations, hint.Location)
+	}
+	return locations
+}
+
+// CatalogFiles returns the catalog and the catalogs it chains to.
+func (v XMLValidator) CatalogFiles() []string {
+	catalog, err := v.catalog()
+	if err != nil || catalog == nil {
+		if v.Catalog != "" {
+			return []string{v.Catalog}
+		}
+		return nil
+	}
+	return catalog.files
+}
+
+// declaredSchemaURL returns the resolved "$schema" of doc, or "" if doc has
+// no string "$schema" property.
+func declaredSchemaURL(doc map[string]any, filePath string) string {
+	schemaURL, _ := doc["$schema"].(string)
+	if schemaURL == "" {
+		return ""
+	}
+	return resolveSchemaURL(schemaURL, filePath)
+}
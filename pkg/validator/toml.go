@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
 )
 
 type TomlValidator struct{}
@@ -64,5 +67,109 @@ func (TomlValidator) ValidateSchema(b []byte, filePath string) (bool, error) {
 		return false, err
 	}
 
-	return JSONSchemaValidate(resolveSchemaURL(schemaURL, filePath), docJSON)
+	return JSONSchemaValidateWithPositions(resolveSchemaURL(schemaURL, filePath), docJSON, buildTOMLPositionMap(b))
+}
+
+// PositionMap locates the keys, tables and array items of the document in
+// b.
+func (TomlValidator) PositionMap(b []byte) map[string]SourcePosition {
+	return buildTOMLPositionMap(b)
+}
+
+// buildTOMLPositionMap walks the TOML expressions in b and maps the path of
+// every key, table header and array item to its position. An item of an
+// array of tables is located at its [[header]].
+func buildTOMLPositionMap(b []byte) map[string]SourcePosition {
+	var p unstable.Parser
+	p.Reset(b)
+	positions := map[string]SourcePosition{"(root)": {Line: 1, Column: 1}}
+
+	// arrayTables holds the index of the current item of every array of
+	// tables seen so far, keyed by its path.
+	arrayTables := make(map[string]int)
+	table := "(root)"
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			path := "(root)"
+			var pos SourcePosition
+			it := expr.Key()
+			for it.Next() {
+				key := it.Node()
+				if pos.Line == 0 {
+					pos = tomlPosition(&p, key)
+				}
+				path += "." + string(key.Data)
+				if idx, ok := arrayTables[path]; ok && (!it.IsLast() || expr.Kind == unstable.Table) {
+					path += "." + strconv.Itoa(idx)
+				}
+			}
+			if expr.Kind == unstable.ArrayTable {
+				idx, ok := arrayTables[path]
+				if ok {
+					idx++
+				}
+				// A new item starts its nested arrays of tables afresh.
+				for nested := range arrayTables {
+					if strings.HasPrefix(nested, path+".") {
+						delete(arrayTables, nested)
+					}
+				}
+				arrayTables[path] = idx
+				positions[path] = pos
+				path += "." + strconv.Itoa(idx)
+			}
+			positions[path] = pos
+			table = path
+		case unstable.KeyValue:
+			walkTOMLKeyValue(&p, expr, table, positions)
+		}
+	}
+	return positions
+}
+
+func walkTOMLKeyValue(p *unstable.Parser, kv *unstable.Node, parent string, positions map[string]SourcePosition) {
+	path := parent
+	it := kv.Key()
+	for it.Next() {
+		key := it.Node()
+		path += "." + string(key.Data)
+		if _, ok := positions[path]; !ok {
+			positions[path] = tomlPosition(p, key)
+		}
+	}
+	walkTOMLValue(p, kv.Value(), path, positions)
+}
+
+func walkTOMLValue(p *unstable.Parser, value *unstable.Node, path string, positions map[string]SourcePosition) {
+	switch value.Kind {
+	case unstable.Array:
+		i := 0
+		it := value.Children()
+		for it.Next() {
+			item := it.Node()
+			if item.Kind == unstable.Comment {
+				continue
+			}
+			itemPath := path + "." + strconv.Itoa(i)
+			if item.Raw.Length > 0 {
+				positions[itemPath] = tomlPosition(p, item)
+			}
+			walkTOMLValue(p, item, itemPath, positions)
+			i++
+		}
+	case unstable.InlineTable:
+		it := value.Children()
+		for it.Next() {
+			if kv := it.Node(); kv.Kind == unstable.KeyValue {
+				walkTOMLKeyValue(p, kv, path, positions)
+			}
+		}
+	}
+}
+
+func tomlPosition(p *unstable.Parser, n *unstable.Node) SourcePosition {
+	start := p.Shape(n.Raw).Start
+	return SourcePosition{Line: start.Line, Column: start.Column}
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/toon-format/toon-go"
 )
@@ -71,5 +72,190 @@ func (ToonValidator) ValidateSchema(b []byte, filePath string) (bool, error) {
 		return false, err
 	}
 
-	return JSONSchemaValidate(resolveSchemaURL(schemaURL, filePath), docJSON)
+	return JSONSchemaValidateWithPositions(resolveSchemaURL(schemaURL, filePath), docJSON, buildTOONPositionMap(b))
+}
+
+// PositionMap locates the keys, list items and table rows of the document
+// in b.
+func (ToonValidator) PositionMap(b []byte) map[string]SourcePosition {
+	return buildTOONPositionMap(b)
+}
+
+// toonIndent is the indentation width of the TOON decoder.
+const toonIndent = 2
+
+type toonLine struct {
+	number  int
+	depth   int
+	column  int
+	content string
+}
+
+// toonPositions maps the paths of a TOON document to positions. It follows
+// the structure the decoder accepts, line by line, without decoding values.
+type toonPositions struct {
+	lines     []toonLine
+	next      int
+	positions map[string]SourcePosition
+}
+
+func buildTOONPositionMap(b []byte) map[string]SourcePosition {
+	t := &toonPositions{positions: make(map[string]SourcePosition)}
+	for i, raw := range strings.Split(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n") {
+		content := strings.TrimLeft(raw, " \t")
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		indent := len(raw) - len(content)
+		t.lines = append(t.lines, toonLine{number: i + 1, depth: indent / toonIndent, column: indent + 1, content: content})
+	}
+	if len(t.lines) == 0 {
+		return t.positions
+	}
+
+	first := t.lines[0]
+	t.positions["(root)"] = SourcePosition{Line: first.number, Column: first.column}
+	if key, header, _, ok := parseTOONField(first.content); ok && header != nil && key == "" {
+		t.next++
+		t.array(header, 0, "(root)")
+		return t.positions
+	}
+	t.object(0, "(root)")
+	return t.positions
+}
+
+// object records the fields at depth, which belong to the object at path.
+func (t *toonPositions) object(depth int, path string) {
+	for t.next < len(t.lines) {
+		line := t.lines[t.next]
+		if line.depth < depth {
+			return
+		}
+		if line.depth > depth {
+			t.next++
+			continue
+		}
+		t.field(line, line.content, line.column, path, depth, depth+1)
+	}
+}
+
+// field records the "key: value" or "key[N]: ..." field in content, which
+// starts at column of line. arrayDepth is the depth its array header is
+// parsed at and objectDepth that of the fields of its nested object.
+func (t *toonPositions) field(line toonLine, content string, column int, path string, arrayDepth, objectDepth int) {
+	t.next++
+	key, header, rest, ok := parseTOONField(content)
+	if !ok {
+		return
+	}
+	path += "." + key
+	t.positions[path] = SourcePosition{Line: line.number, Column: column}
+	switch {
+	case header != nil:
+		t.array(header, arrayDepth, path)
+	case rest == "":
+		t.object(objectDepth, path)
+	}
+}
+
+// array records the items of the array at path, whose header was parsed
+// at depth.
+func (t *toonPositions) array(header *toonHeader, depth int, path string) {
+	if header.inline {
+		return
+	}
+	for i := 0; t.next < len(t.lines); i++ {
+		line := t.lines[t.next]
+		if line.depth != depth+1 {
+			return
+		}
+		itemPath := path + "." + strconv.Itoa(i)
+		t.positions[itemPath] = SourcePosition{Line: line.number, Column: line.column}
+
+		if header.fields != nil {
+			if toonIndexOutsideQuotes(line.content, ':') != -1 {
+				return
+			}
+			for _, field := range header.fields {
+				t.positions[itemPath+"."+field] = SourcePosition{Line: line.number, Column: line.column}
+			}
+			t.next++
+			continue
+		}
+
+		item, isItem := strings.CutPrefix(line.content, "-")
+		if !isItem {
+			return
+		}
+		content := strings.TrimLeft(item, " ")
+		column := line.column + len(line.content) - len(content)
+		key, itemHeader, _, ok := parseTOONField(content)
+		if !ok {
+			t.next++
+			continue
+		}
+		if key == "" && itemHeader != nil {
+			t.next++
+			t.array(itemHeader, depth+1, itemPath)
+			continue
+		}
+		// The first field of an object item shares the hyphen's line; the
+		// others follow one level deeper.
+		t.field(line, content, column, itemPath, depth+1, depth+3)
+		for t.next < len(t.lines) && t.lines[t.next].depth == depth+2 {
+			sibling := t.lines[t.next]
+			t.field(sibling, sibling.content, sibling.column, itemPath, depth+1, depth+3)
+		}
+	}
+}
+
+// toonHeader is the "[N]{fields}" part of an array field. inline is set
+// when the items follow the colon on the same line.
+type toonHeader struct {
+	fields []string
+	inline bool
+}
+
+// parseTOONField splits a "key: value" or "key[N]{fields}: values" line.
+// header is nil for a field that is not an array.
+func parseTOONField(content string) (key string, header *toonHeader, rest string, ok bool) {
+	colon := toonIndexOutsideQuotes(content, ':')
+	if colon == -1 {
+		return "", nil, "", false
+	}
+	left := strings.TrimSpace(content[:colon])
+	rest = strings.TrimSpace(content[colon+1:])
+	if bracket := toonIndexOutsideQuotes(left, '['); bracket != -1 {
+		header = &toonHeader{inline: rest != ""}
+		if open := strings.Index(left[bracket:], "]{"); open != -1 {
+			fields := strings.TrimSuffix(left[bracket+open+2:], "}")
+			for _, field := range strings.FieldsFunc(fields, func(r rune) bool { return r == ',' || r == '|' || r == '\t' }) {
+				header.fields = append(header.fields, unquoteTOONKey(strings.TrimSpace(field)))
+			}
+		}
+		left = strings.TrimSpace(left[:bracket])
+	}
+	return unquoteTOONKey(left), header, rest, true
+}
+
+func unquoteTOONKey(key string) string {
+	if unquoted, err := strconv.Unquote(key); err == nil {
+		return unquoted
+	}
+	return key
+}
+
+func toonIndexOutsideQuotes(s string, c byte) int {
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == c && !quoted:
+			return i
+		}
+	}
+	return -1
 }
@@ -11,15 +11,55 @@ var ErrNoSchema = errors.New("no schema declared")
 
 // ValidationError wraps a validation error with optional source position.
 // Line and Column are 1-based. A zero value means the position is unknown.
+// Rule is the ID of the rule the error violates; when empty, the CLI uses
+// the file type's syntax rule.
 type ValidationError struct {
 	Err    error
 	Line   int
 	Column int
+	Rule   string
 }
 
 func (e *ValidationError) Error() string { return e.Err.Error() }
 func (e *ValidationError) Unwrap() error { return e.Err }
 
+// SyntaxErrors holds every syntax error a validator found in a file, in the
+// order they appear. errors.As finds the first of them as a
+// *ValidationError, so callers interested in a single position still get
+// one.
+type SyntaxErrors struct {
+	Items []*ValidationError
+}
+
+func (e *SyntaxErrors) Error() string {
+	msgs := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		msgs[i] = item.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *SyntaxErrors) Unwrap() []error {
+	errs := make([]error, len(e.Items))
+	for i, item := range e.Items {
+		errs[i] = item
+	}
+	return errs
+}
+
+// syntaxErrors returns items as a single error: nil when there are none,
+// the item itself when there is one, and a *SyntaxErrors otherwise.
+func syntaxErrors(items []*ValidationError) error {
+	switch len(items) {
+	case 0:
+		return nil
+	case 1:
+		return items[0]
+	default:
+		return &SyntaxErrors{Items: items}
+	}
+}
+
 // SchemaErrorPosition holds the source position for a single schema error.
 type SchemaErrorPosition struct {
 	Line   int
@@ -31,10 +71,13 @@ type SchemaErrorPosition struct {
 // while Errors() returns individual error messages.
 // Positions is parallel to Items — Positions[i] is the source position
 // for Items[i]. A zero-value position means the location is unknown.
+// Rules is parallel to Items too; a missing or empty rule ID means
+// RuleSchemaInvalid.
 type SchemaErrors struct {
 	Prefix    string
 	Items     []string
 	Positions []SchemaErrorPosition
+	Rules     []string
 }
 
 func (e *SchemaErrors) Error() string {
@@ -45,6 +88,14 @@ func (e *SchemaErrors) Errors() []string {
 	return e.Items
 }
 
+// Rule returns the rule ID of Items[i].
+func (e *SchemaErrors) Rule(i int) string {
+	if i < len(e.Rules) && e.Rules[i] != "" {
+		return e.Rules[i]
+	}
+	return RuleSchemaInvalid
+}
+
 // Validator is the base interface that all validators must implement.
 // For optional capabilities, use type assertions against SchemaValidator.
 type Validator interface {
@@ -68,6 +119,15 @@ type JSONMarshaler interface {
 	MarshalToJSON(b []byte) ([]byte, error)
 }
 
+// PositionMapper is an optional interface for JSONMarshaler validators that
+// can locate the values of their JSON form in the source. PositionMap maps
+// instance paths such as "(root).server.port" or "(root).items.0" to the
+// position of the key or value in b. The map may be nil or partial when b
+// cannot be parsed.
+type PositionMapper interface {
+	PositionMap(b []byte) map[string]SourcePosition
+}
+
 // XMLSchemaValidator is a marker interface for validators that use XSD
 // schema validation instead of JSON Schema. When an external schema is
 // applied via --schema-map or --schemastore, the CLI uses ValidateXSD
@@ -75,3 +135,97 @@ type JSONMarshaler interface {
 type XMLSchemaValidator interface {
 	ValidateXSD(b []byte, schemaPath string) (bool, error)
 }
+
+// DTDSchemaValidator is an optional interface for validators whose
+// documents can be validated against a DTD. When --schema-map maps a file
+// to a DTD (see IsDTDSchema), the CLI uses ValidateDTD.
+type DTDSchemaValidator interface {
+	ValidateDTD(b []byte, dtdPath string) (bool, error)
+}
+
+// RelaxNGSchemaValidator is an optional interface for validators whose
+// documents can be validated against a RELAX NG schema. When --schema-map
+// maps a file to a RELAX NG schema (see IsRelaxNGSchema), the CLI uses
+// ValidateRelaxNG.
+type RelaxNGSchemaValidator interface {
+	ValidateRelaxNG(b []byte, schemaPath string) (bool, error)
+}
+
+// CUESchemaValidator is an optional interface for validators whose
+// documents are validated against CUE schemas directly rather than through
+// a JSON conversion. When --schema-map maps a file to a CUE schema (see
+// IsCUESchema), the CLI uses ValidateCUE for validators that implement it
+// and converts the documents of other JSONMarshaler validators to JSON.
+type CUESchemaValidator interface {
+	ValidateCUE(b []byte, schemaPath string) (bool, error)
+}
+
+// SchemaLocator is an optional interface for schema validators that can
+// report the schema a document declares without validating against it.
+// SchemaLocation returns the location ValidateSchema would resolve for
+// filePath, or "" when the document does not declare a usable schema.
+type SchemaLocator interface {
+	SchemaLocation(b []byte, filePath string) string
+}
+
+// MultiSchemaLocator is an optional interface for SchemaLocators whose
+// documents can declare several schemas. SchemaLocations returns each of
+// them, resolved as SchemaLocation resolves them.
+type MultiSchemaLocator interface {
+	SchemaLocations(b []byte, filePath string) []string
+}
+
+// CatalogUser is an optional interface for validators that resolve schemas
+// through catalog files. CatalogFiles returns the files, whose content can
+// change what a document is validated against.
+type CatalogUser interface {
+	CatalogFiles() []string
+}
+
+// Commenter is an optional interface for validators whose formats support
+// comments. CommentPrefixes returns the markers that start a comment, such
+// as "#" or "//". The CLI uses them to find cfv-disable directives.
+type Commenter interface {
+	CommentPrefixes() []string
+}
+
+// Severity is the severity of a Diagnostic.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnostic is a finding about a syntactically valid document that does not
+// make it invalid on its own, such as an unknown setting. Line and Column are
+// 1-based; zero means the position is unknown. Rule is the ID of the rule
+// that produced the diagnostic.
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+	Line     int
+	Column   int
+	Rule     string
+}
+
+// Diagnoser is an optional interface for validators that report warnings or
+// informational findings. Diagnostics is only called for documents that
+// passed ValidateSyntax.
+type Diagnoser interface {
+	Diagnostics(b []byte) []Diagnostic
+}
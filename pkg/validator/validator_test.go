@@ -1,13 +1,23 @@
 package validator
 
 import (
+	"bufio"
+	"context"
 	_ "embed"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/hashicorp/go-envparse"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/Boeing/config-file-validator/v2/pkg/tools"
@@ -579,6 +589,183 @@ func Test_ToonMarshalToJSONInvalid(t *testing.T) {
 	require.Error(t, err)
 }
 
+func Test_IniMarshalToJSON(t *testing.T) {
+	t.Parallel()
+	out, err := IniValidator{}.MarshalToJSON([]byte("name = app\n\n[server]\nport = 8080\nport = 9090\n\n[empty]\n"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name": "app", "server": {"port": "9090"}, "empty": {}}`, string(out))
+
+	_, err = IniValidator{}.MarshalToJSON([]byte("server = x\n[server]\nport = 1\n"))
+	require.ErrorContains(t, err, "section [server] conflicts")
+}
+
+func Test_PropMarshalToJSON(t *testing.T) {
+	t.Parallel()
+	src := []byte("server.port=8080\nserver.host = ${name}.local\nname: app\n")
+	out, err := PropValidator{}.MarshalToJSON(src)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"server.port": "8080", "server.host": "app.local", "name": "app"}`, string(out))
+
+	out, err = PropValidator{NestedKeys: true}.MarshalToJSON(src)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"server": {"port": "8080", "host": "app.local"}, "name": "app"}`, string(out))
+
+	_, err = PropValidator{NestedKeys: true}.MarshalToJSON([]byte("a=1\na.b=2\n"))
+	require.ErrorContains(t, err, `property "a.b" conflicts with property "a"`)
+	_, err = PropValidator{NestedKeys: true}.MarshalToJSON([]byte("a.b=2\na=1\n"))
+	require.ErrorContains(t, err, `property "a" conflicts`)
+}
+
+func Test_EnvMarshalToJSON(t *testing.T) {
+	t.Parallel()
+	out, err := EnvValidator{}.MarshalToJSON([]byte("# app\nexport PORT=80\nNAME=\"my app\"\nPORT=81\n"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"PORT": "81", "NAME": "my app"}`, string(out))
+
+	_, err = EnvValidator{}.MarshalToJSON([]byte("not valid\n"))
+	require.Error(t, err)
+}
+
+func Test_HclMarshalToJSON(t *testing.T) {
+	t.Parallel()
+	src := `region = var.region
+count  = 3
+ratio  = 1.5
+tags   = { env = "prod", ids = [1, 2] }
+none   = null
+
+terraform {
+  required_version = ">= 1.0"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "more-logs"
+}
+
+provisioner "local-exec" {
+  command = "echo ${self.id}"
+}
+`
+	out, err := HclValidator{}.MarshalToJSON([]byte(src))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"region": "var.region",
+		"count": 3,
+		"ratio": 1.5,
+		"tags": {"env": "prod", "ids": [1, 2]},
+		"none": null,
+		"terraform": {"required_version": ">= 1.0"},
+		"resource": {"aws_s3_bucket": {"logs": [{"bucket": "logs"}, {"bucket": "more-logs"}]}},
+		"provisioner": {"local-exec": {"command": "echo ${self.id}"}}
+	}`, string(out))
+
+	_, err = HclValidator{}.MarshalToJSON([]byte("a = 1\na {\n}\n"))
+	require.ErrorContains(t, err, "block a conflicts")
+
+	_, err = HclValidator{}.MarshalToJSON([]byte("a = {"))
+	require.Error(t, err)
+}
+
+func Test_PlistMarshalToJSON(t *testing.T) {
+	t.Parallel()
+	src := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+  <key>PayloadIdentifier</key><string>com.example.wifi</string>
+  <key>PayloadVersion</key><integer>1</integer>
+  <key>Ratio</key><real>0.5</real>
+  <key>RemovalDisallowed</key><true/>
+  <key>Expires</key><date>2025-06-01T12:00:00Z</date>
+  <key>Certificate</key><data>aGVsbG8=</data>
+  <key>PayloadContent</key>
+  <array>
+    <dict><key>SSID</key><string>office</string></dict>
+  </array>
+</dict>
+</plist>`
+	out, err := PlistValidator{}.MarshalToJSON([]byte(src))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"PayloadIdentifier": "com.example.wifi",
+		"PayloadVersion": 1,
+		"Ratio": 0.5,
+		"RemovalDisallowed": true,
+		"Expires": "2025-06-01T12:00:00Z",
+		"Certificate": "aGVsbG8=",
+		"PayloadContent": [{"SSID": "office"}]
+	}`, string(out))
+
+	_, err = PlistValidator{}.MarshalToJSON([]byte("<plist><dict><key>a</key></plist>"))
+	require.Error(t, err)
+}
+
+func Test_HoconMarshalToJSON(t *testing.T) {
+	t.Parallel()
+	src := `akka {
+  loglevel = INFO
+  remote.port = 2552
+  timeout = 30s
+}
+host = "db.local"
+url = "jdbc://"${host}":"${akka.remote.port}
+ratio = 0.75
+debug = false
+secret = ${?CFV_TEST_UNSET_VARIABLE}
+nodes = [1, 2, ${?CFV_TEST_UNSET_VARIABLE}]
+copy = ${akka.remote}
+nothing = null
+`
+	out, err := HoconValidator{}.MarshalToJSON([]byte(src))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"akka": {"loglevel": "INFO", "remote": {"port": 2552}, "timeout": "30s"},
+		"host": "db.local",
+		"url": "jdbc://db.local:2552",
+		"ratio": 0.75,
+		"debug": false,
+		"nodes": [1, 2],
+		"copy": {"port": 2552},
+		"nothing": null
+	}`, string(out))
+
+	_, err = HoconValidator{}.MarshalToJSON([]byte("a = ${missing}"))
+	require.Error(t, err)
+}
+
+func Test_KdlMarshalToJSON(t *testing.T) {
+	t.Parallel()
+	src := `server "web" port=8080 {
+    tls enabled=true
+    route "/" "/api"
+}
+server "admin"
+limit (u8)3 12345678901234567890123
+`
+	out, err := KdlValidator{}.MarshalToJSON([]byte(src))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"server": [
+			{"args": ["web"], "props": {"port": 8080}, "children": {
+				"tls": [{"props": {"enabled": true}}],
+				"route": [{"args": ["/", "/api"]}]
+			}},
+			{"args": ["admin"]}
+		],
+		"limit": [{"args": [3, 12345678901234567890123]}]
+	}`, string(out))
+
+	out, err = KdlValidator{}.MarshalToJSON([]byte("// empty\n"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(out))
+
+	_, err = KdlValidator{}.MarshalToJSON([]byte(`node "unterminated`))
+	require.Error(t, err)
+}
+
 // --- resolveSchemaURL tests ---
 
 func Test_resolveSchemaURLHTTPS(t *testing.T) {
@@ -678,6 +865,91 @@ func Test_YAMLValidateSchemaInvalidYAML(t *testing.T) {
 	require.Error(t, err)
 }
 
+// --- CUE schema validation tests ---
+
+func writeTestCUE(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schema.cue")
+	schema := `#Config: {
+	name:  string
+	port:  int & >0 & <65536
+	tags?: [...string]
+}
+`
+	require.NoError(t, os.WriteFile(path, []byte(schema), 0o600))
+	return path
+}
+
+func Test_IsCUESchema(t *testing.T) {
+	t.Parallel()
+	require.True(t, IsCUESchema("schemas/app.cue"))
+	require.True(t, IsCUESchema("/abs/app.cue#Config"))
+	require.False(t, IsCUESchema("schemas/app.json"))
+	require.False(t, IsCUESchema("app.json#/definitions/a"))
+}
+
+func Test_CueValidateCUE(t *testing.T) {
+	t.Parallel()
+	schema := writeTestCUE(t) + "#Config"
+
+	valid, err := CueValidator{}.ValidateCUE([]byte("name: \"api\"\nport: 8080\n"), schema)
+	require.True(t, valid)
+	require.NoError(t, err)
+
+	valid, err = CueValidator{}.ValidateCUE([]byte("name: \"api\"\nport: 0\n"), schema)
+	require.False(t, valid)
+	var se *SchemaErrors
+	require.ErrorAs(t, err, &se)
+	require.Equal(t, []string{"port: invalid value 0 (out of bound >0)"}, se.Items)
+	require.Equal(t, []SchemaErrorPosition{{Line: 2, Column: 7}}, se.Positions)
+	require.Equal(t, []string{RuleCUESchema}, se.Rules)
+
+	_, err = CueValidator{}.ValidateCUE([]byte("name: \"api\"\nport: 80\nextra: 1\n"), schema)
+	require.ErrorContains(t, err, "extra: field not allowed")
+
+	_, err = CueValidator{}.ValidateCUE([]byte("name: \"api\"\n"), schema)
+	require.ErrorContains(t, err, "port: incomplete value")
+}
+
+func Test_ValidateCUEJSONDocument(t *testing.T) {
+	t.Parallel()
+	schema := writeTestCUE(t) + "#Config"
+
+	valid, err := ValidateCUE([]byte(`{"name": "api", "port": 80, "tags": ["a"]}`), schema)
+	require.True(t, valid)
+	require.NoError(t, err)
+
+	_, err = ValidateCUE([]byte(`{"name": 1, "port": 80}`), schema)
+	require.ErrorContains(t, err, "name: conflicting values string and 1")
+}
+
+func Test_ValidateCUEWholeFile(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "schema.cue")
+	require.NoError(t, os.WriteFile(path, []byte("replicas: int & <=5\n"), 0o600))
+
+	valid, err := ValidateCUE([]byte(`{"replicas": 3, "other": true}`), path)
+	require.True(t, valid)
+	require.NoError(t, err)
+
+	_, err = ValidateCUE([]byte(`{"replicas": 9}`), path)
+	require.ErrorContains(t, err, "replicas: invalid value 9")
+}
+
+func Test_ValidateCUESchemaErrors(t *testing.T) {
+	t.Parallel()
+	schema := writeTestCUE(t)
+
+	_, err := ValidateCUE([]byte(`{}`), schema+"#Missing")
+	require.ErrorContains(t, err, "has no definition #Missing")
+
+	_, err = ValidateCUE([]byte(`{}`), filepath.Join(t.TempDir(), "none.cue"))
+	require.ErrorContains(t, err, "schema compilation error")
+
+	_, err = ValidateCUE([]byte(`{`), schema)
+	require.ErrorContains(t, err, "cue parse error")
+}
+
 // --- XML XSD validation tests ---
 
 func Test_XMLValidateSchemaValid(t *testing.T) {
@@ -762,6 +1034,202 @@ func Test_XMLValidateXSDExported(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func Test_extractXSDLocations(t *testing.T) {
+	t.Parallel()
+	hints, err := extractXSDLocations([]byte(`<?xml version="1.0"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0"
+         xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+         xsi:noNamespaceSchemaLocation="local.xsd"
+         xsi:schemaLocation="http://maven.apache.org/POM/4.0.0
+                             https://maven.apache.org/xsd/maven-4.0.0.xsd
+                             urn:ext ext.xsd"/>`))
+	require.NoError(t, err)
+	require.Equal(t, []xsdLocation{
+		{Location: "local.xsd"},
+		{Namespace: "http://maven.apache.org/POM/4.0.0", Location: "https://maven.apache.org/xsd/maven-4.0.0.xsd"},
+		{Namespace: "urn:ext", Location: "ext.xsd"},
+	}, hints)
+
+	_, err = extractXSDLocations([]byte(`<a xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="urn:a a.xsd urn:b"/>`))
+	require.ErrorContains(t, err, "pairs, got 3 values")
+
+	_, err = extractXSDLocations([]byte(`<a xmlns:xsi="urn:wrong" xsi:noNamespaceSchemaLocation="a.xsd"/>`))
+	require.ErrorContains(t, err, "noNamespaceSchemaLocation uses incorrect namespace")
+
+	hints, err = extractXSDLocations([]byte(`<a xmlns:x="urn:other" x:schemaLocation="urn:a a.xsd" schemaLocation="b.xsd"/>`))
+	require.NoError(t, err, "schemaLocation outside xsi is an ordinary attribute")
+	require.Empty(t, hints)
+}
+
+// writeTestCatalog writes an XML catalog and the schemas it maps to dir.
+func writeTestCatalog(t *testing.T, dir string) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "xsd", "spring"), 0o755))
+	writeTestFile(t, dir, "xsd/pom.xsd", `<xs:schema xmlns:xs="`+xsNamespace+`" targetNamespace="urn:pom" elementFormDefault="qualified">`+
+		`<xs:element name="project" type="xs:string"/></xs:schema>`)
+	writeTestFile(t, dir, "catalog.xml", `<?xml version="1.0"?>
+<catalog xmlns="urn:oasis:names:tc:entity:xmlns:xml:catalog">
+  <uri name="urn:pom" uri="xsd/pom.xsd"/>
+  <system systemId="https://example.com/pom.xsd" uri="xsd/pom.xsd"/>
+  <public publicId="-//Example//DTD Config 1.0//EN" uri="dtd/config.dtd"/>
+  <nextCatalog catalog="spring.xml"/>
+</catalog>`)
+	writeTestFile(t, dir, "spring.xml", `<catalog xmlns="urn:oasis:names:tc:entity:xmlns:xml:catalog">
+  <rewriteSystem systemIdStartString="https://example.com/schema/" rewritePrefix="xsd/spring/"/>
+  <rewriteSystem systemIdStartString="https://example.com/schema/beans/" rewritePrefix="https://mirror.example.com/beans/"/>
+</catalog>`)
+	return filepath.Join(dir, "catalog.xml")
+}
+
+func writeTestFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content), 0o600))
+}
+
+func Test_XMLCatalogResolve(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	catalog, err := loadXMLCatalog(writeTestCatalog(t, dir))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"urn:pom", filepath.Join(dir, "xsd", "pom.xsd")},
+		{"https://example.com/pom.xsd", filepath.Join(dir, "xsd", "pom.xsd")},
+		{"-//Example//DTD Config 1.0//EN", filepath.Join(dir, "dtd", "config.dtd")},
+		{"https://example.com/schema/context/context.xsd", filepath.Join(dir, "xsd", "spring", "context", "context.xsd")},
+		{"https://example.com/schema/beans/beans.xsd", "https://mirror.example.com/beans/beans.xsd"},
+	}
+	for _, tt := range tests {
+		got, ok := catalog.resolve(tt.name)
+		require.True(t, ok, tt.name)
+		require.Equal(t, tt.want, got)
+	}
+	_, ok := catalog.resolve("urn:unknown")
+	require.False(t, ok)
+
+	_, err = loadXMLCatalog(filepath.Join(dir, "missing.xml"))
+	require.ErrorContains(t, err, "reading XML catalog")
+}
+
+func Test_XSDBundleRewritesReferences(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	catalog, err := loadXMLCatalog(writeTestCatalog(t, dir))
+	require.NoError(t, err)
+	writeTestFile(t, dir, "xsd/spring/beans.xsd", `<xs:schema xmlns:xs="`+xsNamespace+`" targetNamespace="urn:beans">`+
+		`<xs:include schemaLocation='common.xsd'/><xs:element name="beans" type="xs:string"/></xs:schema>`)
+	writeTestFile(t, dir, "xsd/spring/common.xsd", `<xs:schema xmlns:xs="`+xsNamespace+`"/>`)
+	writeTestFile(t, dir, "local.xsd", `<?xml version="1.0" encoding="ISO-8859-1"?>
+<xs:schema xmlns:xs="`+xsNamespace+`">
+  <xs:import namespace="urn:beans" schemaLocation="https://example.com/schema/beans.xsd"/>
+  <xs:import namespace="urn:pom"/>
+  <xs:element name="config" type="xs:string"/>
+</xs:schema>`)
+
+	bundle := &xsdBundle{dir: t.TempDir(), catalog: catalog, names: make(map[string]string)}
+	root, err := bundle.write([]xsdLocation{
+		{Location: filepath.Join(dir, "local.xsd")},
+		{Namespace: "urn:pom", Location: filepath.Join(dir, "xsd", "pom.xsd")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, xsdBundleRoot, filepath.Base(root))
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(bundle.dir, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+	require.Contains(t, read(xsdBundleRoot), `<xs:include schemaLocation="schema1.xsd"/>`)
+	require.Contains(t, read(xsdBundleRoot), `<xs:import namespace="urn:pom" schemaLocation="schema4.xsd"/>`)
+	require.Contains(t, read("schema1.xsd"), `<xs:import namespace="urn:beans" schemaLocation="schema2.xsd"/>`)
+	require.Contains(t, read("schema1.xsd"), `<xs:import schemaLocation="schema4.xsd" namespace="urn:pom"/>`)
+	require.Contains(t, read("schema1.xsd"), `encoding="ISO-8859-1"`)
+	require.Contains(t, read("schema2.xsd"), `<xs:include schemaLocation="schema3.xsd"/>`)
+	require.Equal(t, map[string]string{
+		filepath.Join(dir, "local.xsd"):                   "schema1.xsd",
+		filepath.Join(dir, "xsd", "spring", "beans.xsd"):  "schema2.xsd",
+		filepath.Join(dir, "xsd", "spring", "common.xsd"): "schema3.xsd",
+		filepath.Join(dir, "xsd", "pom.xsd"):              "schema4.xsd",
+	}, bundle.names)
+
+	err = bundle.restoreLocations(fmt.Errorf("%s:3: element decl invalid", filepath.Join(bundle.dir, "schema2.xsd")))
+	require.EqualError(t, err, filepath.Join(dir, "xsd", "spring", "beans.xsd")+":3: element decl invalid")
+}
+
+func Test_XSDBundleFetchesRemoteSchemas(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xsd/main.xsd":
+			fmt.Fprint(w, `<xs:schema xmlns:xs="`+xsNamespace+`"><xs:include schemaLocation="types.xsd"/></xs:schema>`)
+		case "/xsd/types.xsd":
+			fmt.Fprint(w, `<xs:schema xmlns:xs="`+xsNamespace+`"/>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	main := []xsdLocation{{Namespace: "urn:a", Location: server.URL + "/xsd/main.xsd"}}
+	bundle := &xsdBundle{dir: t.TempDir(), names: make(map[string]string)}
+	_, err := bundle.write(main)
+	require.ErrorContains(t, err, "is remote; map it to a local file with an XML catalog or set remote-schemas")
+
+	bundle = &xsdBundle{dir: t.TempDir(), remote: true, names: make(map[string]string)}
+	_, err = bundle.write(main)
+	require.NoError(t, err)
+	require.Equal(t, "schema2.xsd", bundle.names[server.URL+"/xsd/types.xsd"])
+
+	bundle = &xsdBundle{dir: t.TempDir(), remote: true, names: make(map[string]string)}
+	_, err = bundle.write([]xsdLocation{{Location: server.URL + "/missing.xsd"}})
+	require.ErrorContains(t, err, "HTTP 404")
+
+	server.Close()
+	bundle = &xsdBundle{dir: t.TempDir(), remote: true, names: make(map[string]string)}
+	_, err = bundle.write(main)
+	require.NoError(t, err, "downloaded schemas are cached")
+	require.FileExists(t, xmlSchemaCachePath(server.URL+"/xsd/types.xsd"))
+}
+
+func Test_XMLValidateSchemaRemoteLocationIgnored(t *testing.T) {
+	t.Parallel()
+	doc := `<project xmlns="http://maven.apache.org/POM/4.0.0"
+         xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+         xsi:schemaLocation="http://maven.apache.org/POM/4.0.0 https://maven.apache.org/xsd/maven-4.0.0.xsd"/>`
+	valid, err := XMLValidator{}.ValidateSchema([]byte(doc), "pom.xml")
+	require.ErrorIs(t, err, ErrNoSchema, "remote schemas are not downloaded unless allowed")
+	require.True(t, valid)
+	require.Empty(t, XMLValidator{}.SchemaLocation([]byte(doc), "pom.xml"))
+}
+
+func Test_XMLValidateSchemaLocationWithCatalog(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	v := XMLValidator{Catalog: writeTestCatalog(t, dir)}
+	doc := `<?xml version="1.0"?>
+<project xmlns="urn:pom"
+         xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+         xsi:schemaLocation="urn:pom https://example.com/pom.xsd">4.0.0</project>`
+	valid, err := v.ValidateSchema([]byte(doc), filepath.Join(dir, "pom.xml"))
+	require.NoError(t, err)
+	require.True(t, valid)
+	require.Equal(t, filepath.Join(dir, "xsd", "pom.xsd"), v.SchemaLocation([]byte(doc), filepath.Join(dir, "pom.xml")))
+
+	doc = `<project xmlns="urn:pom" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"
+         xsi:schemaLocation="urn:pom pom.xsd urn:other other.xsd">4.0.0</project>`
+	valid, err = v.ValidateSchema([]byte(doc), filepath.Join(dir, "pom.xml"))
+	require.False(t, valid)
+	require.ErrorContains(t, err, "schema compilation error")
+	require.ErrorContains(t, err, "other.xsd")
+
+	_, err = XMLValidator{Catalog: filepath.Join(dir, "missing.xml")}.ValidateSchema([]byte(doc), filepath.Join(dir, "pom.xml"))
+	require.ErrorContains(t, err, "reading XML catalog")
+}
+
 // --- XML DTD validation tests ---
 
 func Test_XMLDTDValid(t *testing.T) {
@@ -1211,6 +1679,396 @@ func Test_SchemaErrorPositionZeroWhenNoMatch(t *testing.T) {
 	require.NotEmpty(t, se.Positions)
 }
 
+func Test_TOMLSchemaErrorPositions(t *testing.T) {
+	t.Parallel()
+	schema := writeTestSchema(t)
+	doc := "\"$schema\" = \"schema.json\"\nhost = \"db.example.com\"\nport = \"not_a_number\"\ndatabase = \"mydb\"\n"
+	valid, err := TomlValidator{}.ValidateSchema([]byte(doc), filepath.Join(filepath.Dir(schema), "config.toml"))
+	require.False(t, valid)
+
+	var se *SchemaErrors
+	require.ErrorAs(t, err, &se)
+	require.Equal(t, []SchemaErrorPosition{{Line: 3, Column: 1}}, se.Positions)
+}
+
+func Test_TOONSchemaErrorPositions(t *testing.T) {
+	t.Parallel()
+	schema := writeTestSchema(t)
+	doc := "\"$schema\": schema.json\nhost: db.example.com\nport: not_a_number\ndatabase: mydb\n"
+	valid, err := ToonValidator{}.ValidateSchema([]byte(doc), filepath.Join(filepath.Dir(schema), "config.toon"))
+	require.False(t, valid)
+
+	var se *SchemaErrors
+	require.ErrorAs(t, err, &se)
+	require.Equal(t, []SchemaErrorPosition{{Line: 3, Column: 1}}, se.Positions)
+}
+
+func Test_JSONCSchemaErrorPositions(t *testing.T) {
+	t.Parallel()
+	schema := writeTestSchema(t)
+	doc := "{\n  // database settings\n  \"$schema\": \"schema.json\",\n  \"host\": \"db.example.com\",\n  /* port */ \"port\": \"not_a_number\",\n  \"database\": \"mydb\",\n}"
+	valid, err := JSONCValidator{}.ValidateSchema([]byte(doc), filepath.Join(filepath.Dir(schema), "config.jsonc"))
+	require.False(t, valid)
+
+	var se *SchemaErrors
+	require.ErrorAs(t, err, &se)
+	require.Equal(t, []SchemaErrorPosition{{Line: 5, Column: 14}}, se.Positions)
+}
+
+func Test_SchemaErrorPositionFallsBackToAncestor(t *testing.T) {
+	t.Parallel()
+	schemaURL := writeSchemaFile(t, `{"properties": {"ports": {"items": {"type": "integer"}}}}`)
+	doc := []byte("ports = [80, \"http\"]\n")
+	docJSON, err := TomlValidator{}.MarshalToJSON(doc)
+	require.NoError(t, err)
+
+	posMap := TomlValidator{}.PositionMap(doc)
+	delete(posMap, "(root).ports.1")
+	_, err = JSONSchemaValidateWithPositions(schemaURL, docJSON, posMap)
+	var se *SchemaErrors
+	require.ErrorAs(t, err, &se)
+	require.Equal(t, []SchemaErrorPosition{{Line: 1, Column: 1}}, se.Positions)
+}
+
+func Test_cleanXSDError(t *testing.T) {
+	t.Parallel()
+	msg, pos := cleanXSDError("(string):4: Schemas validity error : Element 'port': 'x' is not a valid value.\n")
+	require.Equal(t, "Element 'port': 'x' is not a valid value.", msg)
+	require.Equal(t, SchemaErrorPosition{Line: 4}, pos)
+
+	msg, pos = cleanXSDError("validation failed")
+	require.Equal(t, "validation failed", msg)
+	require.Equal(t, SchemaErrorPosition{}, pos)
+}
+
+func Test_PositionMaps(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		pm   PositionMapper
+		src  string
+		want map[string]SourcePosition
+	}{
+		{"toml", TomlValidator{}, "name = \"a\"\nports = [80, 443]\nlimits = { cpu = 2 }\n\n[server]\nhost = \"h\"\n\n[[rule]]\nid = 1\n\n[[rule]]\nid = 2\n[rule.match]\npath = \"/\"\n", map[string]SourcePosition{
+			"(root).name":              {1, 1},
+			"(root).ports.1":           {2, 14},
+			"(root).limits.cpu":        {3, 12},
+			"(root).server":            {5, 2},
+			"(root).server.host":       {6, 1},
+			"(root).rule.0":            {8, 3},
+			"(root).rule.1.id":         {12, 1},
+			"(root).rule.1.match.path": {14, 1},
+		}},
+		{"toon", ToonValidator{}, "name: app\nserver:\n  host: h\ntags[2]: a,b\nusers[2]{id,name}:\n  1,ann\n  2,bob\nitems[2]:\n  - id: 1\n    qty: 2\n  - x\n", map[string]SourcePosition{
+			"(root).name":         {1, 1},
+			"(root).server.host":  {3, 3},
+			"(root).tags":         {4, 1},
+			"(root).users.1":      {7, 3},
+			"(root).users.1.name": {7, 3},
+			"(root).items.0.id":   {9, 5},
+			"(root).items.0.qty":  {10, 5},
+			"(root).items.1":      {11, 3},
+		}},
+		{"ini", IniValidator{}, "name = app\n\n[server]\n  port = 80\nport = 81\n", map[string]SourcePosition{
+			"(root).name":        {1, 1},
+			"(root).server":      {3, 1},
+			"(root).server.port": {5, 1},
+		}},
+		{"env", EnvValidator{}, "# app\nexport PORT=80\n  NAME=\"x\"\n", map[string]SourcePosition{
+			"(root).PORT": {2, 8},
+			"(root).NAME": {3, 3},
+		}},
+		{"properties", PropValidator{}, "# c\nserver.port=80\nlong = a \\\n  b=c\nserver.host : h\n", map[string]SourcePosition{
+			"(root).server":      {2, 1},
+			"(root).server.port": {2, 1},
+			"(root).long":        {3, 1},
+			"(root).server.host": {5, 1},
+		}},
+		{"hcl", HclValidator{}, "region = \"eu\"\ntags = { env = \"prod\", ids = [1, 2] }\n\nresource \"bucket\" \"logs\" {\n  name = \"a\"\n}\nresource \"bucket\" \"logs\" {\n  name = \"b\"\n}\n", map[string]SourcePosition{
+			"(root).region":                      {1, 1},
+			"(root).tags.ids.1":                  {2, 34},
+			"(root).resource.bucket.logs":        {4, 19},
+			"(root).resource.bucket.logs.1.name": {8, 3},
+		}},
+		{"plist", PlistValidator{}, "<plist version=\"1.0\">\n<dict>\n  <key>Name</key>\n  <string>a</string>\n  <key>Items</key>\n  <array>\n    <integer>1</integer>\n    <dict><key>SSID</key><string>x</string></dict>\n  </array>\n</dict>\n</plist>\n", map[string]SourcePosition{
+			"(root)":              {2, 1},
+			"(root).Name":         {3, 3},
+			"(root).Items.0":      {7, 5},
+			"(root).Items.1.SSID": {8, 11},
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got := tc.pm.PositionMap([]byte(tc.src))
+			for path, want := range tc.want {
+				require.Equal(t, want, got[path], path)
+			}
+		})
+	}
+}
+
+func Test_JSONSchemaCacheReusesCompiledSchema(t *testing.T) {
+	t.Parallel()
+	schemaURL := tools.FileURL(writeTestSchema(t))
+	doc := []byte(`{"host": "db.example.com", "port": 5432, "database": "mydb"}`)
+
+	valid, err := JSONSchemaValidate(schemaURL, doc)
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	_, err = jsonSchemaCache.get(schemaURL, func() (jsonSchema, error) {
+		t.Fatal("schema was compiled twice")
+		return nil, nil
+	})
+	require.NoError(t, err)
+}
+
+func Test_JSONSchemaCacheRecompilesEditedSchema(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(schema, []byte(`{"required": ["name"]}`), 0600))
+	schemaURL := tools.FileURL(schema)
+
+	valid, err := JSONSchemaValidate(schemaURL, []byte(`{"name": "x"}`))
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	require.NoError(t, os.WriteFile(schema, []byte(`{"required": ["other"]}`), 0600))
+
+	valid, err = JSONSchemaValidate(schemaURL, []byte(`{"name": "x"}`))
+	require.False(t, valid)
+	require.ErrorContains(t, err, "other is required")
+}
+
+func Test_SchemaCacheEvictsEditedSchema(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.json")
+	require.NoError(t, os.WriteFile(schema, []byte(`{}`), 0600))
+	var cache schemaCache[int]
+
+	compiled, err := cache.get(schema, func() (int, error) { return 1, nil })
+	require.NoError(t, err)
+	require.Equal(t, 1, compiled)
+	require.Len(t, cache.entries, 1)
+
+	require.NoError(t, os.WriteFile(schema, []byte(`{"type": "object"}`), 0600))
+	compiled, err = cache.get(schema, func() (int, error) { return 2, nil })
+	require.NoError(t, err)
+	require.Equal(t, 2, compiled)
+	require.Len(t, cache.entries, 1, "the entry for the earlier version is dropped")
+}
+
+func Test_JSONSchemaCacheDoesNotCacheErrors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.json")
+	schemaURL := tools.FileURL(schema)
+
+	_, err := JSONSchemaValidate(schemaURL, []byte(`{}`))
+	require.ErrorContains(t, err, "schema validation error")
+
+	require.NoError(t, os.WriteFile(schema, []byte(`{"type": "object"}`), 0600))
+
+	valid, err := JSONSchemaValidate(schemaURL, []byte(`{}`))
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func Test_JSONSchemaCacheConcurrentUse(t *testing.T) {
+	t.Parallel()
+	schemaURL := tools.FileURL(writeTestSchema(t))
+	good := []byte(`{"host": "db.example.com", "port": 5432, "database": "mydb"}`)
+	bad := []byte(`{"host": "db.example.com", "port": "x", "database": "mydb"}`)
+
+	var wg sync.WaitGroup
+	for i := range 32 {
+		wg.Go(func() {
+			if i%2 == 0 {
+				valid, err := JSONSchemaValidate(schemaURL, good)
+				assert.NoError(t, err)
+				assert.True(t, valid)
+				return
+			}
+			valid, err := JSONSchemaValidate(schemaURL, bad)
+			assert.Error(t, err)
+			assert.False(t, valid)
+		})
+	}
+	wg.Wait()
+}
+
+func writeSchemaFile(t *testing.T, schema string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(p, []byte(schema), 0600))
+	return tools.FileURL(p)
+}
+
+func Test_JSONSchemaDraft2020PrefixItems(t *testing.T) {
+	t.Parallel()
+	schemaURL := writeSchemaFile(t, `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"properties": {
+		"point": {
+			"type": "array",
+			"prefixItems": [{ "type": "number" }, { "type": "number" }],
+			"items": false
+		}
+	}
+}`)
+
+	valid, err := JSONSchemaValidate(schemaURL, []byte(`{"point": [1, 2]}`))
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	valid, err = JSONSchemaValidate(schemaURL, []byte(`{"point": ["x", 2, 3]}`))
+	require.False(t, valid)
+	var se *SchemaErrors
+	require.ErrorAs(t, err, &se)
+	require.Len(t, se.Items, 2)
+	require.Equal(t, "point.0: got string, want number", se.Items[0])
+	require.Contains(t, se.Items[1], "not allowed by the schema")
+}
+
+func Test_JSONSchemaDraft2020UnevaluatedProperties(t *testing.T) {
+	t.Parallel()
+	schemaURL := writeSchemaFile(t, `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"$defs": {
+		"named": { "properties": { "name": { "type": "string" } } }
+	},
+	"allOf": [{ "$ref": "#/$defs/named" }],
+	"properties": { "port": { "type": "integer" } },
+	"unevaluatedProperties": false
+}`)
+
+	valid, err := JSONSchemaValidate(schemaURL, []byte(`{"name": "db", "port": 5432}`))
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	valid, err = JSONSchemaValidate(schemaURL, []byte(`{"name": "db", "port": 5432, "extra": true}`))
+	require.False(t, valid)
+	require.ErrorContains(t, err, "extra: not allowed by the schema")
+}
+
+func Test_JSONSchemaDraft2020DynamicRef(t *testing.T) {
+	t.Parallel()
+	schemaURL := writeSchemaFile(t, `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"$id": "https://example.com/tree",
+	"$dynamicAnchor": "node",
+	"type": "object",
+	"properties": {
+		"value": { "type": "integer" },
+		"children": { "type": "array", "items": { "$dynamicRef": "#node" } }
+	}
+}`)
+
+	valid, err := JSONSchemaValidate(schemaURL, []byte(`{"value": 1, "children": [{"value": 2}]}`))
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	valid, err = JSONSchemaValidate(schemaURL, []byte(`{"value": 1, "children": [{"value": "two"}]}`))
+	require.False(t, valid)
+	require.ErrorContains(t, err, "children.0.value")
+}
+
+func Test_JSONSchemaDraft2019DependentRequired(t *testing.T) {
+	t.Parallel()
+	schemaURL := writeSchemaFile(t, `{
+	"$schema": "https://json-schema.org/draft/2019-09/schema#",
+	"dependentRequired": { "tls": ["cert"] }
+}`)
+
+	valid, err := JSONSchemaValidate(schemaURL, []byte(`{"tls": true, "cert": "a.pem"}`))
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	valid, err = JSONSchemaValidate(schemaURL, []byte(`{"tls": true}`))
+	require.False(t, valid)
+	require.ErrorContains(t, err, "cert")
+}
+
+func Test_JSONSchemaDraft2020ErrorPositions(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "schema.json"), []byte(`{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"properties": { "port": { "type": "integer" } }
+}`), 0600))
+	doc := []byte("{\n  \"$schema\": \"schema.json\",\n  \"port\": \"x\"\n}")
+
+	valid, err := JSONValidator{}.ValidateSchema(doc, filepath.Join(dir, "config.json"))
+	require.False(t, valid)
+	var se *SchemaErrors
+	require.ErrorAs(t, err, &se)
+	require.Equal(t, []string{"port: got string, want integer"}, se.Items)
+	require.Equal(t, 3, se.Positions[0].Line)
+}
+
+func Test_JSONSchemaNewerKeywordWithoutDraft(t *testing.T) {
+	t.Parallel()
+	schemaURL := writeSchemaFile(t, `{
+	"properties": {
+		"point": { "prefixItems": [{ "type": "number" }] }
+	}
+}`)
+
+	valid, err := JSONSchemaValidate(schemaURL, []byte(`{"point": ["x"]}`))
+	require.False(t, valid)
+	require.ErrorContains(t, err, `keyword "prefixItems" at #/properties/point requires JSON Schema draft 2019-09 or later`)
+}
+
+func Test_JSONSchemaNewerKeywordAsPropertyName(t *testing.T) {
+	t.Parallel()
+	schemaURL := writeSchemaFile(t, `{
+	"$schema": "http://json-schema.org/draft-07/schema#",
+	"properties": { "prefixItems": { "type": "string" } }
+}`)
+
+	valid, err := JSONSchemaValidate(schemaURL, []byte(`{"prefixItems": "ok"}`))
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func Test_SchemaLocation(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "config")
+	schemaURL := tools.FileURL(filepath.Join(dir, "schema.json"))
+
+	tests := []struct {
+		name    string
+		locator SchemaLocator
+		content string
+		want    string
+	}{
+		{"json", JSONValidator{}, `{"$schema": "schema.json"}`, schemaURL},
+		{"json remote", JSONValidator{}, `{"$schema": "https://example.com/s.json"}`, "https://example.com/s.json"},
+		{"json none", JSONValidator{}, `{"a": 1}`, ""},
+		{"json not a string", JSONValidator{}, `{"$schema": 1}`, ""},
+		{"json array", JSONValidator{}, `[1]`, ""},
+		{"jsonc", JSONCValidator{}, "{\n  // comment\n  \"$schema\": \"schema.json\",\n}", schemaURL},
+		{"toml", TomlValidator{}, `"$schema" = "schema.json"`, schemaURL},
+		{"toon", ToonValidator{}, `"$schema": schema.json`, schemaURL},
+		{"yaml", YAMLValidator{}, "# yaml-language-server: $schema=schema.json\na: 1\n", schemaURL},
+		{"yaml none", YAMLValidator{}, "a: 1\n", ""},
+		{"xml", XMLValidator{}, `<root xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="schema.xsd"/>`, filepath.Join(dir, "schema.xsd")},
+		{"xml namespaces", XMLValidator{RemoteSchemas: true}, `<root xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="urn:a a.xsd urn:b https://example.com/b.xsd"/>`, filepath.Join(dir, "a.xsd") + " https://example.com/b.xsd"},
+		{"xml remote", XMLValidator{}, `<root xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="urn:a a.xsd urn:b https://example.com/b.xsd"/>`, filepath.Join(dir, "a.xsd")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, tt.locator.SchemaLocation([]byte(tt.content), filePath))
+		})
+	}
+}
+
 func Test_JustfileValidateSyntaxError(t *testing.T) {
 	t.Parallel()
 	valid, err := JustfileValidator{}.ValidateSyntax([]byte("name := \"unterminated\n"))
@@ -1230,9 +2088,563 @@ func Test_JustfileValidateSemanticError(t *testing.T) {
 	require.Contains(t, ve.Err.Error(), "undefined recipe")
 }
 
+func Test_JustfileDiagnostics(t *testing.T) {
+	t.Parallel()
+	content := []byte("set foo := true\n\ndefault:\n    echo hello\n")
+	valid, err := JustfileValidator{}.ValidateSyntax(content)
+	require.True(t, valid, "unknown settings are warnings, not errors")
+	require.NoError(t, err)
+
+	diags := JustfileValidator{}.Diagnostics(content)
+	require.Len(t, diags, 1)
+	require.Equal(t, SeverityWarning, diags[0].Severity)
+	require.Equal(t, "unknown setting 'foo'", diags[0].Message)
+	require.Equal(t, 1, diags[0].Line)
+}
+
 func Test_JustfileValidateValid(t *testing.T) {
 	t.Parallel()
 	valid, err := JustfileValidator{}.ValidateSyntax([]byte("# comment\ndefault:\n    echo hello\n"))
 	require.True(t, valid)
 	require.NoError(t, err)
 }
+
+func Test_RuleCatalogue(t *testing.T) {
+	t.Parallel()
+	require.True(t, slices.IsSortedFunc(Rules, func(a, b Rule) int { return strings.Compare(a.ID, b.ID) }))
+	for i, r := range Rules {
+		require.Regexp(t, `^[a-z]+/[a-z-]+$`, r.ID)
+		require.NotEmpty(t, r.Description, r.ID)
+		if i > 0 {
+			require.NotEqual(t, Rules[i-1].ID, r.ID, "duplicate rule ID")
+		}
+	}
+	for _, keyword := range legacySchemaKeywords {
+		require.NotEqual(t, RuleSchemaInvalid, schemaKeywordRule(keyword), keyword)
+	}
+
+	rule, ok := LookupRule("schema/required")
+	require.True(t, ok)
+	require.Equal(t, "schema/required", rule.ID)
+	_, ok = LookupRule("schema/nope")
+	require.False(t, ok)
+}
+
+func Test_MatchRule(t *testing.T) {
+	t.Parallel()
+	require.True(t, MatchRule("json/duplicate-key", "json/duplicate-key"))
+	require.False(t, MatchRule("json/duplicate-key", "json/syntax"))
+	require.True(t, MatchRule("schema/*", "schema/required"))
+	require.False(t, MatchRule("schema/*", "json/syntax"))
+	require.False(t, MatchRule("json/*", "jsonc/syntax"))
+}
+
+func Test_DuplicateKeyRules(t *testing.T) {
+	t.Parallel()
+	var ve *ValidationError
+	_, err := JSONValidator{ForbidDuplicateKeys: true}.ValidateSyntax([]byte(`{"key": 1, "key": 2}`))
+	require.ErrorAs(t, err, &ve)
+	require.Equal(t, RuleJSONDuplicateKey, ve.Rule)
+
+	_, err = IniValidator{ForbidDuplicateKeys: true}.ValidateSyntax([]byte("[section]\nkey=1\nkey=2\n"))
+	require.ErrorAs(t, err, &ve)
+	require.Equal(t, RuleINIDuplicateKey, ve.Rule)
+}
+
+func Test_JSONSchemaRules(t *testing.T) {
+	t.Parallel()
+	schema := writeTestSchema(t)
+	doc := `{"$schema": "schema.json", "port": "x", "extra": 1}`
+	_, err := JSONValidator{}.ValidateSchema([]byte(doc), filepath.Join(filepath.Dir(schema), "config.json"))
+	var se *SchemaErrors
+	require.ErrorAs(t, err, &se)
+	rules := make([]string, len(se.Items))
+	for i := range se.Items {
+		rules[i] = se.Rule(i)
+	}
+	require.ElementsMatch(t, []string{
+		"schema/required", "schema/required", "schema/type", "schema/additional-properties",
+	}, rules)
+}
+
+func Test_JSONSchemaDraft2020Rules(t *testing.T) {
+	t.Parallel()
+	schemaURL := writeSchemaFile(t, `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"required": ["name"],
+	"properties": {
+		"level": { "enum": ["low", "high"] },
+		"point": { "type": "array", "prefixItems": [{ "type": "number" }], "items": false }
+	}
+}`)
+
+	_, err := JSONSchemaValidate(schemaURL, []byte(`{"level": "mid", "point": [1, 2]}`))
+	var se *SchemaErrors
+	require.ErrorAs(t, err, &se)
+	require.ElementsMatch(t, []string{"schema/required", "schema/enum", RuleSchemaNotAllowed}, se.Rules)
+}
+
+func Test_JustfileRules(t *testing.T) {
+	t.Parallel()
+	_, err := JustfileValidator{}.ValidateSyntax([]byte("default:\n    echo {{x}}\n"))
+	var ve *ValidationError
+	require.ErrorAs(t, err, &ve)
+	require.Equal(t, "justfile/undefined-variable", ve.Rule)
+
+	diags := JustfileValidator{}.Diagnostics([]byte("set foo := true\n\ndefault:\n    echo hello\n"))
+	require.Len(t, diags, 1)
+	require.Equal(t, "justfile/unknown-setting", diags[0].Rule)
+}
+
+func Test_SyntaxErrors(t *testing.T) {
+	t.Parallel()
+	first := &ValidationError{Err: errors.New("first"), Line: 2, Column: 3}
+	second := &ValidationError{Err: errors.New("second"), Line: 5}
+	se := &SyntaxErrors{Items: []*ValidationError{first, second}}
+	require.Equal(t, "first; second", se.Error())
+
+	var ve *ValidationError
+	require.ErrorAs(t, se, &ve)
+	require.Same(t, first, ve)
+	require.ErrorIs(t, se, second)
+
+	require.NoError(t, syntaxErrors(nil))
+	require.Same(t, first, syntaxErrors([]*ValidationError{first}))
+}
+
+func Test_MultipleSyntaxErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		validator Validator
+		input     string
+		positions [][2]int
+	}{
+		{"hcl", HclValidator{}, "a = 1\na = 2\nb = [1,\nc = 3 4\n", [][2]int{{2, 1}, {4, 3}}},
+		{"cue", CueValidator{}, "a: [1 2]\nb: [3 4]\n", [][2]int{{1, 7}, {2, 7}}},
+		{"csv", CsvValidator{}, "a,b\n1,2,3\n4\n5,6\n", [][2]int{{2, 1}, {3, 1}}},
+		{"env", EnvValidator{}, "A=1\nB C\nD='x\nE=2\n", [][2]int{{2, 0}, {3, 0}}},
+		{"justfile", JustfileValidator{}, "a: b c\n\tx\n", [][2]int{{1, 4}, {1, 6}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			valid, err := tt.validator.ValidateSyntax([]byte(tt.input))
+			require.False(t, valid)
+			var se *SyntaxErrors
+			require.ErrorAs(t, err, &se)
+			var positions [][2]int
+			for _, item := range se.Items {
+				positions = append(positions, [2]int{item.Line, item.Column})
+			}
+			require.Equal(t, tt.positions, positions)
+		})
+	}
+}
+
+func Test_EnvSyntaxErrorsResume(t *testing.T) {
+	t.Parallel()
+	valid, err := EnvValidator{}.ValidateSyntax([]byte("# app\n=1\n\nA=1\n1B=2\nC=3\n"))
+	require.False(t, valid)
+	var se *SyntaxErrors
+	require.ErrorAs(t, err, &se)
+	require.Len(t, se.Items, 2)
+	require.Equal(t, 2, se.Items[0].Line)
+	require.ErrorIs(t, se.Items[0].Err, envparse.ErrEmptyKey)
+	require.Equal(t, 5, se.Items[1].Line)
+
+	// A line too long to scan ends the search.
+	_, err = EnvValidator{}.ValidateSyntax([]byte("=1\nA=1\nB=" + strings.Repeat("x", 70000) + "\n=2\n"))
+	require.ErrorAs(t, err, &se)
+	require.Len(t, se.Items, 2)
+	require.Equal(t, 3, se.Items[1].Line)
+	require.ErrorIs(t, se.Items[1].Err, bufio.ErrTooLong)
+}
+
+func Test_JustfileMultipleRules(t *testing.T) {
+	t.Parallel()
+	_, err := JustfileValidator{}.ValidateSyntax([]byte("a: b\n\tx\nc: c\n\ty\n"))
+	var se *SyntaxErrors
+	require.ErrorAs(t, err, &se)
+	require.Len(t, se.Items, 2)
+	require.Equal(t, "justfile/undefined-recipe", se.Items[0].Rule)
+	require.Equal(t, "justfile/circular-dependency", se.Items[1].Rule)
+}
+
+func Test_xmlSyntaxErrors(t *testing.T) {
+	t.Parallel()
+
+	joined := errors.Join(
+		errors.New("element a: not declared at line 2, column 3"),
+		errors.New("element b: not declared at line 4, column 1"),
+	)
+	var se *SyntaxErrors
+	require.ErrorAs(t, xmlSyntaxErrors(joined, RuleXMLDTD), &se)
+	require.Len(t, se.Items, 2)
+	require.Equal(t, "element a: not declared", se.Items[0].Error())
+	require.Equal(t, 4, se.Items[1].Line)
+	require.Equal(t, RuleXMLDTD, se.Items[1].Rule)
+
+	lines := errors.New("first at line 1, column 2\nsecond at line 3, column 4")
+	require.ErrorAs(t, xmlSyntaxErrors(lines, ""), &se)
+	require.Len(t, se.Items, 2)
+	require.Equal(t, 3, se.Items[1].Line)
+
+	var ve *ValidationError
+	single := errors.New("premature end of data at line 5, column 1\nsome context")
+	require.ErrorAs(t, xmlSyntaxErrors(single, ""), &ve)
+	require.Equal(t, 5, ve.Line)
+
+	plain := errors.New("no position")
+	require.Equal(t, plain, xmlSyntaxErrors(plain, ""))
+}
+
+func Test_findDoctype(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		doc      string
+		found    bool
+		want     xmlDoctype
+		external bool
+		self     bool
+	}{
+		{
+			name:     "system",
+			doc:      `<?xml version="1.0"?>` + "\n" + `<!DOCTYPE struts-config SYSTEM "struts.dtd"><struts-config/>`,
+			found:    true,
+			want:     xmlDoctype{start: 22, end: 66, root: "struts-config", systemID: "struts.dtd"},
+			external: true,
+		},
+		{
+			name:     "public",
+			doc:      `<!DOCTYPE book PUBLIC "-//OASIS//DTD DocBook XML V4.5//EN" 'docbook.dtd'><book/>`,
+			found:    true,
+			want:     xmlDoctype{end: 73, root: "book", publicID: "-//OASIS//DTD DocBook XML V4.5//EN", systemID: "docbook.dtd"},
+			external: true,
+		},
+		{
+			name:  "internal subset",
+			doc:   "<!DOCTYPE config [\n  <!ELEMENT config (#PCDATA)>\n]>\n<config/>",
+			found: true,
+			want:  xmlDoctype{end: 51, root: "config", internal: "\n  <!ELEMENT config (#PCDATA)>\n", hasInternal: true},
+			self:  true,
+		},
+		{
+			name:  "external entity",
+			doc:   `<!DOCTYPE config [<!ENTITY x SYSTEM "x.txt">]><config/>`,
+			found: true,
+			want:  xmlDoctype{end: 46, root: "config", internal: `<!ENTITY x SYSTEM "x.txt">`, hasInternal: true},
+		},
+		{name: "in comment", doc: `<!-- <!DOCTYPE config SYSTEM "a.dtd"> --><config/>`},
+		{name: "none", doc: `<config/>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := findDoctype([]byte(tt.doc))
+			require.Equal(t, tt.found, ok)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.external, got.external())
+			require.Equal(t, tt.self, got.selfContained())
+		})
+	}
+}
+
+func writeTestDTD(t *testing.T, dir string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "dtd", "mod"), 0o755))
+	writeTestFile(t, dir, "dtd/config.dtd", `<!ELEMENT config (name, port+)>
+<!ELEMENT name (#PCDATA)>
+<!ELEMENT port (#PCDATA)>
+`)
+	writeTestFile(t, dir, "dtd/mod/common.mod", `<!ELEMENT extra EMPTY>
+`)
+}
+
+func Test_dtdEntityLoader(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeTestDTD(t, dir)
+	catalog, err := loadXMLCatalog(writeTestCatalog(t, dir))
+	require.NoError(t, err)
+	dtd := filepath.Join(dir, "dtd", "config.dtd")
+	module := filepath.Join(dir, "dtd", "mod", "common.mod")
+
+	l := &dtdEntityLoader{catalog: catalog, dtd: dtd}
+	_, err = l.load(dtd, "")
+	require.NoError(t, err)
+	_, err = l.load("https://example.com/config.dtd", "-//Example//DTD Config 1.0//EN")
+	require.NoError(t, err, "the catalog maps the public identifier to the DTD")
+	_, err = l.load(module, "")
+	require.ErrorContains(t, err, "external entity "+module+" is not loaded; enable external-entities to load it")
+	_, err = l.load("https://example.com/other.dtd", "")
+	require.ErrorContains(t, err, "https://example.com/other.dtd is remote; map it to a local file with an XML catalog or enable external-entities to download it")
+	_, err = l.load("", "-//Example//DTD Unknown//EN")
+	require.ErrorContains(t, err, `no XML catalog entry for public identifier "-//Example//DTD Unknown//EN"`)
+
+	l = &dtdEntityLoader{catalog: catalog, dtd: dtd, external: true}
+	data, err := l.load(module, "")
+	require.NoError(t, err)
+	require.Equal(t, "<!ELEMENT extra EMPTY>\n", string(data))
+
+	l = &dtdEntityLoader{dtd: dtd}
+	_, err = l.LoadEntity(context.Background(), module, "")
+	require.Error(t, err)
+	_, err = l.LoadEntity(context.Background(), "https://example.com/other.dtd", "")
+	require.Error(t, err)
+	require.ErrorContains(t, l.err, "external entity "+module, "the first refusal is kept")
+}
+
+func Test_withDoctype(t *testing.T) {
+	t.Parallel()
+
+	b := []byte("<?xml version=\"1.0\"?>\n<!DOCTYPE app SYSTEM\n  \"app.dtd\" [\n<!ENTITY a \"b\">\n]>  <app>\n</app>")
+	d, ok := findDoctype(b)
+	require.True(t, ok)
+	d.systemID = "file:///schemas/app.dtd"
+	doc, shift := withDoctype(b, d)
+	require.Equal(t, "<?xml version=\"1.0\"?>\n<!DOCTYPE app SYSTEM \"file:///schemas/app.dtd\" [ <!ENTITY a \"b\"> ]>\n\n\n    <app>\n</app>", string(doc))
+	require.Equal(t, SchemaErrorPosition{Line: 5, Column: 5}, shift.apply(5, 5))
+
+	b = []byte("<!-- x --><app/>")
+	d, ok = rootDoctype(b)
+	require.True(t, ok)
+	d.systemID = "file:///app.dtd"
+	doc, shift = withDoctype(b, d)
+	require.Equal(t, "<!-- x --><!DOCTYPE app SYSTEM \"file:///app.dtd\"><app/>", string(doc))
+	require.Equal(t, SchemaErrorPosition{Line: 1, Column: 11}, shift.apply(1, 50))
+	require.Equal(t, SchemaErrorPosition{Line: 1, Column: 5}, shift.apply(1, 5))
+}
+
+func Test_XMLValidateSchemaDTD(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeTestDTD(t, dir)
+	catalog := writeTestCatalog(t, dir)
+	doc := filepath.Join(dir, "config.xml")
+	public := []byte(`<!DOCTYPE config PUBLIC "-//Example//DTD Config 1.0//EN" "https://example.com/config.dtd"><config><name>a</name><port>1</port></config>`)
+
+	valid, err := XMLValidator{}.ValidateSchema(public, doc)
+	require.True(t, valid)
+	require.ErrorIs(t, err, ErrNoSchema, "external DTDs are opt-in")
+
+	_, err = XMLValidator{DTD: true}.ValidateSchema(public, doc)
+	require.ErrorContains(t, err, "https://example.com/config.dtd is remote; map it to a local file with an XML catalog or enable external-entities")
+
+	valid, err = XMLValidator{DTD: true, Catalog: catalog}.ValidateSchema(public, doc)
+	require.NoError(t, err)
+	require.True(t, valid)
+	require.Equal(t, filepath.Join(dir, "dtd", "config.dtd"), XMLValidator{DTD: true, Catalog: catalog}.SchemaLocation(public, doc))
+	require.Empty(t, XMLValidator{Catalog: catalog}.SchemaLocation(public, doc))
+
+	local := []byte(`<!DOCTYPE config SYSTEM "dtd/config.dtd"><config><name>a</name><port>1</port></config>`)
+	valid, err = XMLValidator{DTD: true}.ValidateSchema(local, doc)
+	require.NoError(t, err)
+	require.True(t, valid)
+}
+
+func writeTestRelaxNG(t *testing.T, dir string) string {
+	t.Helper()
+	writeTestFile(t, dir, "config.rng", `<?xml version="1.0"?>
+<grammar xmlns="http://relaxng.org/ns/structure/1.0" datatypeLibrary="http://www.w3.org/2001/XMLSchema-datatypes">
+  <include href="common.rng">
+    <define name="name"><element name="name"><data type="NCName"/></element></define>
+  </include>
+  <start><ref name="config"/></start>
+  <define name="config">
+    <element name="config">
+      <attribute name="version"><choice><value>1</value><value>2</value></choice></attribute>
+      <optional><attribute name="env"/></optional>
+      <ref name="name"/>
+      <oneOrMore><ref name="server"/></oneOrMore>
+    </element>
+  </define>
+  <define name="server">
+    <element name="server">
+      <attribute name="host"><data type="token"><param name="pattern">[a-z.]+</param></data></attribute>
+      <element name="port"><data type="int"><param name="minInclusive">1</param><param name="maxInclusive">65535</param></data></element>
+      <interleave>
+        <optional><element name="tls"><data type="boolean"/></element></optional>
+        <zeroOrMore><element name="alias"><list><oneOrMore><data type="NCName"/></oneOrMore></list></element></zeroOrMore>
+      </interleave>
+      <zeroOrMore><ref name="server"/></zeroOrMore>
+    </element>
+  </define>
+</grammar>
+`)
+	writeTestFile(t, dir, "common.rng", `<grammar xmlns="http://relaxng.org/ns/structure/1.0">
+  <define name="name"><element name="name"><text/></element></define>
+  <define name="server" combine="choice"><element name="proxy"><empty/></element></define>
+</grammar>
+`)
+	return filepath.Join(dir, "config.rng")
+}
+
+func Test_ValidateRelaxNG(t *testing.T) {
+	t.Parallel()
+	schema := writeTestRelaxNG(t, t.TempDir())
+
+	tests := []struct {
+		name      string
+		doc       string
+		errs      []string
+		positions []SchemaErrorPosition
+	}{
+		{
+			name: "valid",
+			doc: `<config version="1">
+  <name>app</name>
+  <server host="a.example"><port>80</port><alias>www api</alias><tls>true</tls>
+    <server host="b.example"><port>81</port></server>
+  </server>
+  <proxy/>
+</config>`,
+		},
+		{
+			name: "invalid",
+			doc: `<config version="3" debug="true">
+  <name>my app</name>
+  <server><port>0</port><tls>maybe</tls></server>
+  <client/>
+</config>`,
+			errs: []string{
+				`attribute "version" of element "config" has invalid value "3"`,
+				`attribute "debug" is not allowed on element "config"`,
+				`element "name" has invalid value "my app"`,
+				`element "server" is missing required attribute "host"`,
+				`element "port" has invalid value "0"`,
+				`element "tls" has invalid value "maybe"`,
+				`element "client" is not allowed here; expected one of "proxy", "server"`,
+			},
+			positions: []SchemaErrorPosition{{Line: 1, Column: 1}, {Line: 1, Column: 1}, {Line: 2, Column: 9}, {Line: 3, Column: 3}, {Line: 3, Column: 17}, {Line: 3, Column: 30}, {Line: 4, Column: 3}},
+		},
+		{
+			name:      "incomplete",
+			doc:       "<config version=\"2\">\n  <name>app</name>\n</config>",
+			errs:      []string{`element "config" is incomplete; expected one of "proxy", "server"`},
+			positions: []SchemaErrorPosition{{Line: 3, Column: 1}},
+		},
+		{
+			name:      "text",
+			doc:       `<config version="2"><name>app</name><proxy/>stray</config>`,
+			errs:      []string{`text is not allowed in element "config"`},
+			positions: []SchemaErrorPosition{{Line: 1, Column: 45}},
+		},
+		{
+			name:      "root",
+			doc:       `<settings/>`,
+			errs:      []string{`element "settings" is not allowed here; expected "config"`},
+			positions: []SchemaErrorPosition{{Line: 1, Column: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			valid, err := ValidateRelaxNG([]byte(tt.doc), schema)
+			if tt.errs == nil {
+				require.NoError(t, err)
+				require.True(t, valid)
+				return
+			}
+			require.False(t, valid)
+			var se *SchemaErrors
+			require.ErrorAs(t, err, &se)
+			require.Equal(t, tt.errs, se.Items)
+			require.Equal(t, tt.positions, se.Positions)
+			require.Equal(t, RuleXMLRelaxNG, se.Rule(0))
+		})
+	}
+}
+
+func Test_ValidateRelaxNGNamespaces(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeTestFile(t, dir, "ns.rng", `<element name="c:doc" xmlns="http://relaxng.org/ns/structure/1.0" xmlns:c="urn:cfg" ns="urn:cfg">
+  <attribute name="c:lang"/>
+  <zeroOrMore><element name="item"><empty/></element></zeroOrMore>
+  <zeroOrMore><element><anyName><except><nsName/></except></anyName><empty/></element></zeroOrMore>
+</element>`)
+	schema := filepath.Join(dir, "ns.rng")
+
+	valid, err := ValidateRelaxNG([]byte(`<doc xmlns="urn:cfg" xmlns:c="urn:cfg" c:lang="en"><item/><x:ext xmlns:x="urn:ext"/></doc>`), schema)
+	require.NoError(t, err)
+	require.True(t, valid)
+
+	_, err = ValidateRelaxNG([]byte(`<doc xmlns="urn:cfg" lang="en"><item/><other xmlns="urn:cfg"/></doc>`), schema)
+	require.ErrorContains(t, err, `attribute "lang" is not allowed on element "doc"`)
+	require.ErrorContains(t, err, `element "other" is not allowed here`)
+}
+
+func Test_ValidateRelaxNGCompileErrors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{`<grammar xmlns="http://relaxng.org/ns/structure/1.0"><start><ref name="a"/></start><define name="a"><ref name="a"/></define></grammar>`, `pattern "a" refers to itself outside an element`},
+		{`<grammar xmlns="http://relaxng.org/ns/structure/1.0"><start><ref name="b"/></start></grammar>`, `reference to undefined pattern "b"`},
+		{`<grammar xmlns="http://relaxng.org/ns/structure/1.0"><start><empty/></start><start><empty/></start></grammar>`, "more than one start without combine"},
+		{`<element name="a" xmlns="http://relaxng.org/ns/structure/1.0"><data type="int"/></element>`, `unknown datatype "int"`},
+		{`<element name="a" xmlns="http://relaxng.org/ns/structure/1.0" datatypeLibrary="http://www.w3.org/2001/XMLSchema-datatypes"><data type="string"><param name="minInclusive">1</param></data></element>`, `parameter "minInclusive" does not apply to string`},
+		{`<element name="x:a" xmlns="http://relaxng.org/ns/structure/1.0"><empty/></element>`, `undeclared namespace prefix "x"`},
+		{`<schema/>`, "not a RELAX NG schema"},
+	}
+	for i, tt := range tests {
+		name := fmt.Sprintf("schema%d.rng", i)
+		writeTestFile(t, dir, name, tt.schema)
+		_, err := ValidateRelaxNG([]byte(`<a/>`), filepath.Join(dir, name))
+		require.ErrorContains(t, err, "schema compilation error: ")
+		require.ErrorContains(t, err, tt.want)
+	}
+}
+
+func Test_XSDDatatypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		typ     string
+		params  []rngParam
+		valid   []string
+		invalid []string
+	}{
+		{typ: "token", params: []rngParam{{"maxLength", "5"}}, valid: []string{"  a  b ", "abcde"}, invalid: []string{"abcdef"}},
+		{typ: "NCName", valid: []string{"a-b.c", "_x"}, invalid: []string{"a:b", "1a", ""}},
+		{typ: "boolean", valid: []string{"true", " 0 "}, invalid: []string{"yes", "True"}},
+		{typ: "decimal", params: []rngParam{{"totalDigits", "4"}, {"fractionDigits", "2"}}, valid: []string{"12.34", "-0012.30", ".5"}, invalid: []string{"123.45", "1.234", "1e2"}},
+		{typ: "unsignedByte", valid: []string{"0", "+255"}, invalid: []string{"256", "-1", "1.0"}},
+		{typ: "long", valid: []string{"-9223372036854775808"}, invalid: []string{"9223372036854775808"}},
+		{typ: "double", params: []rngParam{{"minExclusive", "0"}}, valid: []string{"1e-3", "INF"}, invalid: []string{"0", "-INF", "NaN", "abc"}},
+		{typ: "date", params: []rngParam{{"maxInclusive", "2030-12-31"}}, valid: []string{"2024-02-29", "2030-12-31Z"}, invalid: []string{"2031-01-01", "2024-13-01", "24-01-01"}},
+		{typ: "dateTime", valid: []string{"2024-01-02T03:04:05.5+01:00"}, invalid: []string{"2024-01-02 03:04:05"}},
+		{typ: "duration", valid: []string{"P1Y2M", "PT1.5S", "-P3D"}, invalid: []string{"P", "PT", "1D"}},
+		{typ: "hexBinary", params: []rngParam{{"length", "2"}}, valid: []string{"0aFF"}, invalid: []string{"0a", "0g0a"}},
+		{typ: "base64Binary", params: []rngParam{{"minLength", "3"}}, valid: []string{"YWJj"}, invalid: []string{"YWI=", "***"}},
+		{typ: "NMTOKENS", params: []rngParam{{"maxLength", "2"}}, valid: []string{"a b"}, invalid: []string{"a b c", "  "}},
+		{typ: "string", params: []rngParam{{"pattern", `\i\c*\.[a-z]{2,3}`}}, valid: []string{"site.com"}, invalid: []string{"9site.com", "site.c"}},
+	}
+	for _, tt := range tests {
+		dt, err := rngDatatypeFor(xsdDatatypes, tt.typ, tt.params)
+		require.NoError(t, err, tt.typ)
+		for _, s := range tt.valid {
+			require.True(t, dt.allows(s), "%s %q", tt.typ, s)
+		}
+		for _, s := range tt.invalid {
+			require.False(t, dt.allows(s), "%s %q", tt.typ, s)
+		}
+	}
+
+	dt, err := rngDatatypeFor(xsdDatatypes, "decimal", nil)
+	require.NoError(t, err)
+	require.True(t, dt.equal("1.50", "+1.5"))
+	dt, err = rngDatatypeFor("", "token", nil)
+	require.NoError(t, err)
+	require.True(t, dt.equal(" a  b", "a b"))
+
+	_, err = rngDatatypeFor(xsdDatatypes, "string", []rngParam{{"pattern", "[a-z-[aeiou]]"}})
+	require.ErrorContains(t, err, "character class subtraction is not supported")
+}
@@ -13,6 +13,8 @@ import (
 
 	"github.com/lestrrat-go/helium"
 	"github.com/lestrrat-go/helium/xsd"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/tools"
 )
 
 var (
@@ -21,94 +23,194 @@ var (
 	xsdErrorLineRe   = regexp.MustCompile(`^\(string\):(\d+): Schemas validity error : (.+)`)
 )
 
-type XMLValidator struct{}
+// XMLValidator validates XML syntax, DTDs, XSD schemas and RELAX NG
+// schemas. Catalog is the path of an OASIS XML catalog that maps namespace
+// URIs, schema locations and DTD identifiers to local schemas; it may be
+// empty. DTD turns on validation against the external DTD a DOCTYPE names;
+// a DOCTYPE with only an internal subset is always validated. The parser
+// only loads an external DTD through an entity loader that refuses remote
+// DTDs and other external entities unless ExternalEntities is set. Remote XML
+// Schemas are only downloaded when RemoteSchemas is set; until then a
+// document's remote schema locations that the catalog does not map are
+// ignored.
+type XMLValidator struct {
+	Catalog          string
+	DTD              bool
+	ExternalEntities bool
+	RemoteSchemas    bool
+}
 
 var _ Validator = XMLValidator{}
 
-// ValidateXSD satisfies the XMLSchemaValidator marker interface.
-func (XMLValidator) ValidateXSD(b []byte, schemaPath string) (bool, error) {
-	return ValidateXSD(b, schemaPath)
-}
-
-// hasDOCTYPE reports whether b contains an XML DOCTYPE declaration.
-// It walks tokens rather than doing a plain substring scan, so a
-// "<!DOCTYPE" that only appears inside a comment does not count as a
-// real declaration. The XML spec requires a DOCTYPE to appear before
-// the root element, so scanning stops as soon as the root start
-// element is reached.
-func hasDOCTYPE(b []byte) bool {
-	decoder := xml.NewDecoder(bytes.NewReader(b))
-	for {
-		token, err := decoder.Token()
-		if err != nil {
-			return false
-		}
-		if directive, ok := token.(xml.Directive); ok && bytes.HasPrefix(bytes.TrimSpace(directive), []byte("DOCTYPE")) {
-			return true
-		}
-		if _, ok := token.(xml.StartElement); ok {
-			return false
-		}
+// ValidateXSD satisfies the XMLSchemaValidator marker interface. The
+// catalog, if any, resolves the schemas schemaPath imports and includes.
+func (v XMLValidator) ValidateXSD(b []byte, schemaPath string) (bool, error) {
+	schema, err := v.compileXSD([]xsdLocation{{Location: schemaPath}})
+	if err != nil {
+		return false, err
 	}
+	return validateXSDDocument(b, schema)
 }
 
 func (XMLValidator) ValidateSyntax(b []byte) (bool, error) {
 	ctx := context.Background()
 	parser := helium.NewParser()
-	if hasDOCTYPE(b) {
+	// Only a self-contained DOCTYPE is validated here: anything that would
+	// load a file or a URL waits for ValidateSchema and the DTD option.
+	doctype, ok := findDoctype(b)
+	dtd := ok && doctype.selfContained()
+	if dtd {
 		parser = parser.ValidateDTD(true)
 	}
 	_, err := parser.Parse(ctx, b)
 	if err != nil {
-		errMsg := err.Error()
-		if m := xmlLineColRe.FindStringSubmatch(errMsg); m != nil {
-			line, _ := strconv.Atoi(m[1])
-			col, _ := strconv.Atoi(m[2])
-			cleanMsg := xmlStripPosition.ReplaceAllString(errMsg, "")
-			return false, &ValidationError{Err: fmt.Errorf("%s", strings.TrimSpace(cleanMsg)), Line: line, Column: col}
+		rule := ""
+		if dtd {
+			// The document is well-formed if it parses without DTD
+			// validation, so the error is a DTD violation.
+			if _, wfErr := helium.NewParser().Parse(ctx, b); wfErr == nil {
+				rule = RuleXMLDTD
+			}
 		}
-		return false, err
+		return false, xmlSyntaxErrors(err, rule)
 	}
 	return true, nil
 }
 
-func (XMLValidator) ValidateSchema(b []byte, filePath string) (bool, error) {
-	schemaLoc, err := extractXSDLocation(b)
+// xmlSyntaxErrors converts a parse error into positioned errors. Well-formedness
+// errors are fatal in XML, so the parser reports at most one of them, but DTD
+// validation can report several, either joined or one per line.
+func xmlSyntaxErrors(err error, rule string) error {
+	items := xmlErrorItems(err, rule)
+	if len(items) > 1 || (len(items) == 1 && items[0].Line > 0) {
+		return syntaxErrors(items)
+	}
+	if rule != "" {
+		return &ValidationError{Err: err, Rule: rule}
+	}
+	return err
+}
+
+// xmlErrorItems splits a parse error into its messages and their positions.
+func xmlErrorItems(err error, rule string) []*ValidationError {
+	msgs := []string{err.Error()}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		msgs = msgs[:0]
+		for _, e := range joined.Unwrap() {
+			msgs = append(msgs, e.Error())
+		}
+	} else if len(xmlLineColRe.FindAllStringIndex(msgs[0], -1)) > 1 {
+		msgs = strings.Split(msgs[0], "\n")
+	}
+
+	var items []*ValidationError
+	for _, msg := range msgs {
+		if strings.TrimSpace(msg) == "" {
+			continue
+		}
+		ve := &ValidationError{Rule: rule}
+		if m := xmlLineColRe.FindStringSubmatch(msg); m != nil {
+			ve.Line, _ = strconv.Atoi(m[1])
+			ve.Column, _ = strconv.Atoi(m[2])
+			msg = xmlStripPosition.ReplaceAllString(msg, "")
+		}
+		ve.Err = fmt.Errorf("%s", strings.TrimSpace(msg))
+		items = append(items, ve)
+	}
+	return items
+}
+
+func (v XMLValidator) ValidateSchema(b []byte, filePath string) (bool, error) {
+	hints, err := extractXSDLocations(b)
+	if err != nil {
+		return false, err
+	}
+	doctype, ok := findDoctype(b)
+	dtd := v.DTD && ok && doctype.external()
+	if dtd {
+		if valid, err := v.validateDTD(b, filePath, ""); !valid {
+			return false, err
+		}
+	}
+	catalog, err := v.catalog()
 	if err != nil {
 		return false, err
 	}
-	if schemaLoc == "" {
+	schemas := v.resolveXSDLocations(hints, filePath, catalog)
+	if len(schemas) == 0 {
+		if dtd {
+			return true, nil
+		}
 		return true, ErrNoSchema
 	}
-
-	schemaPath := resolveXSDPath(schemaLoc, filePath)
-	return ValidateXSD(b, schemaPath)
+	schema, err := v.compileXSD(schemas)
+	if err != nil {
+		return false, err
+	}
+	return validateXSDDocument(b, schema)
 }
 
 // ValidateXSD validates XML bytes against an XSD file at the given path.
-// Exported for use by the CLI when applying external schemas.
+// Exported for use by the CLI when applying external schemas. Compiled
+// schemas are cached per path and shared across calls.
 func ValidateXSD(b []byte, schemaPath string) (bool, error) {
-	ctx := context.Background()
-	parser := helium.NewParser()
+	return XMLValidator{}.ValidateXSD(b, schemaPath)
+}
 
-	schema, err := xsd.NewCompiler().CompileFile(ctx, schemaPath)
+// catalog returns the validator's XML catalog, or nil when it has none.
+func (v XMLValidator) catalog() (*xmlCatalog, error) {
+	if v.Catalog == "" {
+		return nil, nil
+	}
+	return loadXMLCatalog(v.Catalog)
+}
+
+// compileXSD compiles the schemas for a set of namespaces into one. A
+// single local schema is compiled in place; anything else goes through an
+// xsdBundle.
+func (v XMLValidator) compileXSD(schemas []xsdLocation) (*xsd.Schema, error) {
+	catalog, err := v.catalog()
 	if err != nil {
-		return false, fmt.Errorf("schema compilation error: %w", err)
+		return nil, err
 	}
 
-	doc, err := parser.Parse(ctx, b)
+	var schema *xsd.Schema
+	if catalog == nil && len(schemas) == 1 && !isRemoteLocation(schemas[0].Location) {
+		schemaPath := schemas[0].Location
+		schema, err = xsdSchemaCache.get(schemaPath, func() (*xsd.Schema, error) {
+			return xsd.NewCompiler().CompileFile(context.Background(), schemaPath)
+		})
+	} else {
+		schema, err = xsdSchemaCache.get(xsdBundleKey(schemas, v.Catalog, v.RemoteSchemas), func() (*xsd.Schema, error) {
+			return compileXSDBundle(schemas, catalog, v.RemoteSchemas)
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("schema compilation error: %w", err)
+	}
+	return schema, nil
+}
+
+// validateXSDDocument validates XML bytes against a compiled schema.
+func validateXSDDocument(b []byte, schema *xsd.Schema) (bool, error) {
+	ctx := context.Background()
+	doc, err := helium.NewParser().Parse(ctx, b)
 	if err != nil {
 		return false, fmt.Errorf("xml parse error: %w", err)
 	}
 
 	ec := helium.NewErrorCollector(ctx, helium.ErrorLevelNone)
 	if err := xsd.NewValidator(schema).ErrorHandler(ec).Validate(ctx, doc); err != nil {
-		var msgs []string
+		var msgs, rules []string
+		var positions []SchemaErrorPosition
 		for _, e := range ec.Errors() {
-			msgs = append(msgs, cleanXSDError(e.Error()))
+			msg, pos := cleanXSDError(e.Error())
+			msgs = append(msgs, msg)
+			positions = append(positions, pos)
+			rules = append(rules, RuleXMLXSD)
 		}
 		if len(msgs) > 0 {
-			return false, &SchemaErrors{Prefix: "schema validation failed: ", Items: msgs}
+			return false, &SchemaErrors{Prefix: "schema validation failed: ", Items: msgs, Positions: positions, Rules: rules}
 		}
 		return false, fmt.Errorf("schema validation failed: %w", err)
 	}
@@ -118,34 +220,83 @@ func ValidateXSD(b []byte, schemaPath string) (bool, error) {
 
 const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance" //nolint:revive // XSI namespace is a fixed URI; DevSkim: ignore DS137138
 
-func extractXSDLocation(b []byte) (string, error) {
-	decoder := xml.NewDecoder(strings.NewReader(string(b)))
+// xsdLocation is a schema location hint: a namespace, empty for
+// xsi:noNamespaceSchemaLocation, and the location of its schema.
+type xsdLocation struct {
+	Namespace string
+	Location  string
+}
+
+// extractXSDLocations returns the schema location hints on the root
+// element: its xsi:noNamespaceSchemaLocation and each namespace and
+// location pair of its xsi:schemaLocation.
+func extractXSDLocations(b []byte) ([]xsdLocation, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(b))
 	for {
 		tok, err := decoder.Token()
 		if err != nil {
-			return "", nil
+			return nil, nil
 		}
 		start, ok := tok.(xml.StartElement)
 		if !ok {
 			continue
 		}
+		var hints []xsdLocation
 		for _, attr := range start.Attr {
-			if attr.Name.Local != "noNamespaceSchemaLocation" {
+			// schemaLocation is a common attribute name outside xsi, so
+			// only noNamespaceSchemaLocation is checked for a wrong
+			// namespace.
+			if attr.Name.Local != "noNamespaceSchemaLocation" && (attr.Name.Local != "schemaLocation" || attr.Name.Space != xsiNamespace) {
 				continue
 			}
-			if attr.Name.Space == xsiNamespace {
-				return strings.TrimSpace(attr.Value), nil
+			if attr.Name.Space != xsiNamespace {
+				return nil, fmt.Errorf(
+					"%s uses incorrect namespace %q, expected %q",
+					attr.Name.Local, attr.Name.Space, xsiNamespace,
+				)
+			}
+			if attr.Name.Local == "noNamespaceSchemaLocation" {
+				if loc := strings.TrimSpace(attr.Value); loc != "" {
+					hints = append(hints, xsdLocation{Location: loc})
+				}
+				continue
+			}
+			fields := strings.Fields(attr.Value)
+			if len(fields)%2 != 0 {
+				return nil, fmt.Errorf("schemaLocation must list namespace and location pairs, got %d values", len(fields))
+			}
+			for i := 0; i < len(fields); i += 2 {
+				hints = append(hints, xsdLocation{Namespace: fields[i], Location: fields[i+1]})
 			}
-			return "", fmt.Errorf(
-				"noNamespaceSchemaLocation uses incorrect namespace %q, expected %q",
-				attr.Name.Space, xsiNamespace,
-			)
 		}
-		return "", nil
+		return hints, nil
 	}
 }
 
+// resolveXSDLocations resolves the hints of the document at filePath. The
+// catalog maps a hint's location or namespace to a local schema; other
+// locations are URLs or paths relative to the document. URLs are left out
+// unless remote schemas are allowed.
+func (v XMLValidator) resolveXSDLocations(hints []xsdLocation, filePath string, catalog *xmlCatalog) []xsdLocation {
+	resolved := make([]xsdLocation, 0, len(hints))
+	for _, hint := range hints {
+		if target, ok := catalog.resolve(hint.Location, hint.Namespace); ok {
+			hint.Location = target
+		} else if !isRemoteLocation(hint.Location) {
+			hint.Location = resolveXSDPath(hint.Location, filePath)
+		}
+		if isRemoteLocation(hint.Location) && !v.RemoteSchemas {
+			continue
+		}
+		resolved = append(resolved, hint)
+	}
+	return resolved
+}
+
 func resolveXSDPath(schemaLoc, filePath string) string {
+	if path, ok := tools.FileURLToPath(schemaLoc); ok {
+		return path
+	}
 	if filepath.IsAbs(schemaLoc) {
 		return schemaLoc
 	}
@@ -156,13 +307,14 @@ func resolveXSDPath(schemaLoc, filePath string) string {
 	return filepath.Join(dir, schemaLoc)
 }
 
-// cleanXSDError reformats helium XSD errors from
-// "(string):5: Schemas validity error : Element 'port': ...\n"
-// to "line 5: Element 'port': ..."
-func cleanXSDError(s string) string {
+// cleanXSDError splits helium XSD errors such as
+// "(string):5: Schemas validity error : Element 'port': ...\n" into the
+// message, "Element 'port': ...", and its line.
+func cleanXSDError(s string) (string, SchemaErrorPosition) {
 	s = strings.TrimSpace(s)
 	if m := xsdErrorLineRe.FindStringSubmatch(s); m != nil {
-		return fmt.Sprintf("line %s: %s", m[1], m[2])
+		line, _ := strconv.Atoi(m[1])
+		return m[2], SchemaErrorPosition{Line: line}
 	}
-	return s
+	return s, SchemaErrorPosition{}
 }
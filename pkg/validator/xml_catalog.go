@@ -0,0 +1,180 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/tools"
+)
+
+// xmlCatalogCache holds loaded catalogs, keyed like compiled schemas so that
+// an edited catalog is reloaded.
+var xmlCatalogCache schemaCache[*xmlCatalog]
+
+// xmlCatalog maps namespace URIs, schema locations and DTD identifiers to
+// local files. It reads the uri, system, public, rewriteURI, rewriteSystem
+// and nextCatalog entries of OASIS XML catalogs, the format xmllint and most
+// XML tooling use.
+type xmlCatalog struct {
+	entries  map[string]string
+	rewrites []xmlCatalogRewrite
+	// files are the catalog files read, in order.
+	files []string
+}
+
+type xmlCatalogRewrite struct {
+	prefix      string
+	replacement string
+}
+
+// loadXMLCatalog reads the catalog at path and the catalogs it chains to
+// with nextCatalog. Relative targets resolve against the catalog's own
+// directory.
+func loadXMLCatalog(path string) (*xmlCatalog, error) {
+	return xmlCatalogCache.get(path, func() (*xmlCatalog, error) {
+		c := &xmlCatalog{entries: make(map[string]string)}
+		if err := c.load(path, map[string]bool{}); err != nil {
+			return nil, err
+		}
+		return c, nil
+	})
+}
+
+func (c *xmlCatalog) load(path string, seen map[string]bool) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if seen[abs] {
+		return nil
+	}
+	seen[abs] = true
+	c.files = append(c.files, abs)
+
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return fmt.Errorf("reading XML catalog: %w", err)
+	}
+	dir := filepath.Dir(abs)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	var next []string
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("XML catalog %s: %w", path, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		attr := func(name string) string { return xmlAttr(start, name) }
+		switch start.Name.Local {
+		case "uri":
+			c.add(attr("name"), resolveCatalogTarget(attr("uri"), dir))
+		case "system":
+			c.add(attr("systemId"), resolveCatalogTarget(attr("uri"), dir))
+		case "public":
+			c.add(attr("publicId"), resolveCatalogTarget(attr("uri"), dir))
+		case "rewriteURI":
+			c.addRewrite(attr("uriStartString"), resolveCatalogTarget(attr("rewritePrefix"), dir))
+		case "rewriteSystem":
+			c.addRewrite(attr("systemIdStartString"), resolveCatalogTarget(attr("rewritePrefix"), dir))
+		case "nextCatalog":
+			if target := attr("catalog"); target != "" {
+				next = append(next, resolveCatalogTarget(target, dir))
+			}
+		default:
+		}
+	}
+	for _, path := range next {
+		if err := c.load(path, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add keeps the first entry for a name, as catalog resolution does.
+func (c *xmlCatalog) add(name, target string) {
+	if name == "" || target == "" {
+		return
+	}
+	if _, ok := c.entries[name]; !ok {
+		c.entries[name] = target
+	}
+}
+
+func (c *xmlCatalog) addRewrite(prefix, replacement string) {
+	if prefix != "" && replacement != "" {
+		c.rewrites = append(c.rewrites, xmlCatalogRewrite{prefix: prefix, replacement: replacement})
+	}
+}
+
+// resolve returns the local file or URL the catalog maps the first of
+// names, namespace URIs, schema locations or DTD identifiers, to. Exact entries for any name
+// win over rewrites, and the longest matching rewrite prefix wins among
+// those.
+func (c *xmlCatalog) resolve(names ...string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	for _, name := range names {
+		if target, ok := c.entries[name]; ok && name != "" {
+			return target, true
+		}
+	}
+	for _, name := range names {
+		if target, ok := c.rewrite(name); ok {
+			return target, true
+		}
+	}
+	return "", false
+}
+
+func (c *xmlCatalog) rewrite(name string) (string, bool) {
+	best := -1
+	for i, r := range c.rewrites {
+		if name != "" && strings.HasPrefix(name, r.prefix) && (best < 0 || len(r.prefix) > len(c.rewrites[best].prefix)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return "", false
+	}
+	r := c.rewrites[best]
+	rest := strings.TrimPrefix(name, r.prefix)
+	if isRemoteLocation(r.replacement) {
+		return r.replacement + rest, true
+	}
+	return filepath.Join(r.replacement, filepath.FromSlash(rest)), true
+}
+
+// resolveCatalogTarget turns a catalog target into an absolute path, or
+// keeps it when it is a remote URL.
+func resolveCatalogTarget(target, dir string) string {
+	if target == "" || isRemoteLocation(target) {
+		return target
+	}
+	if path, ok := tools.FileURLToPath(target); ok {
+		return path
+	}
+	if filepath.IsAbs(target) {
+		return target
+	}
+	return filepath.Join(dir, filepath.FromSlash(target))
+}
+
+// isRemoteLocation reports whether a schema location is an HTTP(S) URL.
+func isRemoteLocation(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
@@ -0,0 +1,342 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lestrrat-go/helium"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/tools"
+)
+
+// DTD validation lets the parser load the DTD a document names, but only
+// through a dtdEntityLoader, so that what gets loaded, and from where, is
+// decided by the catalog and the ExternalEntities option alone.
+
+var (
+	dtdCommentRe        = regexp.MustCompile(`(?s)<!--.*?-->`)
+	dtdExternalEntityRe = regexp.MustCompile(`<!ENTITY\s+(?:%\s+)?[^\s%]+\s+(?:SYSTEM|PUBLIC)\s`)
+)
+
+// IsDTDSchema reports whether a --schema-map schema is a DTD, named by its
+// .dtd extension.
+func IsDTDSchema(schemaPath string) bool {
+	return strings.EqualFold(filepath.Ext(schemaPath), ".dtd")
+}
+
+// ValidateDTD validates XML bytes against the DTD at dtdPath, whatever
+// DOCTYPE the document declares. Exported for use by the CLI when applying
+// external schemas.
+func ValidateDTD(b []byte, dtdPath string) (bool, error) {
+	return XMLValidator{}.ValidateDTD(b, dtdPath)
+}
+
+// ValidateDTD satisfies the DTDSchemaValidator interface. The catalog and
+// ExternalEntities apply to the entities the DTD loads.
+func (v XMLValidator) ValidateDTD(b []byte, dtdPath string) (bool, error) {
+	return v.validateDTD(b, "", dtdPath)
+}
+
+// xmlDoctype is the DOCTYPE declaration of a document, which spans the
+// bytes from start to end.
+type xmlDoctype struct {
+	start, end  int
+	root        string
+	publicID    string
+	systemID    string
+	internal    string
+	hasInternal bool
+}
+
+// selfContained reports whether the DOCTYPE declares everything it needs in
+// its internal subset, without an external DTD or external entities.
+func (d xmlDoctype) selfContained() bool {
+	return d.hasInternal && !d.external() && !dtdExternalEntityRe.MatchString(dtdCommentRe.ReplaceAllString(d.internal, ""))
+}
+
+// external reports whether the DOCTYPE names an external DTD.
+func (d xmlDoctype) external() bool {
+	return d.systemID != "" || d.publicID != ""
+}
+
+// findDoctype returns the DOCTYPE declaration of b. Like the XML spec, it
+// only looks before the root element, so a DOCTYPE in a comment or after
+// the root does not count.
+func findDoctype(b []byte) (xmlDoctype, bool) {
+	decoder := xml.NewDecoder(bytes.NewReader(b))
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	for {
+		offset := int(decoder.InputOffset())
+		tok, err := decoder.RawToken()
+		if err != nil {
+			return xmlDoctype{}, false
+		}
+		switch tok.(type) {
+		case xml.Directive:
+			end := int(decoder.InputOffset())
+			if d, ok := parseDoctype(string(b[offset:end])); ok {
+				d.start, d.end = offset, end
+				return d, true
+			}
+		case xml.StartElement:
+			return xmlDoctype{}, false
+		default:
+		}
+	}
+}
+
+// rootDoctype returns an empty DOCTYPE for the root element of b, placed
+// right before it.
+func rootDoctype(b []byte) (xmlDoctype, bool) {
+	decoder := xml.NewDecoder(bytes.NewReader(b))
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	for {
+		offset := int(decoder.InputOffset())
+		tok, err := decoder.RawToken()
+		if err != nil {
+			return xmlDoctype{}, false
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			root := start.Name.Local
+			if start.Name.Space != "" {
+				root = start.Name.Space + ":" + root
+			}
+			return xmlDoctype{start: offset, end: offset, root: root}, true
+		}
+	}
+}
+
+// parseDoctype parses a "<!DOCTYPE ...>" declaration.
+func parseDoctype(decl string) (xmlDoctype, bool) {
+	rest, ok := strings.CutPrefix(decl, "<!DOCTYPE")
+	if !ok {
+		return xmlDoctype{}, false
+	}
+	rest = strings.TrimSuffix(rest, ">")
+
+	var d xmlDoctype
+	d.root, rest = dtdName(rest)
+	if d.root == "" {
+		return xmlDoctype{}, false
+	}
+	keyword, after := dtdName(rest)
+	switch keyword {
+	case "SYSTEM":
+		d.systemID, rest, ok = dtdLiteral(after)
+	case "PUBLIC":
+		if d.publicID, rest, ok = dtdLiteral(after); ok {
+			d.systemID, rest, ok = dtdLiteral(rest)
+		}
+	default:
+	}
+	if !ok {
+		return xmlDoctype{}, false
+	}
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "[") {
+		if end := strings.LastIndex(rest, "]"); end > 0 {
+			d.internal, d.hasInternal = rest[1:end], true
+		}
+	}
+	return d, true
+}
+
+// dtdName reads the name at the start of s, after any whitespace.
+func dtdName(s string) (string, string) {
+	s = strings.TrimLeft(s, " \t\r\n")
+	end := strings.IndexAny(s, " \t\r\n[>\"'%;")
+	if end < 0 {
+		end = len(s)
+	}
+	return s[:end], s[end:]
+}
+
+// dtdLiteral reads the quoted literal at the start of s, after any
+// whitespace.
+func dtdLiteral(s string) (string, string, bool) {
+	s = strings.TrimLeft(s, " \t\r\n")
+	if s == "" || (s[0] != '"' && s[0] != '\'') {
+		return "", s, false
+	}
+	end := strings.IndexByte(s[1:], s[0])
+	if end < 0 {
+		return "", s, false
+	}
+	return s[1 : end+1], s[end+2:], true
+}
+
+// validateDTD validates b against the DTD at dtdPath, or against its own
+// DOCTYPE when dtdPath is empty. filePath locates the document, for the
+// relative system identifiers of its DOCTYPE.
+func (v XMLValidator) validateDTD(b []byte, filePath, dtdPath string) (bool, error) {
+	catalog, err := v.catalog()
+	if err != nil {
+		return false, err
+	}
+	doctype, ok := findDoctype(b)
+	doc, shift := b, dtdShift{}
+	if dtdPath != "" {
+		if !ok {
+			if doctype, ok = rootDoctype(b); !ok {
+				return false, errors.New("xml parse error: document has no root element")
+			}
+		}
+		doctype.systemID, doctype.publicID = dtdPath, ""
+		if !isRemoteLocation(dtdPath) {
+			abs, err := filepath.Abs(dtdPath)
+			if err != nil {
+				return false, err
+			}
+			doctype.systemID = tools.FileURL(abs)
+		}
+		doc, shift = withDoctype(b, doctype)
+	}
+
+	if filePath != "" {
+		if filePath, err = filepath.Abs(filePath); err != nil {
+			return false, err
+		}
+	}
+	loader := &dtdEntityLoader{
+		catalog:  catalog,
+		dtd:      dtdLocation(catalog, doctype.systemID, doctype.publicID, filePath),
+		external: v.ExternalEntities,
+	}
+	parser := helium.NewParser().LoadExternalDTD(true).EntityLoader(loader)
+	if filePath != "" {
+		parser = parser.BaseURI(filePath)
+	}
+
+	ctx := context.Background()
+	if _, err := parser.Parse(ctx, doc); err != nil || loader.err != nil {
+		if loader.err != nil {
+			err = loader.err
+		}
+		return false, fmt.Errorf("schema compilation error: %w", err)
+	}
+	if _, err := parser.ValidateDTD(true).Parse(ctx, doc); err != nil {
+		if loader.err != nil {
+			return false, fmt.Errorf("schema compilation error: %w", loader.err)
+		}
+		se := &SchemaErrors{Prefix: "schema validation failed: "}
+		for _, item := range xmlErrorItems(err, RuleXMLDTD) {
+			se.Items = append(se.Items, item.Err.Error())
+			se.Positions = append(se.Positions, shift.apply(item.Line, item.Column))
+			se.Rules = append(se.Rules, RuleXMLDTD)
+		}
+		return false, se
+	}
+	return true, nil
+}
+
+// dtdEntityLoader is the parser's only way to load external entities. It
+// resolves them through the catalog, loads the document's DTD and refuses
+// everything else, including remote DTDs, unless external is set. The
+// first refusal is kept in err, since the parser may only report that the
+// entity could not be loaded.
+type dtdEntityLoader struct {
+	catalog  *xmlCatalog
+	dtd      string
+	external bool
+	err      error
+}
+
+func (l *dtdEntityLoader) LoadEntity(_ context.Context, uri, publicID string) (io.ReadCloser, error) {
+	data, err := l.load(uri, publicID)
+	if err != nil {
+		if l.err == nil {
+			l.err = err
+		}
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (l *dtdEntityLoader) load(uri, publicID string) ([]byte, error) {
+	location := dtdLocation(l.catalog, uri, publicID, "")
+	switch {
+	case location == "":
+		return nil, fmt.Errorf("no XML catalog entry for public identifier %q", publicID)
+	case l.external:
+	case isRemoteLocation(location):
+		return nil, fmt.Errorf("%s is remote; map it to a local file with an XML catalog or enable external-entities to download it", location)
+	case location != l.dtd:
+		return nil, fmt.Errorf("external entity %s is not loaded; enable external-entities to load it", location)
+	default:
+	}
+	return readXMLSchema(location, true)
+}
+
+// dtdLocation returns the DTD a DOCTYPE names, through the catalog first.
+func dtdLocation(catalog *xmlCatalog, systemID, publicID, base string) string {
+	if target, ok := catalog.resolve(systemID, publicID); ok {
+		return target
+	}
+	if systemID == "" {
+		return ""
+	}
+	return resolveXSDReference(systemID, base)
+}
+
+// dtdShift maps the columns of the rewritten document back to the
+// original on the line where the DOCTYPE was replaced, when its length
+// could not be kept.
+type dtdShift struct {
+	line  int
+	after int
+	delta int
+}
+
+func (s dtdShift) apply(line, col int) SchemaErrorPosition {
+	if s.delta != 0 && line == s.line && col > s.after {
+		col -= s.delta
+	}
+	return SchemaErrorPosition{Line: line, Column: col}
+}
+
+// withDoctype replaces the DOCTYPE of b with d, keeping its internal
+// subset. The new DOCTYPE is on one line, followed by as many line breaks
+// as the old one spanned, so that the document keeps its line numbers.
+func withDoctype(b []byte, d xmlDoctype) ([]byte, dtdShift) {
+	decl := "<!DOCTYPE " + d.root
+	switch {
+	case d.publicID != "":
+		decl += ` PUBLIC "` + d.publicID + `" "` + d.systemID + `"`
+	case d.systemID != "":
+		decl += ` SYSTEM "` + d.systemID + `"`
+	default:
+	}
+	if d.hasInternal {
+		decl += " [" + strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(d.internal) + "]"
+	}
+	decl += ">"
+	old := b[d.start:d.end]
+	lineStart := bytes.LastIndexByte(b[:d.start], '\n') + 1
+
+	var shift dtdShift
+	pad := ""
+	if breaks := bytes.Count(old, []byte("\n")); breaks > 0 {
+		lastLine := bytes.LastIndexByte(b[:d.end], '\n') + 1
+		pad = strings.Repeat("\n", breaks) + strings.Repeat(" ", d.end-lastLine)
+	} else {
+		shift = dtdShift{
+			line:  bytes.Count(b[:d.start], []byte("\n")) + 1,
+			after: d.start - lineStart + len(decl),
+			delta: len(decl) - len(old),
+		}
+	}
+
+	doc := make([]byte, 0, len(b)+len(decl)+len(pad))
+	doc = append(doc, b[:d.start]...)
+	doc = append(doc, decl...)
+	doc = append(doc, pad...)
+	doc = append(doc, b[d.end:]...)
+	return doc, shift
+}
@@ -0,0 +1,326 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/helium/xsd"
+
+	"github.com/Boeing/config-file-validator/v2/pkg/tools"
+)
+
+const (
+	xsdNamespace = "http://www.w3.org/2001/XMLSchema" //nolint:revive // XSD namespace is a fixed URI; DevSkim: ignore DS137138
+
+	// xsdBundleRoot is the file name of the schema that imports the others
+	// when a document names several.
+	xsdBundleRoot = "cfv-schemas.xsd"
+
+	// xsdFetchTimeout bounds the download of a remote schema.
+	xsdFetchTimeout = 30 * time.Second
+
+	// xmlSchemaCacheTTL is how long a downloaded schema is reused before
+	// it is downloaded again.
+	xmlSchemaCacheTTL = 24 * time.Hour
+)
+
+// xmlSchemaClient downloads remote schemas.
+var xmlSchemaClient = &http.Client{Timeout: xsdFetchTimeout}
+
+var schemaLocationAttrRe = regexp.MustCompile(`(\sschemaLocation\s*=\s*)(?:"[^"]*"|'[^']*')`)
+
+// xsdBundle copies schemas, and every schema they import, include,
+// redefine or override, into one directory, pointing their references at
+// the copies. References resolve through the catalog first, so schemas
+// compile from local files where the catalog has them. Remote schemas are
+// downloaded once when remote is set, and are an error otherwise.
+type xsdBundle struct {
+	dir     string
+	catalog *xmlCatalog
+	remote  bool
+	// names maps a schema location to the name of its copy in dir.
+	names map[string]string
+}
+
+// compileXSDBundle compiles the schemas for a set of namespaces as one
+// schema. When there are several, a root schema includes the one without
+// a namespace and imports the others.
+func compileXSDBundle(schemas []xsdLocation, catalog *xmlCatalog, remote bool) (*xsd.Schema, error) {
+	dir, err := os.MkdirTemp("", "cfv-xsd-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	bundle := &xsdBundle{dir: dir, catalog: catalog, remote: remote, names: make(map[string]string)}
+	root, err := bundle.write(schemas)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := xsd.NewCompiler().CompileFile(context.Background(), root)
+	if err != nil {
+		return nil, bundle.restoreLocations(err)
+	}
+	return schema, nil
+}
+
+// write copies the schemas into the bundle and returns the path of the
+// schema to compile.
+func (bn *xsdBundle) write(schemas []xsdLocation) (string, error) {
+	var root bytes.Buffer
+	root.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	root.WriteString(`<xs:schema xmlns:xs="` + xsdNamespace + `">` + "\n")
+	for _, s := range schemas {
+		name, err := bn.add(s.Location)
+		if err != nil {
+			return "", err
+		}
+		if len(schemas) == 1 {
+			return filepath.Join(bn.dir, name), nil
+		}
+		if s.Namespace == "" {
+			fmt.Fprintf(&root, "  <xs:include schemaLocation=\"%s\"/>\n", name)
+		} else {
+			fmt.Fprintf(&root, "  <xs:import namespace=\"%s\" schemaLocation=\"%s\"/>\n", xmlAttrEscape(s.Namespace), name)
+		}
+	}
+	root.WriteString("</xs:schema>\n")
+	path := filepath.Join(bn.dir, xsdBundleRoot)
+	return path, os.WriteFile(path, root.Bytes(), 0o600)
+}
+
+// add copies the schema at location and the schemas it references, and
+// returns the name of its copy.
+func (bn *xsdBundle) add(location string) (string, error) {
+	if name, ok := bn.names[location]; ok {
+		return name, nil
+	}
+	name := fmt.Sprintf("schema%d.xsd", len(bn.names)+1)
+	bn.names[location] = name
+
+	data, err := readXMLSchema(location, bn.remote)
+	if err != nil {
+		return "", err
+	}
+	data, err = bn.rewriteReferences(data, location)
+	if err != nil {
+		return "", err
+	}
+	return name, os.WriteFile(filepath.Join(bn.dir, name), data, 0o600)
+}
+
+// rewriteReferences adds the schemas the schema at location references to
+// the bundle and points its schemaLocation attributes at their copies. The
+// rest of the schema is kept byte for byte.
+func (bn *xsdBundle) rewriteReferences(data []byte, location string) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// Only ASCII attribute values are rewritten, so the bytes can be read
+	// as they are whatever the declared encoding.
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	var out bytes.Buffer
+	var last int64
+	for {
+		offset := decoder.InputOffset()
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading schema %s: %w", location, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Space != xsdNamespace {
+			continue
+		}
+		ref := xsdLocation{}
+		switch start.Name.Local {
+		case "import":
+			ref.Namespace = xmlAttr(start, "namespace")
+			ref.Location = xmlAttr(start, "schemaLocation")
+		case "include", "redefine", "override":
+			ref.Location = xmlAttr(start, "schemaLocation")
+		default:
+			continue
+		}
+		target := bn.resolve(ref, location)
+		if target == "" {
+			continue
+		}
+		name, err := bn.add(target)
+		if err != nil {
+			return nil, err
+		}
+		end := decoder.InputOffset()
+		out.Write(data[last:offset])
+		out.Write(setSchemaLocation(data[offset:end], name))
+		last = end
+	}
+	out.Write(data[last:])
+	return out.Bytes(), nil
+}
+
+// resolve returns the schema a reference from the schema at base points
+// to, or "" when it names no schema the catalog knows and no location.
+func (bn *xsdBundle) resolve(ref xsdLocation, base string) string {
+	location := ""
+	if ref.Location != "" {
+		location = resolveXSDReference(ref.Location, base)
+	}
+	if target, ok := bn.catalog.resolve(ref.Location, ref.Namespace, location); ok {
+		return target
+	}
+	return location
+}
+
+// restoreLocations replaces the paths of the copies in a compilation error
+// with the locations they were copied from.
+func (bn *xsdBundle) restoreLocations(err error) error {
+	var pairs []string
+	for _, location := range slices.Sorted(maps.Keys(bn.names)) {
+		pairs = append(pairs, filepath.Join(bn.dir, bn.names[location]), location)
+	}
+	msg := err.Error()
+	restored := strings.NewReplacer(pairs...).Replace(msg)
+	if restored == msg {
+		return err
+	}
+	return errors.New(restored)
+}
+
+// resolveXSDReference resolves a schemaLocation against the location of
+// the schema that contains it.
+func resolveXSDReference(location, base string) string {
+	if isRemoteLocation(location) {
+		return location
+	}
+	if path, ok := tools.FileURLToPath(location); ok {
+		return path
+	}
+	if isRemoteLocation(base) {
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return location
+		}
+		ref, err := url.Parse(location)
+		if err != nil {
+			return location
+		}
+		return baseURL.ResolveReference(ref).String()
+	}
+	if filepath.IsAbs(location) {
+		return location
+	}
+	return filepath.Join(filepath.Dir(base), filepath.FromSlash(location))
+}
+
+// readXMLSchema reads a local schema, or a remote one when remote is set.
+// Remote schemas are cached on disk for xmlSchemaCacheTTL.
+func readXMLSchema(location string, remote bool) ([]byte, error) {
+	if !isRemoteLocation(location) {
+		return os.ReadFile(location)
+	}
+	if !remote {
+		return nil, fmt.Errorf("schema %s is remote; map it to a local file with an XML catalog or set remote-schemas to download it", location)
+	}
+
+	cachePath := xmlSchemaCachePath(location)
+	if info, err := os.Stat(cachePath); err == nil && time.Since(info.ModTime()) < xmlSchemaCacheTTL {
+		if data, err := os.ReadFile(cachePath); err == nil {
+			return data, nil
+		}
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching schema: %w", err)
+	}
+	resp, err := xmlSchemaClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching schema: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching schema %s: HTTP %d", location, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fetching schema %s: %w", location, err)
+	}
+	// The cache is an optimisation; a schema that cannot be stored is
+	// downloaded again next time.
+	if cachePath != "" && os.MkdirAll(filepath.Dir(cachePath), 0o755) == nil {
+		_ = os.WriteFile(cachePath, data, 0o644)
+	}
+	return data, nil
+}
+
+// xmlSchemaCachePath returns where a remote schema is cached, laid out by
+// host and path as SchemaStore schemas are, or "" when it cannot be
+// cached.
+func xmlSchemaCachePath(location string) string {
+	root, err := tools.CacheDir()
+	if err != nil {
+		return ""
+	}
+	u, err := url.Parse(location)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	name := path.Clean("/" + u.Path)
+	if name == "/" {
+		return ""
+	}
+	return filepath.Join(root, "schemas", u.Host, filepath.FromSlash(name))
+}
+
+// xsdBundleKey identifies a set of schemas and a catalog in the schema
+// cache. Local files contribute their size and modification time, so that
+// an edited schema is recompiled.
+func xsdBundleKey(schemas []xsdLocation, catalogPath string, remote bool) string {
+	parts := []string{schemaCacheKey(catalogPath), strconv.FormatBool(remote)}
+	for _, s := range schemas {
+		parts = append(parts, s.Namespace, schemaCacheKey(s.Location))
+	}
+	return strings.Join(parts, "\x01")
+}
+
+// setSchemaLocation sets the schemaLocation attribute of a start tag.
+func setSchemaLocation(tag []byte, location string) []byte {
+	value := `"` + xmlAttrEscape(location) + `"`
+	if loc := schemaLocationAttrRe.FindSubmatchIndex(tag); loc != nil {
+		return slices.Concat(tag[:loc[3]], []byte(value), tag[loc[1]:])
+	}
+	i := bytes.IndexAny(tag, " \t\r\n/>")
+	return slices.Concat(tag[:i], []byte(" schemaLocation="+value), tag[i:])
+}
+
+func xmlAttr(start xml.StartElement, name string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return strings.TrimSpace(attr.Value)
+		}
+	}
+	return ""
+}
+
+func xmlAttrEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
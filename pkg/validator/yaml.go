@@ -44,6 +44,11 @@ func (YAMLValidator) MarshalToJSON(b []byte) ([]byte, error) {
 	return json.Marshal(doc)
 }
 
+// PositionMap locates the keys and items of the document in b.
+func (YAMLValidator) PositionMap(b []byte) map[string]SourcePosition {
+	return buildYAMLPositionMap(b)
+}
+
 func (YAMLValidator) ValidateSchema(b []byte, filePath string) (bool, error) {
 	schemaURL := extractYAMLSchemaComment(b)
 	if schemaURL == "" {
@@ -21,6 +21,7 @@ const sidebars: SidebarsConfig = {
         'guides/glob-patterns',
         'guides/file-type-detection',
         'guides/filtering-exclusion',
+        'guides/suppressing-errors',
         'guides/output-reporters',
         'guides/stdin',
       ],
@@ -37,6 +38,7 @@ const sidebars: SidebarsConfig = {
           label: 'CI/CD Pipelines',
         },
         'integrations/go-library',
+        'integrations/editors',
       ],
     },
     {
@@ -46,6 +48,7 @@ const sidebars: SidebarsConfig = {
         'reference/cli-flags',
         'reference/configuration-keys',
         'reference/environment-variables',
+        'reference/rules',
         'reference/supported-file-types',
         'reference/exit-codes',
         'reference/known-files',